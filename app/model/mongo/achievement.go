@@ -21,10 +21,14 @@ type Achievement struct {
 	Details  map[string]interface{} `bson:"details" json:"details"`     // flexible dynamic fields
 	Tags     []string               `bson:"tags,omitempty" json:"tags"` // optional custom tags
 
+	// Certifications: end of the validity period, derived from details.expiry
+	ExpiresAt *time.Time `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+
 	// Attachments (URLs, file names, metadata)
 	Attachments []Attachment `bson:"attachments,omitempty" json:"attachments"`
 
 	// Metadata
+	Version   int        `bson:"version" json:"version"` // +1 on every update; earlier versions live in achievement_versions
 	CreatedAt time.Time  `bson:"createdAt" json:"createdAt"`
 	UpdatedAt time.Time  `bson:"updatedAt" json:"updatedAt"`
 	DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
@@ -32,8 +36,22 @@ type Achievement struct {
 
 // Attachment represents a single file stored externally (e.g. Cloud Storage / server folder).
 type Attachment struct {
-	FileName string `bson:"fileName" json:"fileName"`
-	URL      string `bson:"url" json:"url"`
-	MimeType string `bson:"mimeType" json:"mimeType"`
-	Size     int64  `bson:"size" json:"size"` // bytes
+	ID         string    `bson:"id" json:"id"`
+	FileName   string    `bson:"fileName" json:"fileName"`
+	URL        string    `bson:"url" json:"url"`           // download route, requires a token (or use the signed URL)
+	MimeType   string    `bson:"mimeType" json:"mimeType"` // sniffed from content, not the client header
+	Size       int64     `bson:"size" json:"size"`         // bytes
+	SHA256     string    `bson:"sha256" json:"sha256"`     // hex digest of the content
+	StorageKey string    `bson:"storageKey" json:"-"`      // key in storage.Storage
+	UploadedBy string    `bson:"uploadedBy" json:"uploadedBy"`
+	UploadedAt time.Time `bson:"uploadedAt" json:"uploadedAt"`
+}
+
+// AchievementVersion is the state of an achievement document before an update replaced it.
+type AchievementVersion struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	AchievementID primitive.ObjectID `bson:"achievementId" json:"achievementId"`
+	Version       int                `bson:"version" json:"version"`
+	Document      Achievement        `bson:"document" json:"document"`
+	SupersededAt  time.Time          `bson:"supersededAt" json:"supersededAt"` // when the next version replaced it
 }
@@ -2,6 +2,10 @@ package postgres
 
 import "time"
 
+// AchievementExpired is the derived status of a verified achievement whose expiry date has
+// passed. It is never stored: the row keeps status verified.
+const AchievementExpired = "expired"
+
 type AchievementReference struct {
 	ID                 string     `db:"id" json:"id"`                                     // uuid
 	StudentID          string     `db:"student_id" json:"student_id"`                     // FK -> students.id
@@ -11,6 +15,17 @@ type AchievementReference struct {
 	VerifiedAt         *time.Time `db:"verified_at" json:"verified_at"`
 	VerifiedBy         *string    `db:"verified_by" json:"verified_by"` // FK -> users.id (verifier)
 	RejectionNote      *string    `db:"rejection_note" json:"rejection_note"`
+	Revision           int        `db:"revision" json:"revision"`                   // review round, +1 on every resubmission
+	Version            int        `db:"version" json:"version"`                     // row version for If-Match, +1 on every update
+	Points             *float64   `db:"points" json:"points"`                       // SKPI points awarded at verification, before semester caps
+	PointsRuleID       *string    `db:"points_rule_id" json:"points_rule_id"`       // FK -> achievement_scoring_rules.id
+	PointsSemester     *string    `db:"points_semester" json:"points_semester"`     // e.g. 2025/2026-1 (ganjil), 2025/2026-2 (genap)
+	ExpiresAt          *time.Time `db:"expires_at" json:"expires_at"`               // certifications: when validity ends (copy of the document's expiresAt)
+	ExpiryFlaggedAt    *time.Time `db:"expiry_flagged_at" json:"expiry_flagged_at"` // when the owner was warned about the coming expiry
+	RenewalOf          *string    `db:"renewal_of" json:"renewal_of"`               // FK -> achievement_references.id of the renewed certificate
+	DeletedAt          *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`     // moved to the trash (status deleted)
+	DeletedBy          *string    `db:"deleted_by" json:"deleted_by,omitempty"`     // FK -> users.id
+	DeletedFrom        *string    `db:"deleted_from" json:"deleted_from,omitempty"` // status to restore
 	CreatedAt          time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt          time.Time  `db:"updated_at" json:"updated_at"`
 }
@@ -0,0 +1,12 @@
+package postgres
+
+import "time"
+
+// AchievementAttachment indexes one file attached to an achievement; the file's metadata
+// lives in the Mongo document.
+type AchievementAttachment struct {
+	ID          string    `db:"id" json:"id"`                     // attachment id in the document
+	ReferenceID string    `db:"reference_id" json:"reference_id"` // FK -> achievement_references.id
+	SHA256      string    `db:"sha256" json:"sha256"`             // empty for files stored before hashing
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package postgres
+
+import "time"
+
+// AchievementComment is one message in the review discussion of an achievement.
+// Top-level comments start a thread; replies point at it through ParentID.
+type AchievementComment struct {
+	ID          string    `db:"id" json:"id"`
+	ReferenceID string    `db:"reference_id" json:"reference_id"` // FK -> achievement_references.id
+	ParentID    *string   `db:"parent_id" json:"parent_id"`
+	Round       int       `db:"round" json:"round"` // review round the comment was written in
+	AuthorID    *string   `db:"author_id" json:"author_id"`
+	AuthorRole  string    `db:"author_role" json:"author_role"` // owner, advisor, delegate, admin
+	FieldPath   *string   `db:"field_path" json:"field_path"`   // e.g. details.rank
+	Body        string    `db:"body" json:"body"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+
+	Unread  bool                  `db:"-" json:"unread"`
+	Replies []*AchievementComment `db:"-" json:"replies,omitempty"`
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import "time"
+
+// Team member roles and invitation states
+const (
+	MemberRoleLeader = "leader" // the student who created the achievement (achievement_references.student_id)
+	MemberRoleMember = "member"
+
+	MemberInvited  = "invited"
+	MemberAccepted = "accepted"
+	MemberDeclined = "declined"
+)
+
+// AchievementMember is one student credited for a (team) achievement.
+type AchievementMember struct {
+	ReferenceID string     `db:"reference_id" json:"reference_id"` // FK -> achievement_references.id
+	StudentID   string     `db:"student_id" json:"student_id"`     // FK -> students.id
+	Role        string     `db:"role" json:"role"`                 // leader, member
+	Status      string     `db:"status" json:"status"`             // invited, accepted, declined
+	InvitedBy   *string    `db:"invited_by" json:"invited_by"`     // FK -> users.id
+	InvitedAt   time.Time  `db:"invited_at" json:"invited_at"`
+	RespondedAt *time.Time `db:"responded_at" json:"responded_at"`
+}
@@ -0,0 +1,13 @@
+package postgres
+
+import "time"
+
+// AchievementSchema is the JSON Schema the details of every achievement in a category must satisfy.
+type AchievementSchema struct {
+	Category    string                 `db:"category" json:"category"` // lomba, sertifikasi, publikasi, ...
+	Description string                 `db:"description" json:"description"`
+	Schema      map[string]interface{} `db:"schema" json:"schema"`         // JSON Schema for mongo.Achievement.Details
+	UpdatedBy   *string                `db:"updated_by" json:"updated_by"` // FK -> users.id
+	CreatedAt   time.Time              `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time              `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package postgres
+
+import "time"
+
+// VerificationDelegation lets a delegate lecturer verify the advisor's advisees during [StartsAt, EndsAt).
+type VerificationDelegation struct {
+	ID         string     `db:"id" json:"id"`
+	AdvisorID  string     `db:"advisor_id" json:"advisor_id"`   // FK -> lecturers.id
+	DelegateID string     `db:"delegate_id" json:"delegate_id"` // FK -> lecturers.id
+	StartsAt   time.Time  `db:"starts_at" json:"starts_at"`
+	EndsAt     time.Time  `db:"ends_at" json:"ends_at"`
+	Reason     string     `db:"reason" json:"reason"`
+	CreatedBy  *string    `db:"created_by" json:"created_by"` // FK -> users.id
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+}
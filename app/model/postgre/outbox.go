@@ -0,0 +1,28 @@
+package postgres
+
+import "time"
+
+// Outbox operations on the achievements collection
+const (
+	OutboxCreateDocument     = "create_document"
+	OutboxUpdateDocument     = "update_document"
+	OutboxSoftDeleteDocument = "soft_delete_document"
+	OutboxRestoreDocument    = "restore_document"
+	OutboxPurgeDocument      = "purge_document" // also deletes versions and attachment files
+	OutboxAddAttachment      = "add_attachment"
+	OutboxRemoveAttachment   = "remove_attachment" // also deletes the stored file
+)
+
+// OutboxMessage is a pending Mongo write recorded together with its Postgres change.
+type OutboxMessage struct {
+	ID            string     `db:"id" json:"id"`
+	ReferenceID   string     `db:"reference_id" json:"reference_id"` // achievement_references.id
+	Operation     string     `db:"operation" json:"operation"`
+	Payload       []byte     `db:"payload" json:"-"`     // MongoDB extended JSON
+	Status        string     `db:"status" json:"status"` // pending, done, failed
+	Attempts      int        `db:"attempts" json:"attempts"`
+	LastError     *string    `db:"last_error" json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	ProcessedAt   *time.Time `db:"processed_at" json:"processed_at,omitempty"`
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import "time"
+
+// FieldChange is one changed field between two review rounds; Path uses dots for nested fields (details.rank).
+type FieldChange struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// DuplicateMatch is an existing achievement that looks like the one being submitted.
+type DuplicateMatch struct {
+	ReferenceID string   `json:"reference_id"`
+	StudentID   string   `json:"student_id"`
+	Status      string   `json:"status"`
+	Title       string   `json:"title"`
+	Reasons     []string `json:"reasons"` // title, category, event_date, attachment
+}
+
+// ReviewRound is one submission of an achievement and the reviewer's decision on it.
+type ReviewRound struct {
+	ID          string                 `db:"id" json:"id"`
+	ReferenceID string                 `db:"reference_id" json:"reference_id"` // FK -> achievement_references.id
+	Round       int                    `db:"round" json:"round"`
+	SubmittedBy *string                `db:"submitted_by" json:"submitted_by"`
+	SubmittedAt time.Time              `db:"submitted_at" json:"submitted_at"`
+	Snapshot    map[string]interface{} `db:"snapshot" json:"snapshot"`         // achievement document as submitted
+	Changes     []FieldChange          `db:"changes" json:"changes,omitempty"` // diff against the previous round
+	Decision    *string                `db:"decision" json:"decision"`         // verified / rejected, nil while pending
+	DecidedBy   *string                `db:"decided_by" json:"decided_by"`
+	DecidedAt   *time.Time             `db:"decided_at" json:"decided_at"`
+	Note        *string                `db:"note" json:"note"` // rejection note
+
+	Duplicates        []DuplicateMatch `db:"duplicates" json:"duplicates,omitempty"`                 // flagged for the reviewer
+	DuplicateOverride *string          `db:"duplicate_override" json:"duplicate_override,omitempty"` // why the student submitted anyway
+}
@@ -6,5 +6,6 @@ type Role struct {
 	ID          string    `db:"id" json:"id"`
 	Name        string    `db:"name" json:"name"`
 	Description string    `db:"description" json:"description"`
+	ParentID    *string   `db:"parent_id" json:"parent_id"` // inherits the parent's permissions
 	CreatedAt   time.Time `db:"created_at" json:"created_at"`
 }
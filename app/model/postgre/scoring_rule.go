@@ -0,0 +1,25 @@
+package postgres
+
+import "time"
+
+// ScoringRule converts a verified achievement into SKPI credit points.
+// Rank "" matches any rank; a rule for the exact rank wins over it.
+type ScoringRule struct {
+	ID          string    `db:"id" json:"id"`
+	Category    string    `db:"category" json:"category"`
+	Level       string    `db:"level" json:"level"` // lokal, nasional, internasional
+	Rank        string    `db:"rank" json:"rank"`   // details.rank, "" = any
+	Points      float64   `db:"points" json:"points"`
+	Description string    `db:"description" json:"description"`
+	UpdatedBy   *string   `db:"updated_by" json:"updated_by"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// PointCap limits the points a student can collect in one category per semester.
+type PointCap struct {
+	Category  string    `db:"category" json:"category"`
+	MaxPoints float64   `db:"max_points" json:"max_points"`
+	UpdatedBy *string   `db:"updated_by" json:"updated_by"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
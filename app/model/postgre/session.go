@@ -0,0 +1,27 @@
+package postgres
+
+import "time"
+
+// UserSession is one logged-in device. Its refresh tokens rotate on every use.
+type UserSession struct {
+	ID           string     `db:"id" json:"id"`
+	UserID       string     `db:"user_id" json:"user_id"` // FK -> users.id
+	DeviceName   string     `db:"device_name" json:"device_name"`
+	UserAgent    string     `db:"user_agent" json:"user_agent"`
+	IPAddress    string     `db:"ip_address" json:"ip_address"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	LastUsedAt   time.Time  `db:"last_used_at" json:"last_used_at"`
+	ExpiresAt    time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt    *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	RevokeReason *string    `db:"revoke_reason" json:"revoke_reason,omitempty"` // logout, revoked, reuse_detected
+}
+
+// RefreshToken stores only the SHA-256 hash of the opaque token given to the client.
+type RefreshToken struct {
+	ID        string     `db:"id" json:"id"`
+	SessionID string     `db:"session_id" json:"session_id"` // FK -> user_sessions.id
+	TokenHash string     `db:"token_hash" json:"-"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `db:"used_at" json:"used_at,omitempty"` // set when rotated
+}
@@ -0,0 +1,22 @@
+package postgres
+
+import "time"
+
+// Entities that can be moved to the trash
+const (
+	TrashUsers        = "users"
+	TrashStudents     = "students"
+	TrashLecturers    = "lecturers"
+	TrashAchievements = "achievements"
+)
+
+// TrashItem is a soft-deleted row waiting to be restored or purged.
+type TrashItem struct {
+	Entity    string    `json:"entity"`
+	ID        string    `json:"id"`
+	Label     string    `json:"label"`              // username, NIM, kode dosen or achievement title
+	OwnerID   *string   `json:"owner_id,omitempty"` // students/lecturers: users.id; achievements: students.id
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy *string   `json:"deleted_by"` // FK -> users.id
+	PurgeAt   time.Time `json:"purge_at"`   // when the retention job deletes it for good
+}
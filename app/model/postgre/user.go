@@ -8,13 +8,27 @@ type User struct {
 	Email        string    `db:"email" json:"email"`
 	PasswordHash string    `db:"password_hash" json:"-"`
 	FullName     string    `db:"full_name" json:"full_name"`
-	RoleID       string    `db:"role_id" json:"role_id"` // FK -> roles.id
+	RoleID       string    `db:"role_id" json:"role_id"` // FK -> roles.id, the primary role
+	RoleIDs      []string  `db:"-" json:"role_ids"`      // every role held (user_roles), primary first
 	IsActive     bool      `db:"is_active" json:"is_active"`
+	Version      int       `db:"version" json:"version"` // row version for If-Match, +1 on every update
 	CreatedAt    time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
 }
 
+// UserAuthState is what the JWT middleware needs to know about a user on every request
+type UserAuthState struct {
+	UserID           string     `db:"id" json:"user_id"`
+	IsActive         bool       `db:"is_active" json:"is_active"`
+	TokensValidAfter *time.Time `db:"tokens_valid_after" json:"tokens_valid_after,omitempty"` // force-logout cutoff
+}
+
 type LoginRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	DeviceName string `json:"device_name,omitempty"` // optional label shown in /auth/sessions
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
@@ -1,8 +1,11 @@
 package mongo
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"regexp"
 	"time"
 
 	mongomodel "clean-arch/app/model/mongo"
@@ -19,44 +22,71 @@ type AchievementRepository interface {
 	GetByID(ctx context.Context, id primitive.ObjectID) (*mongomodel.Achievement, error)
 	Update(ctx context.Context, id primitive.ObjectID, updates map[string]interface{}) error
 	SoftDelete(ctx context.Context, id primitive.ObjectID) error
+	// Restore clears deletedAt (driver.ErrNoDocuments when the document does not exist).
+	Restore(ctx context.Context, id primitive.ObjectID) error
+	// HardDelete removes the document and its versions; a missing document is not an error.
+	HardDelete(ctx context.Context, id primitive.ObjectID) error
 	ListByStudent(ctx context.Context, studentID string, limit, offset int64) ([]*mongomodel.Achievement, error)
+	FindIDs(ctx context.Context, f AchievementFilter) ([]string, error)
+	GetByIDs(ctx context.Context, ids []primitive.ObjectID) (map[string]*mongomodel.Achievement, error)
+	// InsertIfAbsent inserts a document with a preset _id; replaying it is a no-op.
+	InsertIfAbsent(ctx context.Context, a *mongomodel.Achievement) error
+	// ListIDStates returns every document id (hex) and whether it is soft-deleted.
+	ListIDStates(ctx context.Context) (map[string]bool, error)
+	// AddAttachment appends an attachment unless one with the same id is already present.
+	AddAttachment(ctx context.Context, id primitive.ObjectID, att mongomodel.Attachment) error
+	// RemoveAttachment pulls the attachment with the given id (no-op when absent).
+	RemoveAttachment(ctx context.Context, id primitive.ObjectID, attachmentID string) error
+	// ListVersions returns the earlier versions of a document, oldest first.
+	ListVersions(ctx context.Context, id primitive.ObjectID) ([]*mongomodel.AchievementVersion, error)
+	// GetVersion returns one earlier version, or nil when it does not exist.
+	GetVersion(ctx context.Context, id primitive.ObjectID, version int) (*mongomodel.AchievementVersion, error)
+}
+
+// MutableAchievementFields are the top-level document fields Update may set. Everything
+// else (studentId, attachments, createdAt, deletedAt, ...) is owned by the repository.
+var MutableAchievementFields = map[string]bool{
+	"title": true, "type": true, "category": true, "level": true, "details": true, "tags": true,
+	"expiresAt": true,
+}
+
+// ErrImmutableField is returned by Update for a field outside MutableAchievementFields.
+var ErrImmutableField = errors.New("achievement field cannot be updated")
+
+// ErrConcurrentUpdate is returned when other writers keep changing the document between read and write.
+var ErrConcurrentUpdate = errors.New("achievement document changed concurrently")
+
+// AchievementFilter filters on document fields that only exist in Mongo.
+type AchievementFilter struct {
+	Category string
+	Level    string
+	Type     string
+	Title    string // case-insensitive substring
+}
+
+// IsEmpty reports whether no Mongo-side filter was requested.
+func (f AchievementFilter) IsEmpty() bool {
+	return f.Category == "" && f.Level == "" && f.Type == "" && f.Title == ""
 }
 
 // --------------------------
 // Implementation
 // --------------------------
+// AchievementVersionsCollection holds the prior states of achievement documents.
+const AchievementVersionsCollection = "achievement_versions"
+
 type achievementRepo struct {
-	col *driver.Collection
+	col      *driver.Collection
+	versions *driver.Collection
 }
 
-// NewAchievementRepository creates repository and ensures indexes
+// NewAchievementRepository creates repository.
+// Indexes are managed by versioned migrations (see database.NewMigrator).
 func NewAchievementRepository(db *driver.Database, collectionName string) AchievementRepository {
-	col := db.Collection(collectionName)
-	r := &achievementRepo{col: col}
-
-	// ensure indexes (best-effort)
-	_ = r.ensureIndexes(context.Background())
-	return r
-}
-
-func (r *achievementRepo) ensureIndexes(ctx context.Context) error {
-	// index on studentId and createdAt
-	indexes := []driver.IndexModel{
-		{
-			Keys:    bson.D{{Key: "studentId", Value: 1}},
-			Options: options.Index().SetBackground(true),
-		},
-		{
-			Keys:    bson.D{{Key: "createdAt", Value: -1}},
-			Options: options.Index().SetBackground(true),
-		},
-		{
-			Keys:    bson.D{{Key: "tags", Value: 1}},
-			Options: options.Index().SetBackground(true),
-		},
+	return &achievementRepo{
+		col:      db.Collection(collectionName),
+		versions: db.Collection(AchievementVersionsCollection),
 	}
-	_, err := r.col.Indexes().CreateMany(ctx, indexes)
-	return err
 }
 
 // Create inserts a new achievement document and returns its ObjectID
@@ -92,19 +122,90 @@ func (r *achievementRepo) GetByID(ctx context.Context, id primitive.ObjectID) (*
 	return &out, nil
 }
 
+// Update sets whitelisted top-level fields (see MutableAchievementFields). The prior state is
+// kept as a version; an update that changes nothing (e.g. a replayed outbox message) is skipped.
 func (r *achievementRepo) Update(ctx context.Context, id primitive.ObjectID, updates map[string]interface{}) error {
-	now := time.Now()
-	updates["updatedAt"] = now
-	
-	update := bson.M{"$set": updates}
-	res, err := r.col.UpdateOne(ctx, bson.M{"_id": id}, update)
-	if err != nil {
-		return err
+	for k := range updates {
+		if !MutableAchievementFields[k] {
+			return fmt.Errorf("%w: %q", ErrImmutableField, k)
+		}
 	}
-	if res.MatchedCount == 0 {
-		return driver.ErrNoDocuments
+	return r.versionedUpdate(ctx, id, func(cur *mongomodel.Achievement, raw bson.Raw) (bson.M, error) {
+		set := bson.M{}
+		for k, v := range updates {
+			same, err := sameValue(raw.Lookup(k), v)
+			if err != nil {
+				return nil, err
+			}
+			if !same {
+				set[k] = v
+			}
+		}
+		if len(set) == 0 {
+			return nil, nil
+		}
+		return bson.M{"$set": set}, nil
+	})
+}
+
+// versionedUpdate saves the current document to the versions collection and applies the
+// update built from it, bumping version. build returns nil to skip the write. The write only
+// matches the version that was read, so a concurrent change makes it read and retry.
+func (r *achievementRepo) versionedUpdate(ctx context.Context, id primitive.ObjectID, build func(cur *mongomodel.Achievement, raw bson.Raw) (bson.M, error)) error {
+	for attempt := 0; attempt < 5; attempt++ {
+		var raw bson.Raw
+		if err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&raw); err != nil {
+			return err // driver.ErrNoDocuments when missing
+		}
+		var cur mongomodel.Achievement
+		if err := bson.Unmarshal(raw, &cur); err != nil {
+			return err
+		}
+		update, err := build(&cur, raw)
+		if err != nil || update == nil {
+			return err
+		}
+
+		now := time.Now()
+		snapshot := mongomodel.AchievementVersion{AchievementID: id, Version: cur.Version, Document: cur, SupersededAt: now}
+		// the same version always holds the same state, so a repeated insert is a no-op
+		if _, err := r.versions.UpdateOne(ctx,
+			bson.M{"achievementId": id, "version": cur.Version},
+			bson.M{"$setOnInsert": snapshot},
+			options.Update().SetUpsert(true)); err != nil {
+			return err
+		}
+
+		set, _ := update["$set"].(bson.M)
+		if set == nil {
+			set = bson.M{}
+			update["$set"] = set
+		}
+		set["updatedAt"] = now
+		update["$inc"] = bson.M{"version": 1}
+
+		filter := bson.M{"_id": id, "version": cur.Version}
+		if cur.Version == 0 {
+			filter["version"] = bson.M{"$in": bson.A{0, nil}} // documents created before versioning
+		}
+		res, err := r.col.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 1 {
+			return nil
+		}
 	}
-	return nil
+	return ErrConcurrentUpdate
+}
+
+// sameValue reports whether the stored field already holds v
+func sameValue(stored bson.RawValue, v interface{}) (bool, error) {
+	t, data, err := bson.MarshalValue(v)
+	if err != nil {
+		return false, err
+	}
+	return stored.Type == t && bytes.Equal(stored.Value, data), nil
 }
 
 // SoftDelete sets deletedAt timestamp instead of physically deleting
@@ -126,6 +227,29 @@ func (r *achievementRepo) SoftDelete(ctx context.Context, id primitive.ObjectID)
 	return nil
 }
 
+func (r *achievementRepo) Restore(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.M{
+		"$unset": bson.M{"deletedAt": ""},
+		"$set":   bson.M{"updatedAt": time.Now()},
+	}
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return driver.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *achievementRepo) HardDelete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := r.versions.DeleteMany(ctx, bson.M{"achievementId": id}); err != nil {
+		return err
+	}
+	_, err := r.col.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
 // ListByStudent returns achievements for a given student with pagination
 func (r *achievementRepo) ListByStudent(ctx context.Context, studentID string, limit, offset int64) ([]*mongomodel.Achievement, error) {
 	if limit <= 0 {
@@ -159,3 +283,169 @@ func (r *achievementRepo) ListByStudent(ctx context.Context, studentID string, l
 	}
 	return out, nil
 }
+
+// FindIDs returns hex ids of non-deleted documents matching the filter
+func (r *achievementRepo) FindIDs(ctx context.Context, f AchievementFilter) ([]string, error) {
+	filter := bson.M{"deletedAt": bson.M{"$exists": false}}
+	if f.Category != "" {
+		filter["category"] = f.Category
+	}
+	if f.Level != "" {
+		filter["level"] = f.Level
+	}
+	if f.Type != "" {
+		filter["type"] = f.Type
+	}
+	if f.Title != "" {
+		filter["title"] = primitive.Regex{Pattern: regexp.QuoteMeta(f.Title), Options: "i"}
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+
+	cur, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []string{}
+	for cur.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		out = append(out, doc.ID.Hex())
+	}
+	return out, cur.Err()
+}
+
+// GetByIDs fetches several documents at once, keyed by hex id
+func (r *achievementRepo) GetByIDs(ctx context.Context, ids []primitive.ObjectID) (map[string]*mongomodel.Achievement, error) {
+	out := make(map[string]*mongomodel.Achievement, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	cur, err := r.col.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var a mongomodel.Achievement
+		if err := cur.Decode(&a); err != nil {
+			return nil, err
+		}
+		out[a.ID.Hex()] = &a
+	}
+	return out, cur.Err()
+}
+
+// InsertIfAbsent upserts with $setOnInsert so an existing document is never overwritten
+func (r *achievementRepo) InsertIfAbsent(ctx context.Context, a *mongomodel.Achievement) error {
+	if a.ID.IsZero() {
+		return errors.New("achievement id must be set")
+	}
+	now := time.Now()
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = now
+	}
+	if a.UpdatedAt.IsZero() {
+		a.UpdatedAt = now
+	}
+	raw, err := bson.Marshal(a)
+	if err != nil {
+		return err
+	}
+	var fields bson.M
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return err
+	}
+	delete(fields, "_id") // taken from the filter on insert
+
+	opts := options.Update().SetUpsert(true)
+	_, err = r.col.UpdateOne(ctx, bson.M{"_id": a.ID}, bson.M{"$setOnInsert": fields}, opts)
+	return err
+}
+
+// ListIDStates scans the collection (ids and deletedAt only) for reconciliation
+func (r *achievementRepo) ListIDStates(ctx context.Context) (map[string]bool, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "deletedAt": 1})
+	cur, err := r.col.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := map[string]bool{}
+	for cur.Next(ctx) {
+		var doc struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			DeletedAt *time.Time         `bson:"deletedAt"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		out[doc.ID.Hex()] = doc.DeletedAt != nil
+	}
+	return out, cur.Err()
+}
+
+// AddAttachment is a no-op when the attachment is already listed (replay)
+func (r *achievementRepo) AddAttachment(ctx context.Context, id primitive.ObjectID, att mongomodel.Attachment) error {
+	return r.versionedUpdate(ctx, id, func(cur *mongomodel.Achievement, _ bson.Raw) (bson.M, error) {
+		for _, a := range cur.Attachments {
+			if a.ID == att.ID {
+				return nil, nil
+			}
+		}
+		return bson.M{"$push": bson.M{"attachments": att}}, nil
+	})
+}
+
+func (r *achievementRepo) RemoveAttachment(ctx context.Context, id primitive.ObjectID, attachmentID string) error {
+	err := r.versionedUpdate(ctx, id, func(cur *mongomodel.Achievement, _ bson.Raw) (bson.M, error) {
+		for _, a := range cur.Attachments {
+			if a.ID == attachmentID {
+				return bson.M{"$pull": bson.M{"attachments": bson.M{"id": attachmentID}}}, nil
+			}
+		}
+		return nil, nil
+	})
+	if errors.Is(err, driver.ErrNoDocuments) {
+		return nil // nothing left to detach from
+	}
+	return err
+}
+
+func (r *achievementRepo) ListVersions(ctx context.Context, id primitive.ObjectID) ([]*mongomodel.AchievementVersion, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+	cur, err := r.versions.Find(ctx, bson.M{"achievementId": id}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []*mongomodel.AchievementVersion{}
+	for cur.Next(ctx) {
+		var v mongomodel.AchievementVersion
+		if err := cur.Decode(&v); err != nil {
+			return nil, err
+		}
+		out = append(out, &v)
+	}
+	return out, cur.Err()
+}
+
+func (r *achievementRepo) GetVersion(ctx context.Context, id primitive.ObjectID, version int) (*mongomodel.AchievementVersion, error) {
+	var out mongomodel.AchievementVersion
+	err := r.versions.FindOne(ctx, bson.M{"achievementId": id, "version": version}).Decode(&out)
+	if err != nil {
+		if err == driver.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,90 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	pgmodel "clean-arch/app/model/postgre"
+)
+
+// ErrDuplicateAttachment is returned by Create when the achievement already has a file with the same hash.
+var ErrDuplicateAttachment = errors.New("attachment with the same hash already exists")
+
+// AchievementAttachmentRepository manages achievement_attachments, which the attachment limit
+// and duplicate check run against. Use it inside the transaction that writes the outbox message.
+type AchievementAttachmentRepository interface {
+	// Seed records the attachments of an achievement written before the index existed; it does
+	// nothing once the achievement is indexed.
+	Seed(ctx context.Context, referenceID string, atts []*pgmodel.AchievementAttachment) error
+	Count(ctx context.Context, referenceID string) (int, error)
+	// Create records an attachment (ErrDuplicateAttachment when its hash is already attached).
+	Create(ctx context.Context, a *pgmodel.AchievementAttachment) error
+	Delete(ctx context.Context, referenceID string, id string) error
+	WithTx(tx *sql.Tx) AchievementAttachmentRepository
+}
+
+// Implementation
+type achievementAttachmentRepository struct {
+	db DBTX
+}
+
+func NewAchievementAttachmentRepository(db *sql.DB) AchievementAttachmentRepository {
+	return &achievementAttachmentRepository{db: db}
+}
+
+func (r *achievementAttachmentRepository) WithTx(tx *sql.Tx) AchievementAttachmentRepository {
+	return &achievementAttachmentRepository{db: tx}
+}
+
+func (r *achievementAttachmentRepository) Seed(ctx context.Context, referenceID string, atts []*pgmodel.AchievementAttachment) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE achievement_references SET attachments_indexed=TRUE WHERE id=$1 AND NOT attachments_indexed`, referenceID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	for _, a := range atts {
+		a.ReferenceID = referenceID
+		if _, err := r.db.ExecContext(ctx,
+			`INSERT INTO achievement_attachments (id, reference_id, sha256, created_at)
+			 VALUES ($1,$2,NULLIF($3,''),$4) ON CONFLICT DO NOTHING`,
+			a.ID, a.ReferenceID, a.SHA256, a.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *achievementAttachmentRepository) Count(ctx context.Context, referenceID string) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM achievement_attachments WHERE reference_id=$1`, referenceID).Scan(&n)
+	return n, err
+}
+
+func (r *achievementAttachmentRepository) Create(ctx context.Context, a *pgmodel.AchievementAttachment) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO achievement_attachments (id, reference_id, sha256, created_at)
+		 VALUES ($1,$2,NULLIF($3,''),$4) ON CONFLICT (reference_id, sha256) DO NOTHING`,
+		a.ID, a.ReferenceID, a.SHA256, a.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrDuplicateAttachment
+	}
+	return nil
+}
+
+func (r *achievementAttachmentRepository) Delete(ctx context.Context, referenceID string, id string) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM achievement_attachments WHERE reference_id=$1 AND id=$2`, referenceID, id)
+	return err
+}
@@ -0,0 +1,137 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	pgmodel "clean-arch/app/model/postgre"
+
+	"github.com/lib/pq"
+)
+
+// AchievementCommentRepository manages achievement_comments and achievement_comment_reads tables.
+type AchievementCommentRepository interface {
+	Create(ctx context.Context, c *pgmodel.AchievementComment) error
+	GetByID(ctx context.Context, id string) (*pgmodel.AchievementComment, error)
+	// ListByReference returns every comment of the achievement, oldest first.
+	ListByReference(ctx context.Context, referenceID string) ([]*pgmodel.AchievementComment, error)
+	// LastRead returns when the user last read the achievement's comments (nil = never).
+	LastRead(ctx context.Context, referenceID string, userID string) (*time.Time, error)
+	MarkRead(ctx context.Context, referenceID string, userID string, at time.Time) error
+	// UnreadCounts counts comments by others the user has not read yet, per achievement,
+	// over achievements owned by studentIDs (nil = all achievements).
+	UnreadCounts(ctx context.Context, userID string, studentIDs []string) (map[string]int, error)
+}
+
+// Implementation
+type achievementCommentRepository struct {
+	db *sql.DB
+}
+
+func NewAchievementCommentRepository(db *sql.DB) AchievementCommentRepository {
+	return &achievementCommentRepository{db: db}
+}
+
+const achievementCommentColumns = `id, reference_id, parent_id, round, author_id, author_role, field_path, body, created_at`
+
+func scanAchievementComment(row interface{ Scan(...interface{}) error }) (*pgmodel.AchievementComment, error) {
+	var c pgmodel.AchievementComment
+	if err := row.Scan(&c.ID, &c.ReferenceID, &c.ParentID, &c.Round, &c.AuthorID, &c.AuthorRole,
+		&c.FieldPath, &c.Body, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *achievementCommentRepository) Create(ctx context.Context, c *pgmodel.AchievementComment) error {
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	q := `INSERT INTO achievement_comments (` + achievementCommentColumns + `)
+	      VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`
+	_, err := r.db.ExecContext(ctx, q, c.ID, c.ReferenceID, c.ParentID, c.Round, c.AuthorID, c.AuthorRole,
+		c.FieldPath, c.Body, c.CreatedAt)
+	return err
+}
+
+func (r *achievementCommentRepository) GetByID(ctx context.Context, id string) (*pgmodel.AchievementComment, error) {
+	q := `SELECT ` + achievementCommentColumns + ` FROM achievement_comments WHERE id=$1`
+	return scanAchievementComment(r.db.QueryRowContext(ctx, q, id))
+}
+
+func (r *achievementCommentRepository) ListByReference(ctx context.Context, referenceID string) ([]*pgmodel.AchievementComment, error) {
+	q := `SELECT ` + achievementCommentColumns + ` FROM achievement_comments
+	      WHERE reference_id=$1 ORDER BY created_at, id`
+	rows, err := r.db.QueryContext(ctx, q, referenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*pgmodel.AchievementComment{}
+	for rows.Next() {
+		c, err := scanAchievementComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *achievementCommentRepository) LastRead(ctx context.Context, referenceID string, userID string) (*time.Time, error) {
+	var at time.Time
+	err := r.db.QueryRowContext(ctx,
+		`SELECT last_read_at FROM achievement_comment_reads WHERE reference_id=$1 AND user_id=$2`,
+		referenceID, userID).Scan(&at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &at, nil
+}
+
+func (r *achievementCommentRepository) MarkRead(ctx context.Context, referenceID string, userID string, at time.Time) error {
+	q := `INSERT INTO achievement_comment_reads (reference_id, user_id, last_read_at)
+	      VALUES ($1,$2,$3)
+	      ON CONFLICT (reference_id, user_id) DO UPDATE
+	      SET last_read_at = GREATEST(achievement_comment_reads.last_read_at, EXCLUDED.last_read_at)`
+	_, err := r.db.ExecContext(ctx, q, referenceID, userID, at)
+	return err
+}
+
+func (r *achievementCommentRepository) UnreadCounts(ctx context.Context, userID string, studentIDs []string) (map[string]int, error) {
+	q := `SELECT c.reference_id, COUNT(*)
+	      FROM achievement_comments c
+	      JOIN achievement_references ar ON ar.id = c.reference_id
+	      LEFT JOIN achievement_comment_reads rd ON rd.reference_id = c.reference_id AND rd.user_id = $1
+	      WHERE ar.status <> 'deleted'
+	        AND c.author_id IS DISTINCT FROM $1
+	        AND c.created_at > COALESCE(rd.last_read_at, 'epoch'::timestamptz)
+	        AND ($2::uuid[] IS NULL OR ar.student_id = ANY($2))
+	      GROUP BY c.reference_id`
+	var ids interface{}
+	if studentIDs != nil {
+		ids = pq.Array(studentIDs)
+	}
+	rows, err := r.db.QueryContext(ctx, q, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]int)
+	for rows.Next() {
+		var refID string
+		var n int
+		if err := rows.Scan(&refID, &n); err != nil {
+			return nil, err
+		}
+		out[refID] = n
+	}
+	return out, rows.Err()
+}
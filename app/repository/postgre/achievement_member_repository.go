@@ -0,0 +1,126 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	pgmodel "clean-arch/app/model/postgre"
+)
+
+// AchievementMemberRepository manages achievement_members table.
+type AchievementMemberRepository interface {
+	// Upsert adds a member or, for a student already listed (e.g. declined), replaces the row.
+	Upsert(ctx context.Context, m *pgmodel.AchievementMember) error
+	Get(ctx context.Context, referenceID string, studentID string) (*pgmodel.AchievementMember, error)
+	ListByReference(ctx context.Context, referenceID string) ([]*pgmodel.AchievementMember, error)
+	// ListByStudent returns the memberships of a student with the given status ("" = any).
+	ListByStudent(ctx context.Context, studentID string, status string) ([]*pgmodel.AchievementMember, error)
+	// ListAccepted returns every accepted membership, leaders included.
+	ListAccepted(ctx context.Context) ([]*pgmodel.AchievementMember, error)
+	// SetStatus records an invitee's answer; sql.ErrNoRows if the student is not listed.
+	SetStatus(ctx context.Context, referenceID string, studentID string, status string) error
+	Delete(ctx context.Context, referenceID string, studentID string) error
+	WithTx(tx *sql.Tx) AchievementMemberRepository
+}
+
+// Implementation
+type achievementMemberRepository struct {
+	db DBTX
+}
+
+func NewAchievementMemberRepository(db *sql.DB) AchievementMemberRepository {
+	return &achievementMemberRepository{db: db}
+}
+
+func (r *achievementMemberRepository) WithTx(tx *sql.Tx) AchievementMemberRepository {
+	return &achievementMemberRepository{db: tx}
+}
+
+const achievementMemberColumns = `reference_id, student_id, role, status, invited_by, invited_at, responded_at`
+
+func scanAchievementMember(row interface{ Scan(...interface{}) error }) (*pgmodel.AchievementMember, error) {
+	var m pgmodel.AchievementMember
+	if err := row.Scan(&m.ReferenceID, &m.StudentID, &m.Role, &m.Status, &m.InvitedBy,
+		&m.InvitedAt, &m.RespondedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *achievementMemberRepository) Upsert(ctx context.Context, m *pgmodel.AchievementMember) error {
+	if m.InvitedAt.IsZero() {
+		m.InvitedAt = time.Now()
+	}
+	q := `INSERT INTO achievement_members (` + achievementMemberColumns + `)
+	      VALUES ($1,$2,$3,$4,$5,$6,$7)
+	      ON CONFLICT (reference_id, student_id) DO UPDATE
+	      SET role=EXCLUDED.role, status=EXCLUDED.status, invited_by=EXCLUDED.invited_by,
+	          invited_at=EXCLUDED.invited_at, responded_at=EXCLUDED.responded_at`
+	_, err := r.db.ExecContext(ctx, q, m.ReferenceID, m.StudentID, m.Role, m.Status, m.InvitedBy,
+		m.InvitedAt, m.RespondedAt)
+	return err
+}
+
+func (r *achievementMemberRepository) Get(ctx context.Context, referenceID string, studentID string) (*pgmodel.AchievementMember, error) {
+	q := `SELECT ` + achievementMemberColumns + ` FROM achievement_members WHERE reference_id=$1 AND student_id=$2`
+	return scanAchievementMember(r.db.QueryRowContext(ctx, q, referenceID, studentID))
+}
+
+func (r *achievementMemberRepository) ListByReference(ctx context.Context, referenceID string) ([]*pgmodel.AchievementMember, error) {
+	q := `SELECT ` + achievementMemberColumns + ` FROM achievement_members
+	      WHERE reference_id=$1 ORDER BY role = 'leader' DESC, invited_at`
+	return r.list(ctx, q, referenceID)
+}
+
+func (r *achievementMemberRepository) ListByStudent(ctx context.Context, studentID string, status string) ([]*pgmodel.AchievementMember, error) {
+	q := `SELECT ` + achievementMemberColumns + ` FROM achievement_members
+	      WHERE student_id=$1 AND ($2 = '' OR status=$2) ORDER BY invited_at DESC`
+	return r.list(ctx, q, studentID, status)
+}
+
+func (r *achievementMemberRepository) ListAccepted(ctx context.Context) ([]*pgmodel.AchievementMember, error) {
+	q := `SELECT ` + achievementMemberColumns + ` FROM achievement_members WHERE status='accepted'`
+	return r.list(ctx, q)
+}
+
+func (r *achievementMemberRepository) SetStatus(ctx context.Context, referenceID string, studentID string, status string) error {
+	q := `UPDATE achievement_members SET status=$1, responded_at=$2 WHERE reference_id=$3 AND student_id=$4`
+	res, err := r.db.ExecContext(ctx, q, status, time.Now(), referenceID, studentID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *achievementMemberRepository) Delete(ctx context.Context, referenceID string, studentID string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM achievement_members WHERE reference_id=$1 AND student_id=$2`, referenceID, studentID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *achievementMemberRepository) list(ctx context.Context, q string, args ...interface{}) ([]*pgmodel.AchievementMember, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*pgmodel.AchievementMember{}
+	for rows.Next() {
+		m, err := scanAchievementMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
@@ -3,9 +3,14 @@ package postgre
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	pgmodel "clean-arch/app/model/postgre"
+
+	"github.com/lib/pq"
 )
 
 // AchievementRefRepository handles achievement_references table.
@@ -13,31 +18,103 @@ type AchievementRefRepository interface {
 	Create(ctx context.Context, ref *pgmodel.AchievementReference) error
 	UpdateStatus(ctx context.Context, id string, status string, verifierID *string) error
 	GetByID(ctx context.Context, id string) (*pgmodel.AchievementReference, error)
+	// GetByIDs loads several rows in one query, keyed by id; missing ids are left out.
+	GetByIDs(ctx context.Context, ids []string) (map[string]*pgmodel.AchievementReference, error)
 	ListByStudent(ctx context.Context, studentID string) ([]*pgmodel.AchievementReference, error)
+	// ListByMember returns achievements the student owns or is an accepted team member of.
+	ListByMember(ctx context.Context, studentID string) ([]*pgmodel.AchievementReference, error)
 	UpdateRejectionNote(ctx context.Context, id string, note string) error
 	ListAll(ctx context.Context) ([]*pgmodel.AchievementReference, error)
 	Update(ctx context.Context, ref *pgmodel.AchievementReference) error
+	Search(ctx context.Context, f AchievementRefFilter) ([]*pgmodel.AchievementReference, error)
+	CountByStatus(ctx context.Context, f AchievementRefFilter) (map[string]int, error)
+	// ListExpiring returns verified achievements expiring before the given time that were not
+	// flagged yet and have no verified renewal.
+	ListExpiring(ctx context.Context, before time.Time) ([]*pgmodel.AchievementReference, error)
+	// FlagExpiry records that the owner was warned and bumps the row version, so an edit based
+	// on an earlier read cannot write the flag back.
+	FlagExpiry(ctx context.Context, id string, at time.Time) error
+	// SetExpiry fills expires_at of rows written before expiry tracking and bumps the row version.
+	SetExpiry(ctx context.Context, id string, expiresAt *time.Time) error
+	// UpdatePoints stores a recalculated score of a verified achievement and bumps the row
+	// version; updated_at is left alone. sql.ErrNoRows when it is no longer verified.
+	UpdatePoints(ctx context.Context, id string, points *float64, ruleID *string, semester *string) error
+	// ListRenewals returns the achievements that renew the given one, newest first.
+	ListRenewals(ctx context.Context, id string) ([]*pgmodel.AchievementReference, error)
+	// ListTrashed returns deleted achievements, most recently deleted first; a non-nil before
+	// keeps only those deleted before it.
+	ListTrashed(ctx context.Context, before *time.Time) ([]*pgmodel.AchievementReference, error)
+	// Delete removes the row for good (sql.ErrNoRows when absent); members, review rounds and
+	// comments go with it.
+	Delete(ctx context.Context, id string) error
+	// WithTx returns a repository bound to the transaction (see Transactor).
+	WithTx(tx *sql.Tx) AchievementRefRepository
 }
 
 // Implementation
 type achievementRefRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewAchievementRefRepository(db *sql.DB) AchievementRefRepository {
 	return &achievementRefRepository{db: db}
 }
 
+func (r *achievementRefRepository) WithTx(tx *sql.Tx) AchievementRefRepository {
+	return &achievementRefRepository{db: tx}
+}
+
+const achievementRefColumns = `id, student_id, mongo_achievement_id, status, submitted_at, verified_at, verified_by, rejection_note, revision, version, points, points_rule_id, points_semester, expires_at, expiry_flagged_at, renewal_of, deleted_at, deleted_by, deleted_from, created_at, updated_at`
+
+// qualify prefixes every column of a column list with a table alias
+func qualify(alias string, columns string) string {
+	parts := strings.Split(columns, ", ")
+	for i, p := range parts {
+		parts[i] = alias + "." + p
+	}
+	return strings.Join(parts, ", ")
+}
+
+func scanAchievementRef(row interface{ Scan(...interface{}) error }) (*pgmodel.AchievementReference, error) {
+	var out pgmodel.AchievementReference
+	if err := row.Scan(&out.ID, &out.StudentID, &out.MongoAchievementID, &out.Status,
+		&out.SubmittedAt, &out.VerifiedAt, &out.VerifiedBy, &out.RejectionNote, &out.Revision,
+		&out.Version, &out.Points, &out.PointsRuleID, &out.PointsSemester,
+		&out.ExpiresAt, &out.ExpiryFlaggedAt, &out.RenewalOf, &out.DeletedAt, &out.DeletedBy, &out.DeletedFrom,
+		&out.CreatedAt, &out.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func scanAchievementRefs(rows *sql.Rows) ([]*pgmodel.AchievementReference, error) {
+	defer rows.Close()
+	out := []*pgmodel.AchievementReference{}
+	for rows.Next() {
+		item, err := scanAchievementRef(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, rows.Err()
+}
+
 func (r *achievementRefRepository) Create(ctx context.Context, ref *pgmodel.AchievementReference) error {
 	now := time.Now()
 	ref.CreatedAt = now
 	ref.UpdatedAt = now
-	q := `INSERT INTO achievement_references
-	      (id, student_id, mongo_achievement_id, status, submitted_at, verified_at, verified_by, rejection_note, created_at, updated_at)
-	      VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`
+	if ref.Revision == 0 {
+		ref.Revision = 1
+	}
+	ref.Version = 1
+	q := `INSERT INTO achievement_references (` + achievementRefColumns + `)
+	      VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21)`
 	_, err := r.db.ExecContext(ctx, q,
 		ref.ID, ref.StudentID, ref.MongoAchievementID, ref.Status,
-		ref.SubmittedAt, ref.VerifiedAt, ref.VerifiedBy, ref.RejectionNote,
+		ref.SubmittedAt, ref.VerifiedAt, ref.VerifiedBy, ref.RejectionNote, ref.Revision,
+		ref.Version, ref.Points, ref.PointsRuleID, ref.PointsSemester,
+		ref.ExpiresAt, ref.ExpiryFlaggedAt, ref.RenewalOf, ref.DeletedAt, ref.DeletedBy, ref.DeletedFrom,
 		ref.CreatedAt, ref.UpdatedAt,
 	)
 	return err
@@ -46,86 +123,347 @@ func (r *achievementRefRepository) Create(ctx context.Context, ref *pgmodel.Achi
 func (r *achievementRefRepository) UpdateStatus(ctx context.Context, id string, status string, verifierID *string) error {
 	now := time.Now()
 	if verifierID != nil {
-		q := `UPDATE achievement_references SET status=$1, verified_by=$2, verified_at=$3, updated_at=$4 WHERE id=$5`
+		q := `UPDATE achievement_references SET status=$1, verified_by=$2, verified_at=$3, updated_at=$4, version=version+1 WHERE id=$5`
 		_, err := r.db.ExecContext(ctx, q, status, *verifierID, now, now, id)
 		return err
 	}
-	q := `UPDATE achievement_references SET status=$1, updated_at=$2 WHERE id=$3`
+	q := `UPDATE achievement_references SET status=$1, updated_at=$2, version=version+1 WHERE id=$3`
 	_, err := r.db.ExecContext(ctx, q, status, now, id)
 	return err
 }
 
 func (r *achievementRefRepository) GetByID(ctx context.Context, id string) (*pgmodel.AchievementReference, error) {
-	var out pgmodel.AchievementReference
-	q := `SELECT id, student_id, mongo_achievement_id, status, submitted_at, verified_at, verified_by, rejection_note, created_at, updated_at
-	      FROM achievement_references WHERE id=$1`
-	row := r.db.QueryRowContext(ctx, q, id)
-	if err := row.Scan(&out.ID, &out.StudentID, &out.MongoAchievementID, &out.Status,
-		&out.SubmittedAt, &out.VerifiedAt, &out.VerifiedBy, &out.RejectionNote, &out.CreatedAt, &out.UpdatedAt); err != nil {
+	q := `SELECT ` + achievementRefColumns + ` FROM achievement_references WHERE id=$1`
+	return scanAchievementRef(r.db.QueryRowContext(ctx, q, id))
+}
+
+func (r *achievementRefRepository) GetByIDs(ctx context.Context, ids []string) (map[string]*pgmodel.AchievementReference, error) {
+	out := make(map[string]*pgmodel.AchievementReference, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	q := `SELECT ` + achievementRefColumns + ` FROM achievement_references WHERE id::text = ANY($1)`
+	rows, err := r.db.QueryContext(ctx, q, pq.Array(ids))
+	if err != nil {
 		return nil, err
 	}
-	return &out, nil
+	refs, err := scanAchievementRefs(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		out[ref.ID] = ref
+	}
+	return out, nil
 }
 
 func (r *achievementRefRepository) ListByStudent(ctx context.Context, studentID string) ([]*pgmodel.AchievementReference, error) {
-	q := `SELECT id, student_id, mongo_achievement_id, status, submitted_at, verified_at, verified_by, rejection_note, created_at, updated_at
+	q := `SELECT ` + achievementRefColumns + `
 	      FROM achievement_references WHERE student_id=$1 ORDER BY created_at DESC`
 	rows, err := r.db.QueryContext(ctx, q, studentID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return scanAchievementRefs(rows)
+}
 
-	var out []*pgmodel.AchievementReference
-	for rows.Next() {
-		var item pgmodel.AchievementReference
-		if err := rows.Scan(&item.ID, &item.StudentID, &item.MongoAchievementID, &item.Status,
-			&item.SubmittedAt, &item.VerifiedAt, &item.VerifiedBy, &item.RejectionNote, &item.CreatedAt, &item.UpdatedAt); err != nil {
-			return nil, err
-		}
-		out = append(out, &item)
+func (r *achievementRefRepository) ListByMember(ctx context.Context, studentID string) ([]*pgmodel.AchievementReference, error) {
+	q := `SELECT ` + achievementRefColumns + `
+	      FROM achievement_references
+	      WHERE student_id=$1
+	         OR id IN (SELECT reference_id FROM achievement_members WHERE student_id=$1 AND status='accepted')
+	      ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, q, studentID)
+	if err != nil {
+		return nil, err
 	}
-	return out, nil
+	return scanAchievementRefs(rows)
 }
 
 func (r *achievementRefRepository) UpdateRejectionNote(ctx context.Context, id string, note string) error {
 	now := time.Now()
-	q := `UPDATE achievement_references SET rejection_note=$1, status='rejected', updated_at=$2 WHERE id=$3`
+	q := `UPDATE achievement_references SET rejection_note=$1, status='rejected', updated_at=$2, version=version+1 WHERE id=$3`
 	_, err := r.db.ExecContext(ctx, q, note, now, id)
 	return err
 }
 
 func (r *achievementRefRepository) ListAll(ctx context.Context) ([]*pgmodel.AchievementReference, error) {
-	q := `SELECT id, student_id, mongo_achievement_id, status, submitted_at, verified_at, verified_by, rejection_note, created_at, updated_at
+	q := `SELECT ` + achievementRefColumns + `
 	      FROM achievement_references ORDER BY created_at DESC`
 	rows, err := r.db.QueryContext(ctx, q)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var out []*pgmodel.AchievementReference
-	for rows.Next() {
-		var item pgmodel.AchievementReference
-		if err := rows.Scan(&item.ID, &item.StudentID, &item.MongoAchievementID, &item.Status,
-			&item.SubmittedAt, &item.VerifiedAt, &item.VerifiedBy, &item.RejectionNote, &item.CreatedAt, &item.UpdatedAt); err != nil {
-			return nil, err
-		}
-		out = append(out, &item)
-	}
-	return out, nil
+	return scanAchievementRefs(rows)
 }
 
+// Update writes the row only if it still has ref.Version (ErrStaleVersion otherwise) and bumps it.
 func (r *achievementRefRepository) Update(ctx context.Context, ref *pgmodel.AchievementReference) error {
 	now := time.Now()
-	ref.UpdatedAt = now
-	q := `UPDATE achievement_references 
-	      SET student_id=$1, mongo_achievement_id=$2, status=$3, submitted_at=$4, verified_at=$5, 
-	          verified_by=$6, rejection_note=$7, updated_at=$8 
-	      WHERE id=$9`
-	_, err := r.db.ExecContext(ctx, q,
+	q := `UPDATE achievement_references
+	      SET student_id=$1, mongo_achievement_id=$2, status=$3, submitted_at=$4, verified_at=$5,
+	          verified_by=$6, rejection_note=$7, revision=$8, points=$9, points_rule_id=$10,
+	          points_semester=$11, expires_at=$12, expiry_flagged_at=$13, renewal_of=$14,
+	          deleted_at=$15, deleted_by=$16, deleted_from=$17, updated_at=$18, version=version+1
+	      WHERE id=$19 AND version=$20`
+	res, err := r.db.ExecContext(ctx, q,
 		ref.StudentID, ref.MongoAchievementID, ref.Status, ref.SubmittedAt, ref.VerifiedAt,
-		ref.VerifiedBy, ref.RejectionNote, ref.UpdatedAt, ref.ID,
+		ref.VerifiedBy, ref.RejectionNote, ref.Revision, ref.Points, ref.PointsRuleID,
+		ref.PointsSemester, ref.ExpiresAt, ref.ExpiryFlaggedAt, ref.RenewalOf,
+		ref.DeletedAt, ref.DeletedBy, ref.DeletedFrom, now, ref.ID, ref.Version,
 	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrStaleVersion
+	}
+	ref.UpdatedAt = now
+	ref.Version++
+	return nil
+}
+
+func (r *achievementRefRepository) ListExpiring(ctx context.Context, before time.Time) ([]*pgmodel.AchievementReference, error) {
+	q := `SELECT ` + qualify("ar", achievementRefColumns) + `
+	      FROM achievement_references ar
+	      WHERE ar.status = 'verified' AND ar.expires_at < $1 AND ar.expiry_flagged_at IS NULL
+	        AND NOT EXISTS (SELECT 1 FROM achievement_references n WHERE n.renewal_of = ar.id AND n.status = 'verified')
+	      ORDER BY ar.expires_at, ar.id`
+	rows, err := r.db.QueryContext(ctx, q, before)
+	if err != nil {
+		return nil, err
+	}
+	return scanAchievementRefs(rows)
+}
+
+func (r *achievementRefRepository) FlagExpiry(ctx context.Context, id string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE achievement_references SET expiry_flagged_at=$1, version=version+1 WHERE id=$2`, at, id)
+	return err
+}
+
+func (r *achievementRefRepository) SetExpiry(ctx context.Context, id string, expiresAt *time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE achievement_references SET expires_at=$1, expiry_flagged_at=NULL, version=version+1 WHERE id=$2 AND expires_at IS NULL`, expiresAt, id)
 	return err
 }
+
+func (r *achievementRefRepository) UpdatePoints(ctx context.Context, id string, points *float64, ruleID *string, semester *string) error {
+	q := `UPDATE achievement_references SET points=$1, points_rule_id=$2, points_semester=$3, version=version+1
+	      WHERE id=$4 AND status='verified'`
+	res, err := r.db.ExecContext(ctx, q, points, ruleID, semester, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *achievementRefRepository) ListRenewals(ctx context.Context, id string) ([]*pgmodel.AchievementReference, error) {
+	q := `SELECT ` + achievementRefColumns + `
+	      FROM achievement_references WHERE renewal_of=$1 ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	return scanAchievementRefs(rows)
+}
+
+func (r *achievementRefRepository) ListTrashed(ctx context.Context, before *time.Time) ([]*pgmodel.AchievementReference, error) {
+	q := `SELECT ` + achievementRefColumns + `
+	      FROM achievement_references
+	      WHERE status = 'deleted' AND ($1::timestamptz IS NULL OR COALESCE(deleted_at, updated_at) < $1)
+	      ORDER BY COALESCE(deleted_at, updated_at) DESC, id`
+	rows, err := r.db.QueryContext(ctx, q, before)
+	if err != nil {
+		return nil, err
+	}
+	return scanAchievementRefs(rows)
+}
+
+func (r *achievementRefRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM achievement_references WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AchievementRefFilter drives Search / CountByStatus. Zero values mean "no filter".
+type AchievementRefFilter struct {
+	Statuses      []string // matched against the derived status (see achievementRefStatusExpr)
+	StudentID     string
+	StudentIDs    []string // visibility scope; nil means unrestricted
+	AdvisorID     string   // students.advisor_id
+	ProgramStudy  string
+	AcademicYear  string
+	MongoIDs      []string // restrict to these mongo_achievement_id values (from Mongo-side filters)
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	SubmittedFrom *time.Time
+	SubmittedTo   *time.Time
+	VerifiedFrom  *time.Time
+	VerifiedTo    *time.Time
+
+	SortBy   string // created_at, updated_at, submitted_at, verified_at
+	SortDesc bool
+	// keyset cursor: rows strictly after (AfterValue, AfterID) in sort order
+	AfterValue *time.Time
+	AfterID    string
+	Limit      int
+}
+
+// sortable columns; nullable timestamps sort as epoch so keyset comparison stays total
+var achievementRefSortExpr = map[string]string{
+	"created_at":   "ar.created_at",
+	"updated_at":   "ar.updated_at",
+	"submitted_at": "COALESCE(ar.submitted_at, 'epoch'::timestamptz)",
+	"verified_at":  "COALESCE(ar.verified_at, 'epoch'::timestamptz)",
+}
+
+// achievementRefStatusExpr is the status shown in listings and counts: verified achievements
+// past their expiry date are reported as expired.
+var achievementRefStatusExpr = `CASE WHEN ar.status = 'verified' AND ar.expires_at <= NOW()
+	THEN '` + pgmodel.AchievementExpired + `' ELSE ar.status END`
+
+// SortValue returns the value of the sort column for building the next cursor.
+func (f *AchievementRefFilter) SortValue(ref *pgmodel.AchievementReference) time.Time {
+	switch f.SortBy {
+	case "updated_at":
+		return ref.UpdatedAt
+	case "submitted_at":
+		if ref.SubmittedAt != nil {
+			return *ref.SubmittedAt
+		}
+		return time.Unix(0, 0).UTC()
+	case "verified_at":
+		if ref.VerifiedAt != nil {
+			return *ref.VerifiedAt
+		}
+		return time.Unix(0, 0).UTC()
+	}
+	return ref.CreatedAt
+}
+
+// where builds the shared WHERE clause; placeholders start at $1.
+func (f *AchievementRefFilter) where() (string, []interface{}) {
+	conds := []string{}
+	args := []interface{}{}
+	// every "?" in cond refers to the same argument
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		conds = append(conds, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	// achievements of students in the trash are hidden with them
+	conds = append(conds, "s.deleted_at IS NULL")
+	if len(f.Statuses) > 0 {
+		add(achievementRefStatusExpr+" = ANY(?)", pq.Array(f.Statuses))
+	} else {
+		conds = append(conds, "ar.status <> 'deleted'")
+	}
+	// a student's achievements include team achievements they accepted
+	if f.StudentID != "" {
+		add(`(ar.student_id = ? OR ar.id IN (SELECT m.reference_id FROM achievement_members m
+		      WHERE m.student_id = ? AND m.status = 'accepted'))`, f.StudentID)
+	}
+	if f.StudentIDs != nil {
+		add(`(ar.student_id = ANY(?) OR ar.id IN (SELECT m.reference_id FROM achievement_members m
+		      WHERE m.student_id = ANY(?) AND m.status = 'accepted'))`, pq.Array(f.StudentIDs))
+	}
+	if f.AdvisorID != "" {
+		add("s.advisor_id = ?", f.AdvisorID)
+	}
+	if f.ProgramStudy != "" {
+		add("s.program_study = ?", f.ProgramStudy)
+	}
+	if f.AcademicYear != "" {
+		add("s.academic_year = ?", f.AcademicYear)
+	}
+	if f.MongoIDs != nil {
+		add("ar.mongo_achievement_id = ANY(?)", pq.Array(f.MongoIDs))
+	}
+	if f.CreatedFrom != nil {
+		add("ar.created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		add("ar.created_at < ?", *f.CreatedTo)
+	}
+	if f.SubmittedFrom != nil {
+		add("ar.submitted_at >= ?", *f.SubmittedFrom)
+	}
+	if f.SubmittedTo != nil {
+		add("ar.submitted_at < ?", *f.SubmittedTo)
+	}
+	if f.VerifiedFrom != nil {
+		add("ar.verified_at >= ?", *f.VerifiedFrom)
+	}
+	if f.VerifiedTo != nil {
+		add("ar.verified_at < ?", *f.VerifiedTo)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+func (r *achievementRefRepository) Search(ctx context.Context, f AchievementRefFilter) ([]*pgmodel.AchievementReference, error) {
+	sortExpr, ok := achievementRefSortExpr[f.SortBy]
+	if !ok {
+		sortExpr = achievementRefSortExpr["created_at"]
+	}
+	dir, cmp := "ASC", ">"
+	if f.SortDesc {
+		dir, cmp = "DESC", "<"
+	}
+
+	where, args := f.where()
+	if f.AfterValue != nil && f.AfterID != "" {
+		args = append(args, *f.AfterValue, f.AfterID)
+		where += fmt.Sprintf(" AND (%s, ar.id) %s ($%d, $%d)", sortExpr, cmp, len(args)-1, len(args))
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	args = append(args, limit)
+
+	q := fmt.Sprintf(`SELECT %s
+	      FROM achievement_references ar
+	      JOIN students s ON s.id = ar.student_id
+	      WHERE %s
+	      ORDER BY %s %s, ar.id %s
+	      LIMIT $%d`, qualify("ar", achievementRefColumns), where, sortExpr, dir, dir, len(args))
+
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanAchievementRefs(rows)
+}
+
+// CountByStatus counts rows matching the filter (cursor and limit are ignored).
+func (r *achievementRefRepository) CountByStatus(ctx context.Context, f AchievementRefFilter) (map[string]int, error) {
+	where, args := f.where()
+	q := `SELECT ` + achievementRefStatusExpr + `, COUNT(*)
+	      FROM achievement_references ar
+	      JOIN students s ON s.id = ar.student_id
+	      WHERE ` + where + `
+	      GROUP BY 1`
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		out[status] = n
+	}
+	return out, rows.Err()
+}
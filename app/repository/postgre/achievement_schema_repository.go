@@ -0,0 +1,93 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	pgmodel "clean-arch/app/model/postgre"
+)
+
+// AchievementSchemaRepository manages achievement_schemas table.
+type AchievementSchemaRepository interface {
+	ListAll(ctx context.Context) ([]*pgmodel.AchievementSchema, error)
+	// GetByCategory returns sql.ErrNoRows when the category has no schema.
+	GetByCategory(ctx context.Context, category string) (*pgmodel.AchievementSchema, error)
+	// Upsert creates the category schema or replaces it.
+	Upsert(ctx context.Context, s *pgmodel.AchievementSchema) error
+	Delete(ctx context.Context, category string) error
+}
+
+// Implementation
+type achievementSchemaRepository struct {
+	db *sql.DB
+}
+
+func NewAchievementSchemaRepository(db *sql.DB) AchievementSchemaRepository {
+	return &achievementSchemaRepository{db: db}
+}
+
+const achievementSchemaColumns = `category, description, schema, updated_by, created_at, updated_at`
+
+func scanAchievementSchema(row interface{ Scan(...interface{}) error }) (*pgmodel.AchievementSchema, error) {
+	var s pgmodel.AchievementSchema
+	var schema []byte
+	if err := row.Scan(&s.Category, &s.Description, &schema, &s.UpdatedBy, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(schema, &s.Schema); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *achievementSchemaRepository) ListAll(ctx context.Context) ([]*pgmodel.AchievementSchema, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+achievementSchemaColumns+` FROM achievement_schemas ORDER BY category`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*pgmodel.AchievementSchema{}
+	for rows.Next() {
+		s, err := scanAchievementSchema(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (r *achievementSchemaRepository) GetByCategory(ctx context.Context, category string) (*pgmodel.AchievementSchema, error) {
+	q := `SELECT ` + achievementSchemaColumns + ` FROM achievement_schemas WHERE category=$1`
+	return scanAchievementSchema(r.db.QueryRowContext(ctx, q, category))
+}
+
+func (r *achievementSchemaRepository) Upsert(ctx context.Context, s *pgmodel.AchievementSchema) error {
+	schema, err := json.Marshal(s.Schema)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	s.UpdatedAt = now
+	q := `INSERT INTO achievement_schemas (category, description, schema, updated_by, created_at, updated_at)
+	      VALUES ($1,$2,$3,$4,$5,$5)
+	      ON CONFLICT (category) DO UPDATE
+	      SET description=EXCLUDED.description, schema=EXCLUDED.schema,
+	          updated_by=EXCLUDED.updated_by, updated_at=EXCLUDED.updated_at
+	      RETURNING created_at`
+	return r.db.QueryRowContext(ctx, q, s.Category, s.Description, schema, s.UpdatedBy, now).Scan(&s.CreatedAt)
+}
+
+func (r *achievementSchemaRepository) Delete(ctx context.Context, category string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM achievement_schemas WHERE category=$1`, category)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
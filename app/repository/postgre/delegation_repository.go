@@ -0,0 +1,99 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	pgmodel "clean-arch/app/model/postgre"
+)
+
+// DelegationRepository manages verification_delegations table.
+type DelegationRepository interface {
+	Create(ctx context.Context, d *pgmodel.VerificationDelegation) error
+	GetByID(ctx context.Context, id string) (*pgmodel.VerificationDelegation, error)
+	ListByLecturer(ctx context.Context, lecturerID string) ([]*pgmodel.VerificationDelegation, error)
+	// FindActive returns the delegation from advisor to delegate valid at the given time.
+	FindActive(ctx context.Context, advisorID string, delegateID string, at time.Time) (*pgmodel.VerificationDelegation, error)
+	// ListActiveForDelegate returns delegations the lecturer currently holds.
+	ListActiveForDelegate(ctx context.Context, delegateID string, at time.Time) ([]*pgmodel.VerificationDelegation, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+// Implementation
+type delegationRepository struct {
+	db *sql.DB
+}
+
+func NewDelegationRepository(db *sql.DB) DelegationRepository {
+	return &delegationRepository{db: db}
+}
+
+const delegationColumns = `id, advisor_id, delegate_id, starts_at, ends_at, reason, created_by, created_at, revoked_at`
+
+func scanDelegation(row interface{ Scan(...interface{}) error }) (*pgmodel.VerificationDelegation, error) {
+	var d pgmodel.VerificationDelegation
+	if err := row.Scan(&d.ID, &d.AdvisorID, &d.DelegateID, &d.StartsAt, &d.EndsAt, &d.Reason,
+		&d.CreatedBy, &d.CreatedAt, &d.RevokedAt); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *delegationRepository) Create(ctx context.Context, d *pgmodel.VerificationDelegation) error {
+	d.CreatedAt = time.Now()
+	q := `INSERT INTO verification_delegations (id, advisor_id, delegate_id, starts_at, ends_at, reason, created_by, created_at)
+	      VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`
+	_, err := r.db.ExecContext(ctx, q, d.ID, d.AdvisorID, d.DelegateID, d.StartsAt, d.EndsAt, d.Reason, d.CreatedBy, d.CreatedAt)
+	return err
+}
+
+func (r *delegationRepository) GetByID(ctx context.Context, id string) (*pgmodel.VerificationDelegation, error) {
+	q := `SELECT ` + delegationColumns + ` FROM verification_delegations WHERE id=$1`
+	return scanDelegation(r.db.QueryRowContext(ctx, q, id))
+}
+
+func (r *delegationRepository) ListByLecturer(ctx context.Context, lecturerID string) ([]*pgmodel.VerificationDelegation, error) {
+	q := `SELECT ` + delegationColumns + ` FROM verification_delegations
+	      WHERE advisor_id=$1 OR delegate_id=$1
+	      ORDER BY starts_at DESC`
+	return r.list(ctx, q, lecturerID)
+}
+
+func (r *delegationRepository) ListActiveForDelegate(ctx context.Context, delegateID string, at time.Time) ([]*pgmodel.VerificationDelegation, error) {
+	q := `SELECT ` + delegationColumns + ` FROM verification_delegations
+	      WHERE delegate_id=$1 AND revoked_at IS NULL AND starts_at <= $2 AND ends_at > $2
+	      ORDER BY ends_at`
+	return r.list(ctx, q, delegateID, at)
+}
+
+func (r *delegationRepository) list(ctx context.Context, q string, args ...interface{}) ([]*pgmodel.VerificationDelegation, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*pgmodel.VerificationDelegation{}
+	for rows.Next() {
+		d, err := scanDelegation(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *delegationRepository) FindActive(ctx context.Context, advisorID string, delegateID string, at time.Time) (*pgmodel.VerificationDelegation, error) {
+	q := `SELECT ` + delegationColumns + ` FROM verification_delegations
+	      WHERE advisor_id=$1 AND delegate_id=$2 AND revoked_at IS NULL AND starts_at <= $3 AND ends_at > $3
+	      ORDER BY ends_at DESC LIMIT 1`
+	return scanDelegation(r.db.QueryRowContext(ctx, q, advisorID, delegateID, at))
+}
+
+func (r *delegationRepository) Revoke(ctx context.Context, id string) error {
+	q := `UPDATE verification_delegations SET revoked_at=$1 WHERE id=$2 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, q, time.Now(), id)
+	return err
+}
@@ -35,7 +35,7 @@ func (r *lecturerRepository) Create(ctx context.Context, l *pgmodel.Lecturer) er
 
 func (r *lecturerRepository) GetByID(ctx context.Context, id string) (*pgmodel.Lecturer, error) {
 	var out pgmodel.Lecturer
-	q := `SELECT id, user_id, lecturer_id, department, created_at FROM lecturers WHERE id=$1`
+	q := `SELECT id, user_id, lecturer_id, department, created_at FROM lecturers WHERE id=$1 AND deleted_at IS NULL`
 	row := r.db.QueryRowContext(ctx, q, id)
 	if err := row.Scan(&out.ID, &out.UserID, &out.LecturerID, &out.Department, &out.CreatedAt); err != nil {
 		return nil, err
@@ -45,7 +45,7 @@ func (r *lecturerRepository) GetByID(ctx context.Context, id string) (*pgmodel.L
 
 func (r *lecturerRepository) GetByUserID(ctx context.Context, userID string) (*pgmodel.Lecturer, error) {
 	var out pgmodel.Lecturer
-	q := `SELECT id, user_id, lecturer_id, department, created_at FROM lecturers WHERE user_id=$1`
+	q := `SELECT id, user_id, lecturer_id, department, created_at FROM lecturers WHERE user_id=$1 AND deleted_at IS NULL`
 	row := r.db.QueryRowContext(ctx, q, userID)
 	if err := row.Scan(&out.ID, &out.UserID, &out.LecturerID, &out.Department, &out.CreatedAt); err != nil {
 		return nil, err
@@ -54,7 +54,7 @@ func (r *lecturerRepository) GetByUserID(ctx context.Context, userID string) (*p
 }
 
 func (r *lecturerRepository) ListAll(ctx context.Context) ([]*pgmodel.Lecturer, error) {
-	q := `SELECT id, user_id, lecturer_id, department, created_at FROM lecturers ORDER BY lecturer_id`
+	q := `SELECT id, user_id, lecturer_id, department, created_at FROM lecturers WHERE deleted_at IS NULL ORDER BY lecturer_id`
 	rows, err := r.db.QueryContext(ctx, q)
 	if err != nil {
 		return nil, err
@@ -74,7 +74,7 @@ func (r *lecturerRepository) ListAll(ctx context.Context) ([]*pgmodel.Lecturer,
 
 func (r *lecturerRepository) GetAdvisees(ctx context.Context, lecturerID string) ([]*pgmodel.Student, error) {
 	q := `SELECT id, user_id, student_id, program_study, academic_year, advisor_id, created_at 
-	      FROM students WHERE advisor_id=$1 ORDER BY created_at DESC`
+	      FROM students WHERE advisor_id=$1 AND deleted_at IS NULL ORDER BY created_at DESC`
 	rows, err := r.db.QueryContext(ctx, q, lecturerID)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,136 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	pgmodel "clean-arch/app/model/postgre"
+)
+
+// OutboxRepository manages achievement_outbox table.
+type OutboxRepository interface {
+	// Enqueue stores a message; it is not picked up by ClaimPending before notBefore,
+	// which leaves the writer time to apply it itself right after commit.
+	Enqueue(ctx context.Context, m *pgmodel.OutboxMessage, notBefore time.Time) error
+	// ClaimPending leases up to limit due messages, oldest first and at most one per
+	// reference so operations on one achievement are applied in order. Failed messages
+	// are parked: they no longer hold back later messages of their reference.
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*pgmodel.OutboxMessage, error)
+	// ClaimIfNext leases message id for lease unless an earlier message of the same
+	// reference is still pending; false means it is left to the relay.
+	ClaimIfNext(ctx context.Context, id string, lease time.Duration) (bool, error)
+	MarkDone(ctx context.Context, id string) error
+	// MarkRetry records a failed attempt; the message becomes failed when retryAt is nil.
+	MarkRetry(ctx context.Context, id string, lastErr string, retryAt *time.Time) error
+	// ListUnsettled returns pending and failed messages.
+	ListUnsettled(ctx context.Context) ([]*pgmodel.OutboxMessage, error)
+	WithTx(tx *sql.Tx) OutboxRepository
+}
+
+// Implementation
+type outboxRepository struct {
+	db DBTX
+}
+
+func NewOutboxRepository(db *sql.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) WithTx(tx *sql.Tx) OutboxRepository {
+	return &outboxRepository{db: tx}
+}
+
+const outboxColumns = `id, reference_id, operation, payload, status, attempts, last_error, next_attempt_at, created_at, processed_at`
+
+func scanOutbox(row interface{ Scan(...interface{}) error }) (*pgmodel.OutboxMessage, error) {
+	var m pgmodel.OutboxMessage
+	if err := row.Scan(&m.ID, &m.ReferenceID, &m.Operation, &m.Payload, &m.Status, &m.Attempts,
+		&m.LastError, &m.NextAttemptAt, &m.CreatedAt, &m.ProcessedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *outboxRepository) list(ctx context.Context, q string, args ...interface{}) ([]*pgmodel.OutboxMessage, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*pgmodel.OutboxMessage{}
+	for rows.Next() {
+		m, err := scanOutbox(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (r *outboxRepository) Enqueue(ctx context.Context, m *pgmodel.OutboxMessage, notBefore time.Time) error {
+	m.Status = "pending"
+	m.CreatedAt = time.Now()
+	m.NextAttemptAt = notBefore
+	var payload interface{}
+	if m.Payload != nil {
+		payload = string(m.Payload)
+	}
+	q := `INSERT INTO achievement_outbox (id, reference_id, operation, payload, status, next_attempt_at, created_at)
+	      VALUES ($1,$2,$3,$4,$5,$6,$7)`
+	_, err := r.db.ExecContext(ctx, q, m.ID, m.ReferenceID, m.Operation, payload, m.Status, m.NextAttemptAt, m.CreatedAt)
+	return err
+}
+
+func (r *outboxRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*pgmodel.OutboxMessage, error) {
+	q := `UPDATE achievement_outbox SET next_attempt_at = $1
+	      WHERE id IN (
+	          SELECT o.id FROM achievement_outbox o
+	          WHERE o.status = 'pending' AND o.next_attempt_at <= NOW()
+	            AND NOT EXISTS (
+	                SELECT 1 FROM achievement_outbox p
+	                WHERE p.reference_id = o.reference_id AND p.status = 'pending' AND p.created_at < o.created_at)
+	          ORDER BY o.created_at
+	          LIMIT $2
+	          FOR UPDATE SKIP LOCKED)
+	      RETURNING ` + outboxColumns
+	return r.list(ctx, q, time.Now().Add(lease), limit)
+}
+
+func (r *outboxRepository) ClaimIfNext(ctx context.Context, id string, lease time.Duration) (bool, error) {
+	q := `UPDATE achievement_outbox o SET next_attempt_at = $1
+	      WHERE o.id = $2 AND o.status = 'pending'
+	        AND NOT EXISTS (
+	            SELECT 1 FROM achievement_outbox p
+	            WHERE p.reference_id = o.reference_id AND p.status = 'pending' AND p.created_at < o.created_at)`
+	res, err := r.db.ExecContext(ctx, q, time.Now().Add(lease), id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *outboxRepository) MarkDone(ctx context.Context, id string) error {
+	q := `UPDATE achievement_outbox SET status='done', attempts=attempts+1, last_error=NULL, processed_at=$1 WHERE id=$2`
+	_, err := r.db.ExecContext(ctx, q, time.Now(), id)
+	return err
+}
+
+func (r *outboxRepository) MarkRetry(ctx context.Context, id string, lastErr string, retryAt *time.Time) error {
+	if retryAt == nil {
+		q := `UPDATE achievement_outbox SET status='failed', attempts=attempts+1, last_error=$1 WHERE id=$2`
+		_, err := r.db.ExecContext(ctx, q, lastErr, id)
+		return err
+	}
+	q := `UPDATE achievement_outbox SET attempts=attempts+1, last_error=$1, next_attempt_at=$2 WHERE id=$3`
+	_, err := r.db.ExecContext(ctx, q, lastErr, *retryAt, id)
+	return err
+}
+
+func (r *outboxRepository) ListUnsettled(ctx context.Context) ([]*pgmodel.OutboxMessage, error) {
+	q := `SELECT ` + outboxColumns + ` FROM achievement_outbox WHERE status <> 'done' ORDER BY created_at`
+	return r.list(ctx, q)
+}
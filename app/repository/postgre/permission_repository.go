@@ -13,6 +13,10 @@ type PermissionRepository interface {
 	GetByID(ctx context.Context, id string) (*pgmodel.Permission, error)
 	GetByName(ctx context.Context, name string) (*pgmodel.Permission, error)
 	ListAll(ctx context.Context) ([]*pgmodel.Permission, error)
+	// Update changes every field but the id (sql.ErrNoRows when the permission does not exist).
+	Update(ctx context.Context, p *pgmodel.Permission) error
+	// Delete removes the permission and its role assignments (sql.ErrNoRows when absent).
+	Delete(ctx context.Context, id string) error
 }
 
 // Implementation
@@ -68,3 +72,26 @@ func (r *permissionRepository) ListAll(ctx context.Context) ([]*pgmodel.Permissi
 	}
 	return out, nil
 }
+
+func (r *permissionRepository) Update(ctx context.Context, p *pgmodel.Permission) error {
+	q := `UPDATE permissions SET name=$1, resource=$2, action=$3, description=$4 WHERE id=$5`
+	res, err := r.db.ExecContext(ctx, q, p.Name, p.Resource, p.Action, p.Description, p.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *permissionRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM permissions WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
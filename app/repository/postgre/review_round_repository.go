@@ -0,0 +1,116 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	pgmodel "clean-arch/app/model/postgre"
+)
+
+// ReviewRoundRepository manages achievement_review_rounds table.
+type ReviewRoundRepository interface {
+	Create(ctx context.Context, rr *pgmodel.ReviewRound) error
+	ListByReference(ctx context.Context, referenceID string) ([]*pgmodel.ReviewRound, error)
+	// GetLatest returns the round with the highest number for the reference.
+	GetLatest(ctx context.Context, referenceID string) (*pgmodel.ReviewRound, error)
+	// Decide records the reviewer's decision on a round that is still pending.
+	Decide(ctx context.Context, id string, decision string, decidedBy string, note *string) error
+	WithTx(tx *sql.Tx) ReviewRoundRepository
+}
+
+// Implementation
+type reviewRoundRepository struct {
+	db DBTX
+}
+
+func NewReviewRoundRepository(db *sql.DB) ReviewRoundRepository {
+	return &reviewRoundRepository{db: db}
+}
+
+func (r *reviewRoundRepository) WithTx(tx *sql.Tx) ReviewRoundRepository {
+	return &reviewRoundRepository{db: tx}
+}
+
+const reviewRoundColumns = `id, reference_id, round, submitted_by, submitted_at, snapshot, changes, decision, decided_by, decided_at, note, duplicates, duplicate_override`
+
+func scanReviewRound(row interface{ Scan(...interface{}) error }) (*pgmodel.ReviewRound, error) {
+	var rr pgmodel.ReviewRound
+	var snapshot, changes, duplicates sql.NullString
+	if err := row.Scan(&rr.ID, &rr.ReferenceID, &rr.Round, &rr.SubmittedBy, &rr.SubmittedAt,
+		&snapshot, &changes, &rr.Decision, &rr.DecidedBy, &rr.DecidedAt, &rr.Note,
+		&duplicates, &rr.DuplicateOverride); err != nil {
+		return nil, err
+	}
+	if duplicates.Valid {
+		_ = json.Unmarshal([]byte(duplicates.String), &rr.Duplicates)
+	}
+	if snapshot.Valid {
+		_ = json.Unmarshal([]byte(snapshot.String), &rr.Snapshot)
+	}
+	if changes.Valid {
+		_ = json.Unmarshal([]byte(changes.String), &rr.Changes)
+	}
+	return &rr, nil
+}
+
+func (r *reviewRoundRepository) Create(ctx context.Context, rr *pgmodel.ReviewRound) error {
+	if rr.SubmittedAt.IsZero() {
+		rr.SubmittedAt = time.Now()
+	}
+	snapshot, err := json.Marshal(rr.Snapshot)
+	if err != nil {
+		return err
+	}
+	var changes []byte
+	if rr.Changes != nil {
+		if changes, err = json.Marshal(rr.Changes); err != nil {
+			return err
+		}
+	}
+	var duplicates []byte
+	if rr.Duplicates != nil {
+		if duplicates, err = json.Marshal(rr.Duplicates); err != nil {
+			return err
+		}
+	}
+	q := `INSERT INTO achievement_review_rounds (id, reference_id, round, submitted_by, submitted_at, snapshot, changes, duplicates, duplicate_override)
+	      VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`
+	_, err = r.db.ExecContext(ctx, q, rr.ID, rr.ReferenceID, rr.Round, rr.SubmittedBy, rr.SubmittedAt, snapshot, changes,
+		duplicates, rr.DuplicateOverride)
+	return err
+}
+
+func (r *reviewRoundRepository) ListByReference(ctx context.Context, referenceID string) ([]*pgmodel.ReviewRound, error) {
+	q := `SELECT ` + reviewRoundColumns + ` FROM achievement_review_rounds
+	      WHERE reference_id=$1 ORDER BY round`
+	rows, err := r.db.QueryContext(ctx, q, referenceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*pgmodel.ReviewRound{}
+	for rows.Next() {
+		rr, err := scanReviewRound(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rr)
+	}
+	return out, rows.Err()
+}
+
+func (r *reviewRoundRepository) GetLatest(ctx context.Context, referenceID string) (*pgmodel.ReviewRound, error) {
+	q := `SELECT ` + reviewRoundColumns + ` FROM achievement_review_rounds
+	      WHERE reference_id=$1 ORDER BY round DESC LIMIT 1`
+	return scanReviewRound(r.db.QueryRowContext(ctx, q, referenceID))
+}
+
+func (r *reviewRoundRepository) Decide(ctx context.Context, id string, decision string, decidedBy string, note *string) error {
+	q := `UPDATE achievement_review_rounds SET decision=$1, decided_by=$2, decided_at=$3, note=$4
+	      WHERE id=$5 AND decision IS NULL`
+	_, err := r.db.ExecContext(ctx, q, decision, decidedBy, time.Now(), note, id)
+	return err
+}
@@ -12,6 +12,8 @@ type RolePermissionRepository interface {
 	Assign(ctx context.Context, roleID string, permissionID string) error
 	Remove(ctx context.Context, roleID string, permissionID string) error
 	ListByRole(ctx context.Context, roleID string) ([]*pgmodel.Permission, error)
+	// ListRoleIDs returns the roles the permission is assigned to.
+	ListRoleIDs(ctx context.Context, permissionID string) ([]string, error)
 }
 
 // Implementation
@@ -59,3 +61,21 @@ func (r *rolePermissionRepository) ListByRole(ctx context.Context, roleID string
 	}
 	return out, nil
 }
+
+func (r *rolePermissionRepository) ListRoleIDs(ctx context.Context, permissionID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT role_id FROM role_permissions WHERE permission_id=$1`, permissionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
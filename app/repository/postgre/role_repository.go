@@ -16,6 +16,12 @@ type RoleRepository interface {
 	GetByID(ctx context.Context, id string) (*pgmodel.Role, error)
 	GetByName(ctx context.Context, name string) (*pgmodel.Role, error)
 	ListAll(ctx context.Context) ([]*pgmodel.Role, error)
+	// Update changes name, description and parent (sql.ErrNoRows when the role does not exist).
+	Update(ctx context.Context, r *pgmodel.Role) error
+	// Delete removes the role and its permission assignments (sql.ErrNoRows when absent).
+	Delete(ctx context.Context, id string) error
+	// CountUsers counts the users holding the role, including those in the trash.
+	CountUsers(ctx context.Context, id string) (int, error)
 }
 
 // -----------------------------
@@ -33,9 +39,9 @@ func (r *roleRepository) Create(ctx context.Context, role *pgmodel.Role) error {
 	role.CreatedAt = time.Now()
 
 	_, err := r.db.ExecContext(ctx,
-		`INSERT INTO roles (id, name, description, created_at)
-		 VALUES ($1,$2,$3,$4)`,
-		role.ID, role.Name, role.Description, role.CreatedAt,
+		`INSERT INTO roles (id, name, description, parent_id, created_at)
+		 VALUES ($1,$2,$3,$4,$5)`,
+		role.ID, role.Name, role.Description, role.ParentID, role.CreatedAt,
 	)
 
 	return err
@@ -44,13 +50,13 @@ func (r *roleRepository) Create(ctx context.Context, role *pgmodel.Role) error {
 
 func (r *roleRepository) GetByID(ctx context.Context, id string) (*pgmodel.Role, error) {
 	query := `
-		SELECT id, name, description, created_at
+		SELECT id, name, description, parent_id, created_at
 		FROM roles WHERE id=$1
 	`
 	row := r.db.QueryRowContext(ctx, query, id)
 
 	var out pgmodel.Role
-	err := row.Scan(&out.ID, &out.Name, &out.Description, &out.CreatedAt)
+	err := row.Scan(&out.ID, &out.Name, &out.Description, &out.ParentID, &out.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -60,13 +66,13 @@ func (r *roleRepository) GetByID(ctx context.Context, id string) (*pgmodel.Role,
 
 func (r *roleRepository) GetByName(ctx context.Context, name string) (*pgmodel.Role, error) {
 	query := `
-		SELECT id, name, description, created_at
+		SELECT id, name, description, parent_id, created_at
 		FROM roles WHERE name=$1
 	`
 	row := r.db.QueryRowContext(ctx, query, name)
 
 	var out pgmodel.Role
-	err := row.Scan(&out.ID, &out.Name, &out.Description, &out.CreatedAt)
+	err := row.Scan(&out.ID, &out.Name, &out.Description, &out.ParentID, &out.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +82,7 @@ func (r *roleRepository) GetByName(ctx context.Context, name string) (*pgmodel.R
 
 func (r *roleRepository) ListAll(ctx context.Context) ([]*pgmodel.Role, error) {
 	query := `
-		SELECT id, name, description, created_at
+		SELECT id, name, description, parent_id, created_at
 		FROM roles ORDER BY name
 	`
 	rows, err := r.db.QueryContext(ctx, query)
@@ -88,7 +94,7 @@ func (r *roleRepository) ListAll(ctx context.Context) ([]*pgmodel.Role, error) {
 	var out []*pgmodel.Role
 	for rows.Next() {
 		var r pgmodel.Role
-		err := rows.Scan(&r.ID, &r.Name, &r.Description, &r.CreatedAt)
+		err := rows.Scan(&r.ID, &r.Name, &r.Description, &r.ParentID, &r.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -96,3 +102,34 @@ func (r *roleRepository) ListAll(ctx context.Context) ([]*pgmodel.Role, error) {
 	}
 	return out, nil
 }
+
+func (r *roleRepository) Update(ctx context.Context, role *pgmodel.Role) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE roles SET name=$1, description=$2, parent_id=$3 WHERE id=$4`,
+		role.Name, role.Description, role.ParentID, role.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *roleRepository) Delete(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM roles WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *roleRepository) CountUsers(ctx context.Context, id string) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_roles WHERE role_id=$1`, id).Scan(&n)
+	return n, err
+}
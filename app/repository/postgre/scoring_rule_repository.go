@@ -0,0 +1,145 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	pgmodel "clean-arch/app/model/postgre"
+)
+
+// ScoringRuleRepository manages achievement_scoring_rules and achievement_point_caps tables.
+type ScoringRuleRepository interface {
+	ListRules(ctx context.Context) ([]*pgmodel.ScoringRule, error)
+	GetRule(ctx context.Context, id string) (*pgmodel.ScoringRule, error)
+	CreateRule(ctx context.Context, rule *pgmodel.ScoringRule) error
+	UpdateRule(ctx context.Context, rule *pgmodel.ScoringRule) error
+	// DeleteRule returns sql.ErrNoRows when the rule does not exist.
+	DeleteRule(ctx context.Context, id string) error
+
+	ListCaps(ctx context.Context) ([]*pgmodel.PointCap, error)
+	// UpsertCap creates the cap of a category or replaces it.
+	UpsertCap(ctx context.Context, c *pgmodel.PointCap) error
+	DeleteCap(ctx context.Context, category string) error
+}
+
+// Implementation
+type scoringRuleRepository struct {
+	db *sql.DB
+}
+
+func NewScoringRuleRepository(db *sql.DB) ScoringRuleRepository {
+	return &scoringRuleRepository{db: db}
+}
+
+const scoringRuleColumns = `id, category, level, rank, points, description, updated_by, created_at, updated_at`
+
+func scanScoringRule(row interface{ Scan(...interface{}) error }) (*pgmodel.ScoringRule, error) {
+	var r pgmodel.ScoringRule
+	if err := row.Scan(&r.ID, &r.Category, &r.Level, &r.Rank, &r.Points, &r.Description,
+		&r.UpdatedBy, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (r *scoringRuleRepository) ListRules(ctx context.Context) ([]*pgmodel.ScoringRule, error) {
+	q := `SELECT ` + scoringRuleColumns + ` FROM achievement_scoring_rules ORDER BY category, level, rank DESC`
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*pgmodel.ScoringRule{}
+	for rows.Next() {
+		rule, err := scanScoringRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rule)
+	}
+	return out, rows.Err()
+}
+
+func (r *scoringRuleRepository) GetRule(ctx context.Context, id string) (*pgmodel.ScoringRule, error) {
+	q := `SELECT ` + scoringRuleColumns + ` FROM achievement_scoring_rules WHERE id=$1`
+	return scanScoringRule(r.db.QueryRowContext(ctx, q, id))
+}
+
+func (r *scoringRuleRepository) CreateRule(ctx context.Context, rule *pgmodel.ScoringRule) error {
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	q := `INSERT INTO achievement_scoring_rules (` + scoringRuleColumns + `)
+	      VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`
+	_, err := r.db.ExecContext(ctx, q, rule.ID, rule.Category, rule.Level, rule.Rank, rule.Points,
+		rule.Description, rule.UpdatedBy, rule.CreatedAt, rule.UpdatedAt)
+	return err
+}
+
+func (r *scoringRuleRepository) UpdateRule(ctx context.Context, rule *pgmodel.ScoringRule) error {
+	rule.UpdatedAt = time.Now()
+	q := `UPDATE achievement_scoring_rules
+	      SET category=$1, level=$2, rank=$3, points=$4, description=$5, updated_by=$6, updated_at=$7
+	      WHERE id=$8`
+	res, err := r.db.ExecContext(ctx, q, rule.Category, rule.Level, rule.Rank, rule.Points,
+		rule.Description, rule.UpdatedBy, rule.UpdatedAt, rule.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *scoringRuleRepository) DeleteRule(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM achievement_scoring_rules WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *scoringRuleRepository) ListCaps(ctx context.Context) ([]*pgmodel.PointCap, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT category, max_points, updated_by, updated_at FROM achievement_point_caps ORDER BY category`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*pgmodel.PointCap{}
+	for rows.Next() {
+		var c pgmodel.PointCap
+		if err := rows.Scan(&c.Category, &c.MaxPoints, &c.UpdatedBy, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, &c)
+	}
+	return out, rows.Err()
+}
+
+func (r *scoringRuleRepository) UpsertCap(ctx context.Context, c *pgmodel.PointCap) error {
+	c.UpdatedAt = time.Now()
+	q := `INSERT INTO achievement_point_caps (category, max_points, updated_by, updated_at)
+	      VALUES ($1,$2,$3,$4)
+	      ON CONFLICT (category) DO UPDATE
+	      SET max_points=EXCLUDED.max_points, updated_by=EXCLUDED.updated_by, updated_at=EXCLUDED.updated_at`
+	_, err := r.db.ExecContext(ctx, q, c.Category, c.MaxPoints, c.UpdatedBy, c.UpdatedAt)
+	return err
+}
+
+func (r *scoringRuleRepository) DeleteCap(ctx context.Context, category string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM achievement_point_caps WHERE category=$1`, category)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
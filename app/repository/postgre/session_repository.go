@@ -0,0 +1,128 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	pgmodel "clean-arch/app/model/postgre"
+)
+
+// SessionRepository manages user_sessions and refresh_tokens tables.
+type SessionRepository interface {
+	CreateSession(ctx context.Context, s *pgmodel.UserSession) error
+	GetSession(ctx context.Context, id string) (*pgmodel.UserSession, error)
+	ListActiveByUser(ctx context.Context, userID string) ([]*pgmodel.UserSession, error)
+	TouchSession(ctx context.Context, id string, ip string) error
+	RevokeSession(ctx context.Context, id string, reason string) error
+	RevokeAllByUser(ctx context.Context, userID string, reason string) error
+
+	CreateRefreshToken(ctx context.Context, t *pgmodel.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*pgmodel.RefreshToken, error)
+	// MarkRefreshTokenUsed returns false when the token was already used (rotation race or reuse).
+	MarkRefreshTokenUsed(ctx context.Context, id string) (bool, error)
+}
+
+// Implementation
+type sessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+const sessionColumns = `id, user_id, device_name, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at, revoke_reason`
+
+func scanSession(row interface{ Scan(...interface{}) error }) (*pgmodel.UserSession, error) {
+	var s pgmodel.UserSession
+	if err := row.Scan(&s.ID, &s.UserID, &s.DeviceName, &s.UserAgent, &s.IPAddress,
+		&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt, &s.RevokeReason); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *sessionRepository) CreateSession(ctx context.Context, s *pgmodel.UserSession) error {
+	now := time.Now()
+	s.CreatedAt = now
+	s.LastUsedAt = now
+	q := `INSERT INTO user_sessions (id, user_id, device_name, user_agent, ip_address, created_at, last_used_at, expires_at)
+	      VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`
+	_, err := r.db.ExecContext(ctx, q, s.ID, s.UserID, s.DeviceName, s.UserAgent, s.IPAddress, s.CreatedAt, s.LastUsedAt, s.ExpiresAt)
+	return err
+}
+
+func (r *sessionRepository) GetSession(ctx context.Context, id string) (*pgmodel.UserSession, error) {
+	q := `SELECT ` + sessionColumns + ` FROM user_sessions WHERE id=$1`
+	return scanSession(r.db.QueryRowContext(ctx, q, id))
+}
+
+func (r *sessionRepository) ListActiveByUser(ctx context.Context, userID string) ([]*pgmodel.UserSession, error) {
+	q := `SELECT ` + sessionColumns + ` FROM user_sessions
+	      WHERE user_id=$1 AND revoked_at IS NULL AND expires_at > NOW()
+	      ORDER BY last_used_at DESC`
+	rows, err := r.db.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*pgmodel.UserSession{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (r *sessionRepository) TouchSession(ctx context.Context, id string, ip string) error {
+	q := `UPDATE user_sessions SET last_used_at=$1, ip_address=$2 WHERE id=$3`
+	_, err := r.db.ExecContext(ctx, q, time.Now(), ip, id)
+	return err
+}
+
+func (r *sessionRepository) RevokeSession(ctx context.Context, id string, reason string) error {
+	q := `UPDATE user_sessions SET revoked_at=$1, revoke_reason=$2 WHERE id=$3 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, q, time.Now(), reason, id)
+	return err
+}
+
+func (r *sessionRepository) RevokeAllByUser(ctx context.Context, userID string, reason string) error {
+	q := `UPDATE user_sessions SET revoked_at=$1, revoke_reason=$2 WHERE user_id=$3 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, q, time.Now(), reason, userID)
+	return err
+}
+
+func (r *sessionRepository) CreateRefreshToken(ctx context.Context, t *pgmodel.RefreshToken) error {
+	t.CreatedAt = time.Now()
+	q := `INSERT INTO refresh_tokens (id, session_id, token_hash, created_at, expires_at) VALUES ($1,$2,$3,$4,$5)`
+	_, err := r.db.ExecContext(ctx, q, t.ID, t.SessionID, t.TokenHash, t.CreatedAt, t.ExpiresAt)
+	return err
+}
+
+func (r *sessionRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*pgmodel.RefreshToken, error) {
+	var out pgmodel.RefreshToken
+	q := `SELECT id, session_id, token_hash, created_at, expires_at, used_at FROM refresh_tokens WHERE token_hash=$1`
+	row := r.db.QueryRowContext(ctx, q, hash)
+	if err := row.Scan(&out.ID, &out.SessionID, &out.TokenHash, &out.CreatedAt, &out.ExpiresAt, &out.UsedAt); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *sessionRepository) MarkRefreshTokenUsed(ctx context.Context, id string) (bool, error) {
+	q := `UPDATE refresh_tokens SET used_at=$1 WHERE id=$2 AND used_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
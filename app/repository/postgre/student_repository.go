@@ -37,7 +37,7 @@ func (r *studentRepository) Create(ctx context.Context, s *pgmodel.Student) erro
 
 func (r *studentRepository) GetByID(ctx context.Context, id string) (*pgmodel.Student, error) {
 	var out pgmodel.Student
-	q := `SELECT id, user_id, student_id, program_study, academic_year, advisor_id, created_at FROM students WHERE id=$1`
+	q := `SELECT id, user_id, student_id, program_study, academic_year, advisor_id, created_at FROM students WHERE id=$1 AND deleted_at IS NULL`
 	row := r.db.QueryRowContext(ctx, q, id)
 	if err := row.Scan(&out.ID, &out.UserID, &out.StudentID, &out.Program, &out.AcademicYear, &out.AdvisorID, &out.CreatedAt); err != nil {
 		return nil, err
@@ -47,7 +47,7 @@ func (r *studentRepository) GetByID(ctx context.Context, id string) (*pgmodel.St
 
 func (r *studentRepository) GetByUserID(ctx context.Context, userID string) (*pgmodel.Student, error) {
 	var out pgmodel.Student
-	q := `SELECT id, user_id, student_id, program_study, academic_year, advisor_id, created_at FROM students WHERE user_id=$1`
+	q := `SELECT id, user_id, student_id, program_study, academic_year, advisor_id, created_at FROM students WHERE user_id=$1 AND deleted_at IS NULL`
 	row := r.db.QueryRowContext(ctx, q, userID)
 	if err := row.Scan(&out.ID, &out.UserID, &out.StudentID, &out.Program, &out.AcademicYear, &out.AdvisorID, &out.CreatedAt); err != nil {
 		return nil, err
@@ -56,7 +56,7 @@ func (r *studentRepository) GetByUserID(ctx context.Context, userID string) (*pg
 }
 
 func (r *studentRepository) ListByAdvisor(ctx context.Context, advisorID string) ([]*pgmodel.Student, error) {
-	q := `SELECT id, user_id, student_id, program_study, academic_year, advisor_id, created_at FROM students WHERE advisor_id=$1 ORDER BY created_at DESC`
+	q := `SELECT id, user_id, student_id, program_study, academic_year, advisor_id, created_at FROM students WHERE advisor_id=$1 AND deleted_at IS NULL ORDER BY created_at DESC`
 	rows, err := r.db.QueryContext(ctx, q, advisorID)
 	if err != nil {
 		return nil, err
@@ -75,7 +75,7 @@ func (r *studentRepository) ListByAdvisor(ctx context.Context, advisorID string)
 }
 
 func (r *studentRepository) ListAll(ctx context.Context) ([]*pgmodel.Student, error) {
-	q := `SELECT id, user_id, student_id, program_study, academic_year, advisor_id, created_at FROM students ORDER BY created_at DESC`
+	q := `SELECT id, user_id, student_id, program_study, academic_year, advisor_id, created_at FROM students WHERE deleted_at IS NULL ORDER BY created_at DESC`
 	rows, err := r.db.QueryContext(ctx, q)
 	if err != nil {
 		return nil, err
@@ -8,9 +8,12 @@ import (
 
 // TokenRepository mendefinisikan operasi untuk blacklist token.
 // Interface ini harus cocok dengan yang dibutuhkan oleh AuthService.
+// Yang disimpan adalah JWT ID (klaim jti), bukan string token lengkap.
 type TokenRepository interface {
-	AddToBlacklist(ctx context.Context, token string, expiresAt time.Time) error
-	IsBlacklisted(ctx context.Context, token string) (bool, error)
+	AddToBlacklist(ctx context.Context, jti string, expiresAt time.Time) error
+	IsBlacklisted(ctx context.Context, jti string) (bool, error)
+	ListActive(ctx context.Context) (map[string]time.Time, error)
+	DeleteExpired(ctx context.Context) (int64, error)
 }
 
 // Implementation
@@ -23,23 +26,52 @@ func NewTokenRepository(db *sql.DB) TokenRepository {
 	return &tokenRepository{db: db}
 }
 
-// AddToBlacklist menyimpan token ke tabel blacklist
-func (r *tokenRepository) AddToBlacklist(ctx context.Context, token string, expiresAt time.Time) error {
-	// Kita simpan expires_at agar nanti bisa dibuat cron job untuk cleanup data sampah
-	query := `INSERT INTO token_blacklist (token, expires_at) VALUES ($1, $2) ON CONFLICT (token) DO NOTHING`
-	_, err := r.db.ExecContext(ctx, query, token, expiresAt)
+// AddToBlacklist menyimpan jti ke tabel blacklist
+func (r *tokenRepository) AddToBlacklist(ctx context.Context, jti string, expiresAt time.Time) error {
+	// Kita simpan expires_at agar sweeper bisa membersihkan data sampah
+	query := `INSERT INTO token_blacklist (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING`
+	_, err := r.db.ExecContext(ctx, query, jti, expiresAt)
 	return err
 }
 
-// IsBlacklisted mengecek apakah token ada di database
-func (r *tokenRepository) IsBlacklisted(ctx context.Context, token string) (bool, error) {
+// IsBlacklisted mengecek apakah jti ada di database
+func (r *tokenRepository) IsBlacklisted(ctx context.Context, jti string) (bool, error) {
 	// Kita gunakan EXISTS agar performa lebih cepat (tidak perlu scan data)
-	query := `SELECT EXISTS(SELECT 1 FROM token_blacklist WHERE token=$1)`
+	query := `SELECT EXISTS(SELECT 1 FROM token_blacklist WHERE jti=$1)`
 
 	var exists bool
-	err := r.db.QueryRowContext(ctx, query, token).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, query, jti).Scan(&exists)
 	if err != nil {
 		return false, err
 	}
 	return exists, nil
 }
+
+// ListActive mengembalikan semua jti yang belum expired (untuk mengisi cache in-process)
+func (r *tokenRepository) ListActive(ctx context.Context) (map[string]time.Time, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT jti, expires_at FROM token_blacklist WHERE expires_at > NOW()`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]time.Time)
+	for rows.Next() {
+		var jti string
+		var exp time.Time
+		if err := rows.Scan(&jti, &exp); err != nil {
+			return nil, err
+		}
+		out[jti] = exp
+	}
+	return out, rows.Err()
+}
+
+// DeleteExpired menghapus baris yang token-nya sudah kadaluarsa
+func (r *tokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM token_blacklist WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
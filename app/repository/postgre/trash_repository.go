@@ -0,0 +1,204 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	pgmodel "clean-arch/app/model/postgre"
+)
+
+// ErrUnknownTrashEntity is returned for an entity without a table in trashTables.
+var ErrUnknownTrashEntity = errors.New("unknown trash entity")
+
+// TrashRepository moves users, students and lecturers in and out of the trash (deleted_at).
+// Achievements are trashed by the workflow (status deleted), see AchievementRefRepository.
+type TrashRepository interface {
+	// SoftDelete trashes a live row (sql.ErrNoRows when absent or already trashed). A user's
+	// student and lecturer profiles go to the trash with it. A non-nil version, for versioned
+	// entities, trashes the row only if it still has that version (ErrStaleVersion otherwise).
+	SoftDelete(ctx context.Context, entity string, id string, deletedBy string, at time.Time, version *int) error
+	// Restore takes a row out of the trash (sql.ErrNoRows when it is not there), together with
+	// the profiles that were trashed with a user.
+	Restore(ctx context.Context, entity string, id string) error
+	// List returns trashed rows, most recently deleted first; a non-nil before keeps only
+	// those deleted before it. PurgeAt is left for the caller.
+	List(ctx context.Context, entity string, before *time.Time) ([]*pgmodel.TrashItem, error)
+	Get(ctx context.Context, entity string, id string) (*pgmodel.TrashItem, error)
+	// Purge deletes a trashed row for good (sql.ErrNoRows when it is not in the trash).
+	Purge(ctx context.Context, entity string, id string) error
+	// StudentIDsOfUser returns the student profile ids of a user, trashed or not.
+	StudentIDsOfUser(ctx context.Context, userID string) ([]string, error)
+	// WithTx returns a repository bound to the transaction (see Transactor).
+	WithTx(tx *sql.Tx) TrashRepository
+}
+
+// trashTable is how an entity is stored: its table, the column shown as label, the owner column
+// and whether the table has a row version (see migration 0012)
+type trashTable struct {
+	table     string
+	label     string
+	owner     string
+	versioned bool
+}
+
+var trashTables = map[string]trashTable{
+	pgmodel.TrashUsers:     {table: "users", label: "username", owner: "NULL::uuid", versioned: true},
+	pgmodel.TrashStudents:  {table: "students", label: "student_id", owner: "user_id"},
+	pgmodel.TrashLecturers: {table: "lecturers", label: "lecturer_id", owner: "user_id"},
+}
+
+// Implementation
+type trashRepository struct {
+	db DBTX
+}
+
+func NewTrashRepository(db *sql.DB) TrashRepository {
+	return &trashRepository{db: db}
+}
+
+func (r *trashRepository) WithTx(tx *sql.Tx) TrashRepository {
+	return &trashRepository{db: tx}
+}
+
+func (r *trashRepository) SoftDelete(ctx context.Context, entity string, id string, deletedBy string, at time.Time, version *int) error {
+	t, ok := trashTables[entity]
+	if !ok {
+		return ErrUnknownTrashEntity
+	}
+	q := `UPDATE ` + t.table + ` SET deleted_at=$1, deleted_by=$2 WHERE id=$3 AND deleted_at IS NULL`
+	args := []interface{}{at, deletedBy, id}
+	if t.versioned {
+		q = `UPDATE ` + t.table + ` SET deleted_at=$1, deleted_by=$2, version=version+1
+		     WHERE id=$3 AND deleted_at IS NULL AND ($4::int IS NULL OR version=$4)`
+		args = append(args, version)
+	}
+	res, err := r.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if version == nil || !t.versioned {
+			return sql.ErrNoRows
+		}
+		// tell a stale version from a row that is gone
+		var live bool
+		q := `SELECT EXISTS (SELECT 1 FROM ` + t.table + ` WHERE id=$1 AND deleted_at IS NULL)`
+		if err := r.db.QueryRowContext(ctx, q, id).Scan(&live); err != nil {
+			return err
+		}
+		if live {
+			return ErrStaleVersion
+		}
+		return sql.ErrNoRows
+	}
+	if entity != pgmodel.TrashUsers {
+		return nil
+	}
+	for _, profile := range []string{"students", "lecturers"} {
+		q := `UPDATE ` + profile + ` SET deleted_at=$1, deleted_by=$2 WHERE user_id=$3 AND deleted_at IS NULL`
+		if _, err := r.db.ExecContext(ctx, q, at, deletedBy, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *trashRepository) Restore(ctx context.Context, entity string, id string) error {
+	t, ok := trashTables[entity]
+	if !ok {
+		return ErrUnknownTrashEntity
+	}
+	var deletedAt time.Time
+	q := `UPDATE ` + t.table + ` x SET deleted_at=NULL, deleted_by=NULL
+	      FROM (SELECT deleted_at FROM ` + t.table + ` WHERE id=$1) old
+	      WHERE x.id=$1 AND x.deleted_at IS NOT NULL
+	      RETURNING old.deleted_at`
+	if err := r.db.QueryRowContext(ctx, q, id).Scan(&deletedAt); err != nil {
+		return err
+	}
+	if entity != pgmodel.TrashUsers {
+		return nil
+	}
+	// profiles trashed on their own before the user stay in the trash
+	for _, profile := range []string{"students", "lecturers"} {
+		q := `UPDATE ` + profile + ` SET deleted_at=NULL, deleted_by=NULL WHERE user_id=$1 AND deleted_at=$2`
+		if _, err := r.db.ExecContext(ctx, q, id, deletedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *trashRepository) List(ctx context.Context, entity string, before *time.Time) ([]*pgmodel.TrashItem, error) {
+	t, ok := trashTables[entity]
+	if !ok {
+		return nil, ErrUnknownTrashEntity
+	}
+	q := `SELECT id, ` + t.label + `, ` + t.owner + `, deleted_at, deleted_by FROM ` + t.table + `
+	      WHERE deleted_at IS NOT NULL AND ($1::timestamptz IS NULL OR deleted_at < $1)
+	      ORDER BY deleted_at DESC, id`
+	rows, err := r.db.QueryContext(ctx, q, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*pgmodel.TrashItem{}
+	for rows.Next() {
+		item := &pgmodel.TrashItem{Entity: entity}
+		if err := rows.Scan(&item.ID, &item.Label, &item.OwnerID, &item.DeletedAt, &item.DeletedBy); err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, rows.Err()
+}
+
+func (r *trashRepository) Get(ctx context.Context, entity string, id string) (*pgmodel.TrashItem, error) {
+	t, ok := trashTables[entity]
+	if !ok {
+		return nil, ErrUnknownTrashEntity
+	}
+	q := `SELECT id, ` + t.label + `, ` + t.owner + `, deleted_at, deleted_by FROM ` + t.table + `
+	      WHERE id=$1 AND deleted_at IS NOT NULL`
+	item := &pgmodel.TrashItem{Entity: entity}
+	if err := r.db.QueryRowContext(ctx, q, id).Scan(&item.ID, &item.Label, &item.OwnerID, &item.DeletedAt, &item.DeletedBy); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (r *trashRepository) Purge(ctx context.Context, entity string, id string) error {
+	t, ok := trashTables[entity]
+	if !ok {
+		return ErrUnknownTrashEntity
+	}
+	res, err := r.db.ExecContext(ctx, `DELETE FROM `+t.table+` WHERE id=$1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *trashRepository) StudentIDsOfUser(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id FROM students WHERE user_id=$1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
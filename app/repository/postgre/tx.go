@@ -0,0 +1,44 @@
+package postgre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrStaleVersion is returned by versioned updates when the row changed since it was read.
+var ErrStaleVersion = errors.New("row was changed by another request")
+
+// DBTX is satisfied by *sql.DB and *sql.Tx, so a repository can run inside a transaction.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Transactor runs a function inside one Postgres transaction; use the WithTx
+// variants of repositories inside fn.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(tx *sql.Tx) error) error
+}
+
+type transactor struct {
+	db *sql.DB
+}
+
+func NewTransactor(db *sql.DB) Transactor {
+	return &transactor{db: db}
+}
+
+// WithinTx commits when fn returns nil and rolls back otherwise.
+func (t *transactor) WithinTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
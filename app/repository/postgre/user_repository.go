@@ -6,8 +6,14 @@ import (
 	"time"
 
 	pgmodel "clean-arch/app/model/postgre"
+
+	"github.com/lib/pq"
 )
 
+// userRoleIDsExpr lists the roles of a users row, primary role first
+const userRoleIDsExpr = `ARRAY(SELECT ur.role_id::text FROM user_roles ur WHERE ur.user_id = users.id
+		       ORDER BY ur.role_id = users.role_id DESC, ur.created_at, ur.role_id)`
+
 // ----------------------
 // INTERFACE
 // ----------------------
@@ -16,9 +22,13 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id string) (*pgmodel.User, error)
 	GetByUsername(ctx context.Context, username string) (*pgmodel.User, error)
 	Update(ctx context.Context, u *pgmodel.User) error
-	Delete(ctx context.Context, id string) error
 	ListAll(ctx context.Context) ([]*pgmodel.User, error)
 	UpdateRole(ctx context.Context, userID string, roleID string) error
+	// SetRoles replaces the roles of a user; the first becomes the primary role (role_id).
+	SetRoles(ctx context.Context, userID string, roleIDs []string) error
+	GetAuthState(ctx context.Context, id string) (*pgmodel.UserAuthState, error)
+	// SetTokensValidAfter sets the force-logout cutoff (sql.ErrNoRows for an unknown user).
+	SetTokensValidAfter(ctx context.Context, id string, t time.Time) error
 }
 
 // ----------------------
@@ -36,6 +46,7 @@ func (r *userRepository) Create(ctx context.Context, u *pgmodel.User) error {
 	now := time.Now()
 	u.CreatedAt = now
 	u.UpdatedAt = now
+	u.Version = 1
 
 	query := `
 		INSERT INTO users (
@@ -53,8 +64,8 @@ func (r *userRepository) Create(ctx context.Context, u *pgmodel.User) error {
 func (r *userRepository) GetByID(ctx context.Context, id string) (*pgmodel.User, error) {
 	query := `
 		SELECT id, username, email, password_hash, full_name,
-		       role_id, is_active, created_at, updated_at
-		FROM users WHERE id=$1
+		       role_id, ` + userRoleIDsExpr + `, is_active, version, created_at, updated_at
+		FROM users WHERE id=$1 AND deleted_at IS NULL
 	`
 
 	row := r.db.QueryRowContext(ctx, query, id)
@@ -62,7 +73,7 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*pgmodel.User,
 
 	err := row.Scan(
 		&u.ID, &u.Username, &u.Email, &u.PasswordHash, &u.FullName,
-		&u.RoleID, &u.IsActive, &u.CreatedAt, &u.UpdatedAt,
+		&u.RoleID, pq.Array(&u.RoleIDs), &u.IsActive, &u.Version, &u.CreatedAt, &u.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -74,8 +85,8 @@ func (r *userRepository) GetByID(ctx context.Context, id string) (*pgmodel.User,
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*pgmodel.User, error) {
 	query := `
 		SELECT id, username, email, password_hash, full_name,
-		       role_id, is_active, created_at, updated_at
-		FROM users WHERE username=$1
+		       role_id, ` + userRoleIDsExpr + `, is_active, version, created_at, updated_at
+		FROM users WHERE username=$1 AND deleted_at IS NULL
 	`
 
 	row := r.db.QueryRowContext(ctx, query, username)
@@ -83,7 +94,7 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*p
 
 	err := row.Scan(
 		&u.ID, &u.Username, &u.Email, &u.PasswordHash, &u.FullName,
-		&u.RoleID, &u.IsActive, &u.CreatedAt, &u.UpdatedAt,
+		&u.RoleID, pq.Array(&u.RoleIDs), &u.IsActive, &u.Version, &u.CreatedAt, &u.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -92,33 +103,36 @@ func (r *userRepository) GetByUsername(ctx context.Context, username string) (*p
 	return &u, nil
 }
 
+// Update writes the row only if it still has u.Version (ErrStaleVersion otherwise) and bumps it.
 func (r *userRepository) Update(ctx context.Context, u *pgmodel.User) error {
-	u.UpdatedAt = time.Now()
+	now := time.Now()
 
 	query := `
 		UPDATE users
 		SET username=$1, email=$2, password_hash=$3, full_name=$4,
-		    role_id=$5, is_active=$6, updated_at=$7
-		WHERE id=$8
+		    role_id=$5, is_active=$6, updated_at=$7, version=version+1
+		WHERE id=$8 AND version=$9
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	res, err := r.db.ExecContext(ctx, query,
 		u.Username, u.Email, u.PasswordHash, u.FullName,
-		u.RoleID, u.IsActive, u.UpdatedAt, u.ID,
+		u.RoleID, u.IsActive, now, u.ID, u.Version,
 	)
-	return err
-}
-
-func (r *userRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM users WHERE id=$1`
-	_, err := r.db.ExecContext(ctx, query, id)
-	return err
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrStaleVersion
+	}
+	u.UpdatedAt = now
+	u.Version++
+	return nil
 }
 
 func (r *userRepository) ListAll(ctx context.Context) ([]*pgmodel.User, error) {
 	query := `
 		SELECT id, username, email, password_hash, full_name,
-		       role_id, is_active, created_at, updated_at
-		FROM users ORDER BY created_at DESC
+		       role_id, ` + userRoleIDsExpr + `, is_active, version, created_at, updated_at
+		FROM users WHERE deleted_at IS NULL ORDER BY created_at DESC
 	`
 
 	rows, err := r.db.QueryContext(ctx, query)
@@ -132,7 +146,7 @@ func (r *userRepository) ListAll(ctx context.Context) ([]*pgmodel.User, error) {
 		var u pgmodel.User
 		err := rows.Scan(
 			&u.ID, &u.Username, &u.Email, &u.PasswordHash, &u.FullName,
-			&u.RoleID, &u.IsActive, &u.CreatedAt, &u.UpdatedAt,
+			&u.RoleID, pq.Array(&u.RoleIDs), &u.IsActive, &u.Version, &u.CreatedAt, &u.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -144,7 +158,59 @@ func (r *userRepository) ListAll(ctx context.Context) ([]*pgmodel.User, error) {
 
 func (r *userRepository) UpdateRole(ctx context.Context, userID string, roleID string) error {
 	now := time.Now()
-	query := `UPDATE users SET role_id=$1, updated_at=$2 WHERE id=$3`
+	query := `UPDATE users SET role_id=$1, updated_at=$2, version=version+1 WHERE id=$3`
 	_, err := r.db.ExecContext(ctx, query, roleID, now, userID)
 	return err
 }
+
+func (r *userRepository) SetRoles(ctx context.Context, userID string, roleIDs []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the users trigger moves the primary role in user_roles; the rest is replaced here
+	res, err := tx.ExecContext(ctx,
+		`UPDATE users SET role_id=$1, updated_at=$2, version=version+1 WHERE id=$3 AND deleted_at IS NULL`,
+		roleIDs[0], time.Now(), userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM user_roles WHERE user_id=$1 AND NOT (role_id::text = ANY($2))`,
+		userID, pq.Array(roleIDs)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO user_roles (user_id, role_id) SELECT $1, unnest($2::uuid[]) ON CONFLICT DO NOTHING`,
+		userID, pq.Array(roleIDs)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetAuthState returns only the columns needed to validate an access token; a user in the trash is inactive
+func (r *userRepository) GetAuthState(ctx context.Context, id string) (*pgmodel.UserAuthState, error) {
+	query := `SELECT id, is_active AND deleted_at IS NULL, tokens_valid_after FROM users WHERE id=$1`
+	var st pgmodel.UserAuthState
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&st.UserID, &st.IsActive, &st.TokensValidAfter); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (r *userRepository) SetTokensValidAfter(ctx context.Context, id string, t time.Time) error {
+	query := `UPDATE users SET tokens_valid_after=$1, updated_at=$2 WHERE id=$3`
+	res, err := r.db.ExecContext(ctx, query, t, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
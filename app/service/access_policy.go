@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	pgModel "clean-arch/app/model/postgre"
+	pgRepo "clean-arch/app/repository/postgre"
+)
+
+// Role names as seeded by database/migrations/0002_seed_roles_permissions.up.sql
+const (
+	RoleAdmin    = "Admin"
+	RoleStudent  = "Mahasiswa"
+	RoleLecturer = "Dosen Wali"
+)
+
+// Actor is the authenticated caller, taken from the JWT claims.
+type Actor struct {
+	UserID  string
+	RoleID  string   // primary role
+	RoleIDs []string // every role of the user, primary first; empty in tokens with one role
+}
+
+// Roles returns every role of the actor.
+func (a Actor) Roles() []string {
+	if len(a.RoleIDs) > 0 {
+		return a.RoleIDs
+	}
+	if a.RoleID != "" {
+		return []string{a.RoleID}
+	}
+	return nil
+}
+
+// Scope lists which students' achievements an actor may see.
+type Scope struct {
+	All        bool     // admin
+	StudentIDs []string // students.id values visible to the actor
+	StudentID  string   // set when the actor is a student
+	LecturerID string   // set when the actor is a lecturer
+}
+
+// Allows reports whether the scope covers the given student.
+func (sc *Scope) Allows(studentID string) bool {
+	if sc.All {
+		return true
+	}
+	for _, id := range sc.StudentIDs {
+		if id == studentID {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessPolicy decides ownership-aware visibility of achievements:
+// students see their own (and team achievements they belong to), lecturers
+// their advisees', admins everything.
+type AccessPolicy struct {
+	roleRepo       pgRepo.RoleRepository
+	studentRepo    pgRepo.StudentRepository
+	lecturerRepo   pgRepo.LecturerRepository
+	delegationRepo pgRepo.DelegationRepository
+	memberRepo     pgRepo.AchievementMemberRepository
+}
+
+func NewAccessPolicy(
+	roleRepo pgRepo.RoleRepository,
+	studentRepo pgRepo.StudentRepository,
+	lecturerRepo pgRepo.LecturerRepository,
+	delegationRepo pgRepo.DelegationRepository,
+	memberRepo pgRepo.AchievementMemberRepository,
+) *AccessPolicy {
+	return &AccessPolicy{
+		roleRepo:       roleRepo,
+		studentRepo:    studentRepo,
+		lecturerRepo:   lecturerRepo,
+		delegationRepo: delegationRepo,
+		memberRepo:     memberRepo,
+	}
+}
+
+// IsAdmin reports whether one of the actor's roles is the admin role.
+func (p *AccessPolicy) IsAdmin(ctx context.Context, actor Actor) (bool, error) {
+	for _, roleID := range actor.Roles() {
+		role, err := p.roleRepo.GetByID(ctx, roleID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return false, err
+		}
+		if role.Name == RoleAdmin {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Resolve computes the visibility scope of the actor.
+func (p *AccessPolicy) Resolve(ctx context.Context, actor Actor) (*Scope, error) {
+	admin, err := p.IsAdmin(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	if admin {
+		return &Scope{All: true}, nil
+	}
+
+	sc := &Scope{StudentIDs: []string{}}
+
+	// lecturer: advisees, plus advisees of advisors who currently delegate to them
+	lecturer, err := p.lecturerRepo.GetByUserID(ctx, actor.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if lecturer != nil {
+		sc.LecturerID = lecturer.ID
+		advisorIDs := []string{lecturer.ID}
+		if p.delegationRepo != nil {
+			delegations, err := p.delegationRepo.ListActiveForDelegate(ctx, lecturer.ID, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			for _, d := range delegations {
+				advisorIDs = append(advisorIDs, d.AdvisorID)
+			}
+		}
+		for _, advisorID := range advisorIDs {
+			advisees, err := p.lecturerRepo.GetAdvisees(ctx, advisorID)
+			if err != nil {
+				return nil, err
+			}
+			for _, st := range advisees {
+				sc.StudentIDs = append(sc.StudentIDs, st.ID)
+			}
+		}
+	}
+
+	// student: own achievements
+	student, err := p.studentRepo.GetByUserID(ctx, actor.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if student != nil {
+		sc.StudentID = student.ID
+		sc.StudentIDs = append(sc.StudentIDs, student.ID)
+	}
+	return sc, nil
+}
+
+// CanViewStudent reports whether the actor may see achievements of the student.
+func (p *AccessPolicy) CanViewStudent(ctx context.Context, actor Actor, studentID string) (bool, error) {
+	sc, err := p.Resolve(ctx, actor)
+	if err != nil {
+		return false, err
+	}
+	return sc.Allows(studentID), nil
+}
+
+// CanViewAchievement reports whether the actor may see the achievement: its owner is in
+// scope, or one of its team members is (invitees included, so they can decide).
+func (p *AccessPolicy) CanViewAchievement(ctx context.Context, actor Actor, ref *pgModel.AchievementReference) (bool, error) {
+	sc, err := p.Resolve(ctx, actor)
+	if err != nil {
+		return false, err
+	}
+	if sc.Allows(ref.StudentID) {
+		return true, nil
+	}
+	if p.memberRepo == nil {
+		return false, nil
+	}
+	members, err := p.memberRepo.ListByReference(ctx, ref.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range members {
+		if m.Status == pgModel.MemberDeclined {
+			continue
+		}
+		// an invitee sees it through their own profile, advisors only once the member accepted
+		if m.StudentID == sc.StudentID || (m.Status == pgModel.MemberAccepted && sc.Allows(m.StudentID)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,394 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	mongoModel "clean-arch/app/model/mongo"
+	pgModel "clean-arch/app/model/postgre"
+	pgRepo "clean-arch/app/repository/postgre"
+	"clean-arch/app/storage"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrNotEditable          = &CustomError{"not_editable", "achievement cannot be changed in its current status", 409}
+	ErrAttachmentNotFound   = &CustomError{"attachment_not_found", "attachment not found", 404}
+	ErrAttachmentTooLarge   = &CustomError{"attachment_too_large", "file exceeds the maximum attachment size", 413}
+	ErrAttachmentType       = &CustomError{"attachment_type_not_allowed", "file type is not allowed", 415}
+	ErrAttachmentDuplicate  = &CustomError{"attachment_duplicate", "the same file is already attached", 409}
+	ErrAttachmentLimit      = &CustomError{"attachment_limit", "maximum number of attachments reached", 409}
+	ErrInvalidDownloadToken = &CustomError{"invalid_download_link", "download link is invalid or expired", 403}
+)
+
+// AttachmentLimits is read from ATTACHMENT_MAX_SIZE (bytes), ATTACHMENT_MAX_COUNT,
+// ATTACHMENT_ALLOWED_TYPES (comma separated MIME types) and ATTACHMENT_URL_TTL.
+type AttachmentLimits struct {
+	MaxSize      int64
+	MaxCount     int
+	AllowedTypes []string
+	URLTTL       time.Duration
+}
+
+func attachmentLimitsFromEnv() AttachmentLimits {
+	l := AttachmentLimits{
+		MaxSize:      5 << 20,
+		MaxCount:     10,
+		AllowedTypes: []string{"application/pdf", "image/jpeg", "image/png"},
+		URLTTL:       durationFromEnv("ATTACHMENT_URL_TTL", 5*time.Minute),
+	}
+	if n, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_SIZE"), 10, 64); err == nil && n > 0 {
+		l.MaxSize = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("ATTACHMENT_MAX_COUNT")); err == nil && n > 0 {
+		l.MaxCount = n
+	}
+	if v := os.Getenv("ATTACHMENT_ALLOWED_TYPES"); v != "" {
+		l.AllowedTypes = nil
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				l.AllowedTypes = append(l.AllowedTypes, t)
+			}
+		}
+	}
+	return l
+}
+
+func (l AttachmentLimits) allows(mimeType string) bool {
+	for _, t := range l.AllowedTypes {
+		if t == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// attachmentExt picks the file extension used in the storage key
+func attachmentExt(mimeType string) string {
+	switch mimeType {
+	case "application/pdf":
+		return ".pdf"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	}
+	if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+		return exts[len(exts)-1]
+	}
+	return ""
+}
+
+// AttachmentUpload is one uploaded file as received by the handler.
+type AttachmentUpload struct {
+	FileName string
+	Size     int64 // declared size, checked again while reading
+	Content  io.Reader
+}
+
+// SignedURL is a short-lived download link that works without a bearer token.
+type SignedURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// countingWriter counts bytes written through it
+type countingWriter struct{ n int64 }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// editableDocument loads a reference the actor owns in an editable state, plus its document
+func (s *AchievementService) editableDocument(ctx context.Context, actor Actor, refID string) (*pgModel.AchievementReference, *mongoModel.Achievement, error) {
+	ref, err := s.loadVisibleRef(ctx, actor, refID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.checkGuard(ctx, &transitionRun{ref: ref, actor: actor}, GuardOwner); err != nil {
+		return nil, nil, err
+	}
+	if st := s.workflow.State(ref.Status); st == nil || !st.Editable {
+		return nil, nil, ErrNotEditable
+	}
+	doc, err := s.documentOf(ctx, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ref, doc, nil
+}
+
+func (s *AchievementService) documentOf(ctx context.Context, ref *pgModel.AchievementReference) (*mongoModel.Achievement, error) {
+	oid, err := primitive.ObjectIDFromHex(ref.MongoAchievementID)
+	if err != nil {
+		return nil, errors.New("invalid mongo object id")
+	}
+	doc, err := s.achievementMongo.GetByID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, ErrNotFound
+	}
+	return doc, nil
+}
+
+func findAttachment(doc *mongoModel.Achievement, attachmentID string) *mongoModel.Attachment {
+	for i := range doc.Attachments {
+		if doc.Attachments[i].ID == attachmentID {
+			return &doc.Attachments[i]
+		}
+	}
+	return nil
+}
+
+// AddAttachment stores an uploaded file for the owner's editable achievement. The type is
+// sniffed from the content; the file is hashed while it is written to storage. The limit and
+// duplicate checks run against achievement_attachments in the transaction that queues the
+// document update, so concurrent uploads cannot get past them.
+func (s *AchievementService) AddAttachment(ctx context.Context, actor Actor, refID string, up AttachmentUpload) (*mongoModel.Attachment, error) {
+	ref, doc, err := s.editableDocument(ctx, actor, refID)
+	if err != nil {
+		return nil, err
+	}
+	if up.Size > s.attachments.MaxSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(up.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+	mimeType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !s.attachments.allows(mimeType) {
+		return nil, ErrAttachmentType
+	}
+
+	att := mongoModel.Attachment{
+		ID:         uuid.New().String(),
+		FileName:   filepath.Base(filepath.Clean("/" + up.FileName)),
+		MimeType:   mimeType,
+		UploadedBy: actor.UserID,
+		UploadedAt: time.Now(),
+	}
+	att.URL = fmt.Sprintf("/api/v1/achievements/%s/attachments/%s", ref.ID, att.ID)
+	att.StorageKey = ref.ID + "/" + att.ID + attachmentExt(mimeType)
+
+	// read at most MaxSize+1 bytes so an oversized body is detected without buffering it
+	hash := sha256.New()
+	size := &countingWriter{}
+	body := io.LimitReader(io.MultiReader(bytes.NewReader(head), up.Content), s.attachments.MaxSize+1)
+	if err := s.files.Put(ctx, att.StorageKey, io.TeeReader(body, io.MultiWriter(hash, size))); err != nil {
+		return nil, err
+	}
+	discard := func() { _ = s.files.Delete(context.Background(), att.StorageKey) }
+	if size.n > s.attachments.MaxSize {
+		discard()
+		return nil, ErrAttachmentTooLarge
+	}
+	att.Size = size.n
+	att.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	msg, err := newOutboxMessage(ref.ID, pgModel.OutboxAddAttachment, map[string]interface{}{
+		"mongoId": doc.ID, "attachment": att,
+	})
+	if err != nil {
+		discard()
+		return nil, err
+	}
+	ref.UpdatedAt = time.Now()
+	if err := s.writeWithOutbox(ctx, func(tx *sql.Tx) error {
+		// the versioned update locks the reference for the checks below
+		if err := s.achievementRefPG.WithTx(tx).Update(ctx, ref); err != nil {
+			return err
+		}
+		index := s.attachmentRepo.WithTx(tx)
+		if err := index.Seed(ctx, ref.ID, attachmentIndex(doc)); err != nil {
+			return err
+		}
+		n, err := index.Count(ctx, ref.ID)
+		if err != nil {
+			return err
+		}
+		if n >= s.attachments.MaxCount {
+			return ErrAttachmentLimit
+		}
+		err = index.Create(ctx, &pgModel.AchievementAttachment{ID: att.ID, ReferenceID: ref.ID, SHA256: att.SHA256, CreatedAt: att.UploadedAt})
+		if errors.Is(err, pgRepo.ErrDuplicateAttachment) {
+			return ErrAttachmentDuplicate
+		}
+		return err
+	}, msg); err != nil {
+		discard()
+		return nil, err
+	}
+
+	s.writeActivityLog(ctx, &pgModel.ActivityLog{
+		ID:         uuid.New().String(),
+		EntityType: "achievement_reference",
+		EntityID:   ref.ID,
+		EventType:  "attachment_added",
+		ActorID:    &actor.UserID,
+		Current: map[string]interface{}{
+			"attachment_id": att.ID, "file_name": att.FileName, "mime_type": att.MimeType,
+			"size": att.Size, "sha256": att.SHA256,
+		},
+		CreatedAt: time.Now(),
+	})
+	return &att, nil
+}
+
+// RemoveAttachment detaches a file; Mongo and storage are cleaned up through the outbox.
+func (s *AchievementService) RemoveAttachment(ctx context.Context, actor Actor, refID string, attachmentID string) error {
+	ref, doc, err := s.editableDocument(ctx, actor, refID)
+	if err != nil {
+		return err
+	}
+	att := findAttachment(doc, attachmentID)
+	if att == nil {
+		return ErrAttachmentNotFound
+	}
+
+	msg, err := newOutboxMessage(ref.ID, pgModel.OutboxRemoveAttachment, map[string]interface{}{
+		"mongoId": doc.ID, "attachmentId": att.ID, "storageKey": att.StorageKey,
+	})
+	if err != nil {
+		return err
+	}
+	ref.UpdatedAt = time.Now()
+	if err := s.writeWithOutbox(ctx, func(tx *sql.Tx) error {
+		if err := s.achievementRefPG.WithTx(tx).Update(ctx, ref); err != nil {
+			return err
+		}
+		index := s.attachmentRepo.WithTx(tx)
+		if err := index.Seed(ctx, ref.ID, attachmentIndex(doc)); err != nil {
+			return err
+		}
+		return index.Delete(ctx, ref.ID, att.ID)
+	}, msg); err != nil {
+		return err
+	}
+
+	s.writeActivityLog(ctx, &pgModel.ActivityLog{
+		ID:         uuid.New().String(),
+		EntityType: "achievement_reference",
+		EntityID:   ref.ID,
+		EventType:  "attachment_removed",
+		ActorID:    &actor.UserID,
+		Previous: map[string]interface{}{
+			"attachment_id": att.ID, "file_name": att.FileName, "sha256": att.SHA256,
+		},
+		CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+// OpenAttachment returns the attachment and its content for anyone who may see the achievement.
+// The caller must close the reader.
+func (s *AchievementService) OpenAttachment(ctx context.Context, actor Actor, refID string, attachmentID string) (*mongoModel.Attachment, io.ReadCloser, error) {
+	ref, err := s.loadVisibleRef(ctx, actor, refID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.openAttachment(ctx, ref, attachmentID)
+}
+
+func (s *AchievementService) openAttachment(ctx context.Context, ref *pgModel.AchievementReference, attachmentID string) (*mongoModel.Attachment, io.ReadCloser, error) {
+	doc, err := s.documentOf(ctx, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	att := findAttachment(doc, attachmentID)
+	if att == nil {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	rc, err := s.files.Open(ctx, att.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+	return att, rc, nil
+}
+
+// AttachmentURL issues a signed download link valid for ATTACHMENT_URL_TTL,
+// e.g. for <img src> or sharing with a browser that has no bearer token.
+func (s *AchievementService) AttachmentURL(ctx context.Context, actor Actor, refID string, attachmentID string) (*SignedURL, error) {
+	ref, err := s.loadVisibleRef(ctx, actor, refID)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := s.documentOf(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if findAttachment(doc, attachmentID) == nil {
+		return nil, ErrAttachmentNotFound
+	}
+	exp := time.Now().Add(s.attachments.URLTTL).Truncate(time.Second)
+	q := url.Values{}
+	q.Set("ref", ref.ID)
+	q.Set("att", attachmentID)
+	q.Set("exp", strconv.FormatInt(exp.Unix(), 10))
+	q.Set("sig", s.signAttachment(ref.ID, attachmentID, exp.Unix()))
+	return &SignedURL{URL: "/api/v1/attachments/download?" + q.Encode(), ExpiresAt: exp}, nil
+}
+
+// OpenSignedAttachment serves a link produced by AttachmentURL, as long as the achievement
+// has not been deleted since the link was issued.
+func (s *AchievementService) OpenSignedAttachment(ctx context.Context, refID, attachmentID, exp, sig string) (*mongoModel.Attachment, io.ReadCloser, error) {
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > expUnix {
+		return nil, nil, ErrInvalidDownloadToken
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.signAttachment(refID, attachmentID, expUnix))) {
+		return nil, nil, ErrInvalidDownloadToken
+	}
+	ref, err := s.achievementRefPG.GetByID(ctx, refID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+	if ref.Status == "deleted" {
+		return nil, nil, ErrAttachmentNotFound
+	}
+	return s.openAttachment(ctx, ref, attachmentID)
+}
+
+// attachmentIndex lists the document's attachments for AchievementAttachmentRepository.Seed
+func attachmentIndex(doc *mongoModel.Achievement) []*pgModel.AchievementAttachment {
+	out := make([]*pgModel.AchievementAttachment, 0, len(doc.Attachments))
+	for _, a := range doc.Attachments {
+		out = append(out, &pgModel.AchievementAttachment{ID: a.ID, SHA256: a.SHA256, CreatedAt: a.UploadedAt})
+	}
+	return out
+}
+
+func (s *AchievementService) signAttachment(refID, attachmentID string, exp int64) string {
+	mac := hmac.New(sha256.New, s.urlSecret)
+	fmt.Fprintf(mac, "%s|%s|%d", refID, attachmentID, exp)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxBulkItems bounds one bulk request
+const maxBulkItems = 100
+
+// bulkItemTimeout bounds each item of a bulk request, so a slow item cannot starve the rest
+const bulkItemTimeout = 10 * time.Second
+
+var (
+	ErrBulkAction   = &CustomError{"invalid_bulk_action", "action must be verify or reject", 400}
+	ErrBulkEmpty    = &CustomError{"empty_bulk", "ids must contain at least one achievement", 400}
+	ErrBulkTooLarge = &CustomError{"bulk_too_large", "too many achievements in one request (max 100)", 400}
+)
+
+// BulkRequest is the body of POST /achievements/bulk.
+type BulkRequest struct {
+	Action string   `json:"action"` // verify | reject
+	IDs    []string `json:"ids"`    // achievement reference ids
+	Note   string   `json:"note"`   // required for reject
+}
+
+// BulkItemResult is the outcome for one achievement of a bulk request.
+type BulkItemResult struct {
+	ID      string `json:"id"`
+	OK      bool   `json:"ok"`
+	Status  string `json:"status,omitempty"` // new status when OK
+	Code    string `json:"code,omitempty"`   // error code when not OK
+	Message string `json:"message,omitempty"`
+}
+
+// BulkResult reports a bulk request; every item's activity log carries BatchID.
+type BulkResult struct {
+	BatchID   string            `json:"batch_id"`
+	Action    string            `json:"action"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []*BulkItemResult `json:"results"`
+}
+
+// Bulk verifies or rejects several achievements. Each item goes through the same workflow
+// transition as Verify/Reject, under its own timeout, and succeeds or fails on its own.
+// Infrastructure errors are logged and reported on the item with a generic message so one
+// bad row does not hide the others and internals do not leak to the caller.
+func (s *AchievementService) Bulk(ctx context.Context, actor Actor, req BulkRequest) (*BulkResult, error) {
+	if req.Action != "verify" && req.Action != "reject" {
+		return nil, ErrBulkAction
+	}
+	if req.Action == "reject" && req.Note == "" {
+		return nil, ErrNoteRequired
+	}
+	ids := make([]string, 0, len(req.IDs))
+	seen := map[string]bool{}
+	for _, id := range req.IDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, ErrBulkEmpty
+	}
+	if len(ids) > maxBulkItems {
+		return nil, ErrBulkTooLarge
+	}
+
+	res := &BulkResult{BatchID: uuid.New().String(), Action: req.Action, Results: make([]*BulkItemResult, 0, len(ids))}
+	for _, id := range ids {
+		item := &BulkItemResult{ID: id}
+		itemCtx, cancel := context.WithTimeout(ctx, bulkItemTimeout)
+		ref, err := s.Transition(itemCtx, id, actor, req.Action, TransitionInput{Note: req.Note, BatchID: res.BatchID})
+		cancel()
+		if err != nil {
+			item.Code, item.Message = bulkItemError(res.BatchID, id, err)
+			res.Failed++
+		} else {
+			item.OK, item.Status = true, ref.Status
+			res.Succeeded++
+		}
+		res.Results = append(res.Results, item)
+	}
+	return res, nil
+}
+
+// bulkItemError maps a transition error to the code and message reported on a bulk item.
+// Domain errors keep their message; anything else is logged and reported generically.
+func bulkItemError(batchID, id string, err error) (string, string) {
+	var ce *CustomError
+	if errors.As(err, &ce) {
+		return ce.Code, ce.Message
+	}
+	var de *DuplicateError
+	if errors.As(err, &de) {
+		return "duplicate", de.Error()
+	}
+	var ve *DetailsValidationError
+	if errors.As(err, &ve) {
+		return "validation_failed", ve.Error()
+	}
+	log.Printf("bulk %s: achievement %s: %v", batchID, id, err)
+	return "error", "internal error"
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	pgModel "clean-arch/app/model/postgre"
+
+	"github.com/google/uuid"
+)
+
+// Comment author roles (achievement_comments.author_role)
+const (
+	CommentRoleOwner    = "owner"
+	CommentRoleAdvisor  = "advisor"
+	CommentRoleDelegate = "delegate"
+	CommentRoleAdmin    = "admin"
+)
+
+const maxCommentLength = 5000
+
+var (
+	ErrCommentBody      = &CustomError{"invalid_comment", "comment body is required (max 5000 characters)", 400}
+	ErrCommentField     = &CustomError{"invalid_field_path", "field_path must name a document field, e.g. details.rank", 400}
+	ErrCommentParent    = &CustomError{"invalid_parent", "the comment to reply to does not belong to this achievement", 400}
+	ErrCommentsClosed   = &CustomError{"comments_closed", "deleted achievements cannot be commented on", 409}
+	ErrCommentForbidden = &CustomError{"comment_forbidden", "only the owner, the advisor and admins can take part in the review discussion", 403}
+)
+
+// commentFieldPath matches anchors such as title or details.rank
+var commentFieldPath = regexp.MustCompile(`^(title|type|category|level|details|tags|attachments)(\.[A-Za-z0-9_]+)*$`)
+
+// CommentRequest is the body of POST /achievements/:id/comments.
+type CommentRequest struct {
+	Body      string `json:"body"`
+	FieldPath string `json:"field_path"` // optional anchor
+	ParentID  string `json:"parent_id"`  // optional, reply to this thread
+}
+
+// CommentThreads is the review discussion of an achievement.
+type CommentThreads struct {
+	ReferenceID string                        `json:"reference_id"`
+	Round       int                           `json:"round"`  // current review round
+	Unread      int                           `json:"unread"` // comments by others not read yet
+	Threads     []*pgModel.AchievementComment `json:"threads"`
+}
+
+// ListComments returns the discussion as threads (oldest first) across all review rounds.
+// Listing does not mark comments read; see MarkCommentsRead.
+func (s *AchievementService) ListComments(ctx context.Context, actor Actor, refID string) (*CommentThreads, error) {
+	ref, _, err := s.commentAccess(ctx, actor, refID)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.commentRepo.ListByReference(ctx, ref.ID)
+	if err != nil {
+		return nil, err
+	}
+	lastRead, err := s.commentRepo.LastRead(ctx, ref.ID, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &CommentThreads{ReferenceID: ref.ID, Round: ref.Revision, Threads: []*pgModel.AchievementComment{}}
+	roots := map[string]*pgModel.AchievementComment{}
+	for _, c := range comments {
+		own := c.AuthorID != nil && *c.AuthorID == actor.UserID
+		c.Unread = !own && (lastRead == nil || c.CreatedAt.After(*lastRead))
+		if c.Unread {
+			out.Unread++
+		}
+		if c.ParentID == nil {
+			roots[c.ID] = c
+			out.Threads = append(out.Threads, c)
+			continue
+		}
+		if root, ok := roots[*c.ParentID]; ok {
+			root.Replies = append(root.Replies, c)
+		}
+	}
+	return out, nil
+}
+
+// AddComment posts a comment, or a reply when req.ParentID is set. The comment is tied to the
+// current review round and stays visible after resubmission.
+func (s *AchievementService) AddComment(ctx context.Context, actor Actor, refID string, req CommentRequest) (*pgModel.AchievementComment, error) {
+	ref, role, err := s.commentAccess(ctx, actor, refID)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Status == "deleted" {
+		return nil, ErrCommentsClosed
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" || utf8.RuneCountInString(body) > maxCommentLength {
+		return nil, ErrCommentBody
+	}
+
+	c := &pgModel.AchievementComment{
+		ID:          uuid.New().String(),
+		ReferenceID: ref.ID,
+		Round:       ref.Revision,
+		AuthorID:    &actor.UserID,
+		AuthorRole:  role,
+		Body:        body,
+		CreatedAt:   time.Now(),
+	}
+	if path := strings.TrimSpace(req.FieldPath); path != "" {
+		if len(path) > 200 || !commentFieldPath.MatchString(path) {
+			return nil, ErrCommentField
+		}
+		c.FieldPath = &path
+	}
+	if req.ParentID != "" {
+		parent, err := s.commentRepo.GetByID(ctx, req.ParentID)
+		if err != nil || parent.ReferenceID != ref.ID {
+			return nil, ErrCommentParent
+		}
+		// threads are one level deep: a reply to a reply joins the same thread
+		rootID := parent.ID
+		if parent.ParentID != nil {
+			rootID = *parent.ParentID
+		}
+		c.ParentID = &rootID
+	}
+	if err := s.commentRepo.Create(ctx, c); err != nil {
+		return nil, err
+	}
+	// the author has read everything up to their own comment
+	_ = s.commentRepo.MarkRead(ctx, ref.ID, actor.UserID, c.CreatedAt)
+
+	metadata := map[string]interface{}{"comment_id": c.ID, "round": c.Round}
+	if c.ParentID != nil {
+		metadata["parent_id"] = *c.ParentID
+	}
+	if c.FieldPath != nil {
+		metadata["field_path"] = *c.FieldPath
+	}
+	s.writeActivityLog(ctx, &pgModel.ActivityLog{
+		ID:         uuid.New().String(),
+		EntityType: "achievement_reference",
+		EntityID:   ref.ID,
+		EventType:  "comment_added",
+		ActorID:    &actor.UserID,
+		ActorRole:  &role,
+		Metadata:   metadata,
+		CreatedAt:  time.Now(),
+	})
+	return c, nil
+}
+
+// MarkCommentsRead marks the achievement's discussion as read by the actor up to now.
+func (s *AchievementService) MarkCommentsRead(ctx context.Context, actor Actor, refID string) error {
+	ref, _, err := s.commentAccess(ctx, actor, refID)
+	if err != nil {
+		return err
+	}
+	return s.commentRepo.MarkRead(ctx, ref.ID, actor.UserID, time.Now())
+}
+
+// UnreadComments counts unread comments per achievement over the actor's own achievements
+// (students), their advisees' (lecturers) or all achievements (admins).
+func (s *AchievementService) UnreadComments(ctx context.Context, actor Actor) (map[string]int, error) {
+	scope, err := s.policy.Resolve(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.All && len(scope.StudentIDs) == 0 {
+		return map[string]int{}, nil
+	}
+	var studentIDs []string
+	if !scope.All {
+		studentIDs = scope.StudentIDs
+	}
+	return s.commentRepo.UnreadCounts(ctx, actor.UserID, studentIDs)
+}
+
+// commentAccess loads the reference and decides the actor's role in its discussion:
+// the owning student, the advisor (or an active delegate) of that student, or an admin.
+func (s *AchievementService) commentAccess(ctx context.Context, actor Actor, refID string) (*pgModel.AchievementReference, string, error) {
+	ref, err := s.loadVisibleRef(ctx, actor, refID)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.checkGuard(ctx, &transitionRun{ref: ref, actor: actor}, GuardOwner); err == nil {
+		return ref, CommentRoleOwner, nil
+	} else if !isDenial(err) {
+		return nil, "", err
+	}
+	grant, err := s.authorizeReviewer(ctx, actor, ref)
+	if err != nil {
+		if isDenial(err) {
+			return nil, "", ErrCommentForbidden
+		}
+		return nil, "", err
+	}
+	switch grant.Authority {
+	case AuthorityAdvisor:
+		return ref, CommentRoleAdvisor, nil
+	case AuthorityDelegate:
+		return ref, CommentRoleDelegate, nil
+	}
+	return ref, CommentRoleAdmin, nil
+}
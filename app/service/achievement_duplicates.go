@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	mongoModel "clean-arch/app/model/mongo"
+	pgModel "clean-arch/app/model/postgre"
+)
+
+// titleSimilarity is the share of common title words above which two titles count as the same
+const titleSimilarity = 0.8
+
+// Reasons reported on a DuplicateMatch
+const (
+	DuplicateTitle      = "title"
+	DuplicateCategory   = "category"
+	DuplicateEventDate  = "event_date"
+	DuplicateAttachment = "attachment"
+)
+
+// DuplicateError stops a submission that looks like existing achievements until the
+// student gives an override reason.
+type DuplicateError struct {
+	Matches []pgModel.DuplicateMatch
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("this achievement looks like %d existing achievement(s); submit again with override_reason to proceed", len(e.Matches))
+}
+
+// FindDuplicates lists the achievements that look like this one (same student or team).
+func (s *AchievementService) FindDuplicates(ctx context.Context, actor Actor, refID string) ([]pgModel.DuplicateMatch, error) {
+	ref, err := s.loadVisibleRef(ctx, actor, refID)
+	if err != nil {
+		return nil, err
+	}
+	return s.findDuplicates(ctx, ref)
+}
+
+// checkNoDuplicates is the no_duplicates guard: likely duplicates need run.input.OverrideReason,
+// and are then recorded on the new review round for the reviewer.
+func (s *AchievementService) checkNoDuplicates(ctx context.Context, run *transitionRun) error {
+	matches, err := s.findDuplicates(ctx, run.ref)
+	if err != nil || len(matches) == 0 {
+		return err
+	}
+	reason := strings.TrimSpace(run.input.OverrideReason)
+	if reason == "" {
+		return &DuplicateError{Matches: matches}
+	}
+	ids := make([]string, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m.ReferenceID)
+	}
+	run.duplicates = matches
+	run.metadata["duplicates"] = ids
+	run.metadata["duplicate_override"] = reason
+	return nil
+}
+
+// findDuplicates compares the achievement with the other non-deleted achievements of its owner
+// and team members. A match needs the same attachment content, or a similar title plus the same
+// category or event date.
+func (s *AchievementService) findDuplicates(ctx context.Context, ref *pgModel.AchievementReference) ([]pgModel.DuplicateMatch, error) {
+	doc, err := s.documentOf(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	students := []string{ref.StudentID}
+	members, err := s.memberRepo.ListByReference(ctx, ref.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		if m.StudentID != ref.StudentID && m.Status != pgModel.MemberDeclined {
+			students = append(students, m.StudentID)
+		}
+	}
+
+	seen := map[string]bool{ref.ID: true}
+	candidates := []*pgModel.AchievementReference{}
+	for _, studentID := range students {
+		refs, err := s.achievementRefPG.ListByMember(ctx, studentID)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range refs {
+			if isRenewalPair(ref, r) {
+				seen[r.ID] = true
+			}
+			if !seen[r.ID] && r.Status != "deleted" {
+				seen[r.ID] = true
+				candidates = append(candidates, r)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return []pgModel.DuplicateMatch{}, nil
+	}
+	docs, err := s.loadDocuments(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []pgModel.DuplicateMatch{}
+	for _, c := range candidates {
+		other, ok := docs[c.MongoAchievementID]
+		if !ok || other.DeletedAt != nil {
+			continue
+		}
+		reasons := duplicateReasons(doc, other)
+		if !isLikelyDuplicate(reasons) {
+			continue
+		}
+		out = append(out, pgModel.DuplicateMatch{
+			ReferenceID: c.ID, StudentID: c.StudentID, Status: c.Status, Title: other.Title, Reasons: reasons,
+		})
+	}
+	return out, nil
+}
+
+// duplicateReasons lists the signals two documents share
+func duplicateReasons(a, b *mongoModel.Achievement) []string {
+	reasons := []string{}
+	if titleWords := normalizeTitle(a.Title); len(titleWords) > 0 && wordOverlap(titleWords, normalizeTitle(b.Title)) >= titleSimilarity {
+		reasons = append(reasons, DuplicateTitle)
+	}
+	if a.Category != "" && strings.EqualFold(strings.TrimSpace(a.Category), strings.TrimSpace(b.Category)) {
+		reasons = append(reasons, DuplicateCategory)
+	}
+	if da, ok := detailDate(a); ok {
+		if db, ok := detailDate(b); ok && da.Format("2006-01-02") == db.Format("2006-01-02") {
+			reasons = append(reasons, DuplicateEventDate)
+		}
+	}
+	hashes := map[string]bool{}
+	for _, att := range a.Attachments {
+		if att.SHA256 != "" {
+			hashes[att.SHA256] = true
+		}
+	}
+	for _, att := range b.Attachments {
+		if hashes[att.SHA256] {
+			reasons = append(reasons, DuplicateAttachment)
+			break
+		}
+	}
+	return reasons
+}
+
+// isRenewalPair reports whether one achievement renews the other; a renewal is meant to look alike
+func isRenewalPair(a, b *pgModel.AchievementReference) bool {
+	return (a.RenewalOf != nil && *a.RenewalOf == b.ID) || (b.RenewalOf != nil && *b.RenewalOf == a.ID)
+}
+
+func isLikelyDuplicate(reasons []string) bool {
+	has := map[string]bool{}
+	for _, r := range reasons {
+		has[r] = true
+	}
+	return has[DuplicateAttachment] || (has[DuplicateTitle] && (has[DuplicateCategory] || has[DuplicateEventDate]))
+}
+
+// normalizeTitle lowercases a title and splits it into words, dropping punctuation
+func normalizeTitle(title string) []string {
+	return strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// wordOverlap is the Jaccard similarity of two word lists
+func wordOverlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	setA := map[string]bool{}
+	for _, w := range a {
+		setA[w] = true
+	}
+	setB := map[string]bool{}
+	common := 0
+	for _, w := range b {
+		if !setB[w] {
+			setB[w] = true
+			if setA[w] {
+				common++
+			}
+		}
+	}
+	union := len(setA) + len(setB) - common
+	return float64(common) / float64(union)
+}
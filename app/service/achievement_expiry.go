@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	mongoModel "clean-arch/app/model/mongo"
+	pgModel "clean-arch/app/model/postgre"
+	mongoRepo "clean-arch/app/repository/mongo"
+	pgRepo "clean-arch/app/repository/postgre"
+
+	"github.com/google/uuid"
+)
+
+// certificationCategory is the achievement category with a validity period (details.expiry)
+const certificationCategory = "sertifikasi"
+
+// defaultExpiryNoticeDays is how long before expiry owners are warned (CERT_EXPIRY_NOTICE_DAYS)
+const defaultExpiryNoticeDays = 30
+
+var (
+	ErrNotRenewable    = &CustomError{"not_renewable", "only verified certifications with an expiry date can be renewed", 409}
+	ErrRenewalExists   = &CustomError{"renewal_exists", "this certification already has a renewal", 409}
+	ErrRenewalNotLater = &CustomError{"invalid_renewal", "the renewed certificate must expire after the current one", 400}
+)
+
+// certificateExpiry reads details.expiry (YYYY-MM-DD or RFC3339) of a certification. A
+// certificate is valid through its expiry day, so a plain date ends at midnight after it (UTC).
+func certificateExpiry(category string, details map[string]interface{}) *time.Time {
+	if !strings.EqualFold(strings.TrimSpace(category), certificationCategory) {
+		return nil
+	}
+	v, ok := details["expiry"].(string)
+	if !ok {
+		return nil
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		t = t.AddDate(0, 0, 1)
+		return &t
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t
+	}
+	return nil
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// EffectiveStatus is the status shown in listings and reports: a verified achievement past
+// its expiry date is pgModel.AchievementExpired.
+func EffectiveStatus(ref *pgModel.AchievementReference, now time.Time) string {
+	if ref.Status == "verified" && ref.ExpiresAt != nil && !ref.ExpiresAt.After(now) {
+		return pgModel.AchievementExpired
+	}
+	return ref.Status
+}
+
+func expiryNoticeDaysFromEnv() int {
+	if n, err := strconv.Atoi(os.Getenv("CERT_EXPIRY_NOTICE_DAYS")); err == nil && n > 0 {
+		return n
+	}
+	return defaultExpiryNoticeDays
+}
+
+// RenewCertificate starts a draft for the renewed certificate of a verified certification and
+// links it to the old one. Title, type, level and tags default to the old certificate; the
+// category stays the same and details (new number and dates) come from doc.
+func (s *AchievementService) RenewCertificate(ctx context.Context, actor Actor, refID string, doc *mongoModel.Achievement) (*pgModel.AchievementReference, error) {
+	old, err := s.loadVisibleRef(ctx, actor, refID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkGuard(ctx, &transitionRun{ref: old, actor: actor}, GuardOwner); err != nil {
+		return nil, err
+	}
+	if old.Status != "verified" || old.ExpiresAt == nil {
+		return nil, ErrNotRenewable
+	}
+	renewals, err := s.achievementRefPG.ListRenewals(ctx, old.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range renewals {
+		if r.Status != "deleted" {
+			return nil, ErrRenewalExists
+		}
+	}
+	prev, err := s.documentOf(ctx, old)
+	if err != nil {
+		return nil, err
+	}
+
+	doc.Category = prev.Category
+	if doc.Title == "" {
+		doc.Title = prev.Title
+	}
+	if doc.Type == "" {
+		doc.Type = prev.Type
+	}
+	if doc.Level == "" {
+		doc.Level = prev.Level
+	}
+	if doc.Tags == nil {
+		doc.Tags = prev.Tags
+	}
+	if next := certificateExpiry(doc.Category, doc.Details); next != nil && !next.After(*old.ExpiresAt) {
+		return nil, ErrRenewalNotLater
+	}
+
+	ref, err := s.createDraft(ctx, actor.UserID, doc, &old.ID)
+	if err != nil {
+		return nil, err
+	}
+	role := RoleStudent
+	s.writeActivityLog(ctx, &pgModel.ActivityLog{
+		ID:         uuid.New().String(),
+		EntityType: "achievement_reference",
+		EntityID:   old.ID,
+		EventType:  "renewal_started",
+		ActorID:    &actor.UserID,
+		ActorRole:  &role,
+		Metadata:   map[string]interface{}{"renewal_id": ref.ID},
+		CreatedAt:  time.Now(),
+	})
+	return ref, nil
+}
+
+// ExpiryCheckResult reports one run of CheckExpiry.
+type ExpiryCheckResult struct {
+	Backfilled int `json:"backfilled"` // references that got their expiry date copied
+	Flagged    int `json:"flagged"`    // certifications flagged as expiring soon
+}
+
+// CheckExpiry flags verified certifications that expire within the notice period and have no
+// verified renewal. Each certification is flagged once per expiry date, with an expiry_warning
+// activity log on the achievement.
+func (s *AchievementService) CheckExpiry(ctx context.Context) (*ExpiryCheckResult, error) {
+	res := &ExpiryCheckResult{}
+	n, err := s.backfillExpiry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res.Backfilled = n
+
+	now := time.Now()
+	refs, err := s.achievementRefPG.ListExpiring(ctx, now.AddDate(0, 0, s.expiryNoticeDays))
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		if err := s.achievementRefPG.FlagExpiry(ctx, ref.ID, now); err != nil {
+			return res, err
+		}
+		res.Flagged++
+		s.writeActivityLog(ctx, &pgModel.ActivityLog{
+			ID:         uuid.New().String(),
+			EntityType: "achievement_reference",
+			EntityID:   ref.ID,
+			EventType:  "expiry_warning",
+			Metadata: map[string]interface{}{
+				"student_id":  ref.StudentID,
+				"expires_at":  ref.ExpiresAt,
+				"days_left":   int(math.Ceil(ref.ExpiresAt.Sub(now).Hours() / 24)),
+				"notice_days": s.expiryNoticeDays,
+			},
+			CreatedAt: now,
+		})
+	}
+	return res, nil
+}
+
+// backfillExpiry copies the expiry date of certifications written before expiry tracking into
+// their references.
+func (s *AchievementService) backfillExpiry(ctx context.Context) (int, error) {
+	ids, err := s.achievementMongo.FindIDs(ctx, mongoRepo.AchievementFilter{Category: certificationCategory})
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	refs, err := s.achievementRefPG.Search(ctx, pgRepo.AchievementRefFilter{MongoIDs: ids, Limit: math.MaxInt32})
+	if err != nil {
+		return 0, err
+	}
+	missing := []*pgModel.AchievementReference{}
+	for _, ref := range refs {
+		if ref.ExpiresAt == nil {
+			missing = append(missing, ref)
+		}
+	}
+	if len(missing) == 0 {
+		return 0, nil
+	}
+	docs, err := s.loadDocuments(ctx, missing)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, ref := range missing {
+		doc, ok := docs[ref.MongoAchievementID]
+		if !ok {
+			continue
+		}
+		expiresAt := doc.ExpiresAt
+		if expiresAt == nil {
+			expiresAt = certificateExpiry(doc.Category, doc.Details)
+		}
+		if expiresAt == nil {
+			continue
+		}
+		if err := s.achievementRefPG.SetExpiry(ctx, ref.ID, expiresAt); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// StartExpiryCheck runs CheckExpiry now and then every interval until ctx is cancelled.
+func (s *AchievementService) StartExpiryCheck(ctx context.Context, interval time.Duration) {
+	if s.achievementMongo == nil {
+		return
+	}
+	run := func() {
+		rctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+		res, err := s.CheckExpiry(rctx)
+		if err != nil {
+			log.Printf("certification expiry check failed: %v", err)
+			return
+		}
+		if res.Flagged > 0 || res.Backfilled > 0 {
+			log.Printf("certification expiry check: %d flagged as expiring within %d days, %d backfilled",
+				res.Flagged, s.expiryNoticeDays, res.Backfilled)
+		}
+	}
+	go func() {
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	mongoModel "clean-arch/app/model/mongo"
+	pgModel "clean-arch/app/model/postgre"
+	mongoRepo "clean-arch/app/repository/mongo"
+	pgRepo "clean-arch/app/repository/postgre"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrInvalidSortField = errors.New("invalid sort field")
+)
+
+// AchievementListQuery is parsed from GET /achievements query parameters.
+type AchievementListQuery struct {
+	Statuses      []string
+	StudentID     string
+	AdvisorID     string
+	ProgramStudy  string
+	AcademicYear  string
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time
+	SubmittedFrom *time.Time
+	SubmittedTo   *time.Time
+	VerifiedFrom  *time.Time
+	VerifiedTo    *time.Time
+
+	// Mongo document fields
+	Category string
+	Level    string
+	Type     string
+	Title    string
+
+	SortBy    string // created_at (default), updated_at, submitted_at, verified_at
+	SortOrder string // asc | desc (default)
+	Cursor    string
+	Limit     int
+}
+
+// AchievementListItem is a reference row plus a summary of its Mongo document.
+// Status is the derived status (see EffectiveStatus); it hides the reference's own.
+type AchievementListItem struct {
+	*pgModel.AchievementReference
+	Status   string `json:"status"`
+	Title    string `json:"title"`
+	Category string `json:"category"`
+	Level    string `json:"level"`
+	Type     string `json:"type"`
+}
+
+// AchievementPage is the response envelope for GET /achievements.
+type AchievementPage struct {
+	Items        []*AchievementListItem `json:"items"`
+	NextCursor   string                 `json:"next_cursor,omitempty"`
+	Total        int                    `json:"total"`
+	StatusCounts map[string]int         `json:"status_counts"`
+}
+
+type listCursor struct {
+	Value time.Time `json:"v"`
+	ID    string    `json:"id"`
+}
+
+func encodeCursor(c listCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*listCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c listCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	// the id is compared with a uuid column; a malformed one would fail in Postgres
+	if _, err := uuid.Parse(c.ID); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// GetAllAchievements returns one page of achievement references matching q,
+// limited to what the actor is allowed to see.
+func (s *AchievementService) GetAllAchievements(ctx context.Context, actor Actor, q AchievementListQuery) (*AchievementPage, error) {
+	f := pgRepo.AchievementRefFilter{
+		Statuses:      q.Statuses,
+		StudentID:     q.StudentID,
+		AdvisorID:     q.AdvisorID,
+		ProgramStudy:  q.ProgramStudy,
+		AcademicYear:  q.AcademicYear,
+		CreatedFrom:   q.CreatedFrom,
+		CreatedTo:     q.CreatedTo,
+		SubmittedFrom: q.SubmittedFrom,
+		SubmittedTo:   q.SubmittedTo,
+		VerifiedFrom:  q.VerifiedFrom,
+		VerifiedTo:    q.VerifiedTo,
+		SortBy:        q.SortBy,
+		SortDesc:      q.SortOrder != "asc",
+		Limit:         q.Limit,
+	}
+	switch f.SortBy {
+	case "created_at", "updated_at", "submitted_at", "verified_at":
+	case "":
+		f.SortBy = "created_at"
+	default:
+		return nil, ErrInvalidSortField
+	}
+	if f.Limit <= 0 {
+		f.Limit = defaultListLimit
+	}
+	if f.Limit > maxListLimit {
+		f.Limit = maxListLimit
+	}
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		f.AfterValue = &c.Value
+		f.AfterID = c.ID
+	}
+
+	page := &AchievementPage{Items: []*AchievementListItem{}, StatusCounts: map[string]int{}}
+
+	scope, err := s.policy.Resolve(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.All {
+		if len(scope.StudentIDs) == 0 {
+			return page, nil
+		}
+		f.StudentIDs = scope.StudentIDs
+	}
+
+	// Mongo-side filters are resolved to a set of document ids first
+	mf := mongoRepo.AchievementFilter{Category: q.Category, Level: q.Level, Type: q.Type, Title: q.Title}
+	if !mf.IsEmpty() {
+		ids, err := s.achievementMongo.FindIDs(ctx, mf)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return page, nil
+		}
+		f.MongoIDs = ids
+	}
+
+	counts, err := s.achievementRefPG.CountByStatus(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	for status, n := range counts {
+		page.StatusCounts[status] = n
+		page.Total += n
+	}
+
+	// fetch one extra row to know whether another page exists
+	limit := f.Limit
+	f.Limit = limit + 1
+	refs, err := s.achievementRefPG.Search(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) > limit {
+		refs = refs[:limit]
+		last := refs[len(refs)-1]
+		page.NextCursor = encodeCursor(listCursor{Value: f.SortValue(last), ID: last.ID})
+	}
+
+	docs, err := s.loadDocuments(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, ref := range refs {
+		item := &AchievementListItem{AchievementReference: ref, Status: EffectiveStatus(ref, now)}
+		if d, ok := docs[ref.MongoAchievementID]; ok {
+			item.Title, item.Category, item.Level, item.Type = d.Title, d.Category, d.Level, d.Type
+		}
+		page.Items = append(page.Items, item)
+	}
+	return page, nil
+}
+
+// loadDocuments fetches the Mongo documents of refs in one query
+func (s *AchievementService) loadDocuments(ctx context.Context, refs []*pgModel.AchievementReference) (map[string]*mongoModel.Achievement, error) {
+	ids := make([]primitive.ObjectID, 0, len(refs))
+	for _, ref := range refs {
+		if oid, err := primitive.ObjectIDFromHex(ref.MongoAchievementID); err == nil {
+			ids = append(ids, oid)
+		}
+	}
+	return s.achievementMongo.GetByIDs(ctx, ids)
+}
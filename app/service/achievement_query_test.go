@@ -0,0 +1,47 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := listCursor{Value: time.Date(2026, 3, 1, 8, 30, 15, 123456789, time.UTC), ID: "6f1c2a9e-0000-4000-8000-000000000001"}
+	s := encodeCursor(want)
+	for _, r := range s {
+		if r == '+' || r == '/' || r == '=' {
+			t.Fatalf("cursor %q is not URL safe", s)
+		}
+	}
+	got, err := decodeCursor(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Value.Equal(want.Value) || got.ID != want.ID {
+		t.Fatalf("decodeCursor(encodeCursor(c)) = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	tests := map[string]string{
+		"not base64":    "%%%",
+		"padded":        base64.URLEncoding.EncodeToString([]byte(`{"v":"2026-03-01T00:00:00Z","id":"x"}`)),
+		"not JSON":      raw("created_at>2026"),
+		"missing id":    raw(`{"v":"2026-03-01T00:00:00Z"}`),
+		"empty id":      raw(`{"v":"2026-03-01T00:00:00Z","id":""}`),
+		"bad time":      raw(`{"v":"yesterday","id":"6f1c2a9e-0000-4000-8000-000000000001"}`),
+		"id not a uuid": raw(`{"v":"2026-03-01T00:00:00Z","id":"x"}`),
+		"wrong shape":   raw(`["2026-03-01T00:00:00Z","x"]`),
+		"empty string":  "",
+	}
+	for name, s := range tests {
+		t.Run(name, func(t *testing.T) {
+			if c, err := decodeCursor(s); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("decodeCursor(%q) = %+v, %v; want ErrInvalidCursor", s, c, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	pgModel "clean-arch/app/model/postgre"
+	pgRepo "clean-arch/app/repository/postgre"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidSchema = &CustomError{"invalid_schema", "schema is not valid", 400}
+
+// DetailsValidationError lists why achievement details do not satisfy their category schema.
+type DetailsValidationError struct {
+	Category   string
+	Violations []SchemaViolation
+}
+
+func (e *DetailsValidationError) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, v.Path+" "+v.Message)
+	}
+	return "details do not match the " + e.Category + " schema: " + strings.Join(parts, "; ")
+}
+
+// AchievementSchemaService manages per-category JSON Schemas and validates achievement details.
+// Categories without a schema accept free-form details. Changes need schema:manage, checked by
+// the routes.
+type AchievementSchemaService struct {
+	repo         pgRepo.AchievementSchemaRepository
+	activityRepo pgRepo.ActivityLogRepository
+}
+
+func NewAchievementSchemaService(
+	repo pgRepo.AchievementSchemaRepository,
+	activityRepo pgRepo.ActivityLogRepository,
+) *AchievementSchemaService {
+	return &AchievementSchemaService{repo: repo, activityRepo: activityRepo}
+}
+
+// AchievementSchemaRequest is the body of PUT /achievement-schemas/:category.
+type AchievementSchemaRequest struct {
+	Description string                 `json:"description"`
+	Schema      map[string]interface{} `json:"schema"`
+}
+
+func (s *AchievementSchemaService) List(ctx context.Context) ([]*pgModel.AchievementSchema, error) {
+	return s.repo.ListAll(ctx)
+}
+
+func (s *AchievementSchemaService) Get(ctx context.Context, category string) (*pgModel.AchievementSchema, error) {
+	sc, err := s.repo.GetByCategory(ctx, category)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return sc, nil
+}
+
+// Put creates or replaces the schema of a category.
+func (s *AchievementSchemaService) Put(ctx context.Context, actor Actor, category string, req AchievementSchemaRequest) (*pgModel.AchievementSchema, error) {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return nil, errors.New("category is required")
+	}
+	if req.Schema == nil {
+		return nil, errors.New("schema is required")
+	}
+	if err := checkSchema("schema", req.Schema); err != nil {
+		return nil, &CustomError{ErrInvalidSchema.Code, err.Error(), ErrInvalidSchema.Status}
+	}
+	if t, _ := schemaTypeList(req.Schema["type"]); len(t) != 1 || t[0] != "object" {
+		return nil, &CustomError{ErrInvalidSchema.Code, "schema.type must be \"object\"", ErrInvalidSchema.Status}
+	}
+
+	var previous map[string]interface{}
+	if old, err := s.repo.GetByCategory(ctx, category); err == nil {
+		previous = map[string]interface{}{"description": old.Description, "schema": old.Schema}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	sc := &pgModel.AchievementSchema{
+		Category:    category,
+		Description: req.Description,
+		Schema:      req.Schema,
+		UpdatedBy:   &actor.UserID,
+	}
+	if err := s.repo.Upsert(ctx, sc); err != nil {
+		return nil, err
+	}
+	s.log(ctx, actor, category, "updated", previous, map[string]interface{}{"description": sc.Description, "schema": sc.Schema})
+	return sc, nil
+}
+
+// Delete removes the schema of a category; its details become free-form again.
+func (s *AchievementSchemaService) Delete(ctx context.Context, actor Actor, category string) error {
+	old, err := s.Get(ctx, category)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, category); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	s.log(ctx, actor, category, "deleted", map[string]interface{}{"description": old.Description, "schema": old.Schema}, nil)
+	return nil
+}
+
+// ValidateDetails checks details against the schema of category. It returns a
+// *DetailsValidationError when they do not match.
+func (s *AchievementSchemaService) ValidateDetails(ctx context.Context, category string, details map[string]interface{}) error {
+	if s == nil || s.repo == nil {
+		return nil
+	}
+	sc, err := s.repo.GetByCategory(ctx, category)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	value, err := plainJSON(details)
+	if err != nil {
+		return err
+	}
+	if v := validateSchema("details", sc.Schema, value); len(v) > 0 {
+		return &DetailsValidationError{Category: category, Violations: v}
+	}
+	return nil
+}
+
+func (s *AchievementSchemaService) log(ctx context.Context, actor Actor, category, event string, previous, current map[string]interface{}) {
+	if s.activityRepo == nil {
+		return
+	}
+	_ = s.activityRepo.Create(ctx, &pgModel.ActivityLog{
+		ID:         uuid.New().String(),
+		EntityType: "achievement_schema",
+		EntityID:   category,
+		EventType:  event,
+		ActorID:    &actor.UserID,
+		Previous:   previous,
+		Current:    current,
+		CreatedAt:  time.Now(),
+	})
+}
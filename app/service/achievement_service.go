@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	pgModel "clean-arch/app/model/postgre"
 	mongoRepo "clean-arch/app/repository/mongo"
 	pgRepo "clean-arch/app/repository/postgre"
+	"clean-arch/app/storage"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -19,8 +21,25 @@ type AchievementService struct {
 	achievementMongo mongoRepo.AchievementRepository
 	achievementRefPG pgRepo.AchievementRefRepository
 	studentRepo      pgRepo.StudentRepository
+	lecturerRepo     pgRepo.LecturerRepository
+	delegationRepo   pgRepo.DelegationRepository
 	userRepo         pgRepo.UserRepository
 	activityRepo     pgRepo.ActivityLogRepository
+	reviewRoundRepo  pgRepo.ReviewRoundRepository
+	memberRepo       pgRepo.AchievementMemberRepository
+	commentRepo      pgRepo.AchievementCommentRepository
+	attachmentRepo   pgRepo.AchievementAttachmentRepository
+	outboxRepo       pgRepo.OutboxRepository
+	tx               pgRepo.Transactor
+	policy           *AccessPolicy
+	workflow         *WorkflowDefinition
+	permissions      PermissionChecker
+	files            storage.Storage
+	schemas          *AchievementSchemaService
+	scoring          *ScoringService
+	attachments      AttachmentLimits
+	expiryNoticeDays int    // certifications expiring within this many days are flagged
+	urlSecret        []byte // signs attachment download links
 }
 
 // NewAchievementService creates an instance of AchievementService.
@@ -29,15 +48,50 @@ func NewAchievementService(
 	achievementMongo mongoRepo.AchievementRepository,
 	achievementRefPG pgRepo.AchievementRefRepository,
 	studentRepo pgRepo.StudentRepository,
+	lecturerRepo pgRepo.LecturerRepository,
+	delegationRepo pgRepo.DelegationRepository,
 	userRepo pgRepo.UserRepository,
 	activityRepo pgRepo.ActivityLogRepository,
+	reviewRoundRepo pgRepo.ReviewRoundRepository,
+	memberRepo pgRepo.AchievementMemberRepository,
+	commentRepo pgRepo.AchievementCommentRepository,
+	attachmentRepo pgRepo.AchievementAttachmentRepository,
+	outboxRepo pgRepo.OutboxRepository,
+	tx pgRepo.Transactor,
+	policy *AccessPolicy,
+	workflow *WorkflowDefinition,
+	permissions PermissionChecker,
+	files storage.Storage,
+	schemas *AchievementSchemaService,
+	scoring *ScoringService,
+	urlSecret []byte,
 ) *AchievementService {
+	if workflow == nil {
+		workflow = DefaultWorkflow()
+	}
 	return &AchievementService{
 		achievementMongo: achievementMongo,
 		achievementRefPG: achievementRefPG,
 		studentRepo:      studentRepo,
+		lecturerRepo:     lecturerRepo,
+		delegationRepo:   delegationRepo,
 		userRepo:         userRepo,
 		activityRepo:     activityRepo,
+		reviewRoundRepo:  reviewRoundRepo,
+		memberRepo:       memberRepo,
+		commentRepo:      commentRepo,
+		attachmentRepo:   attachmentRepo,
+		outboxRepo:       outboxRepo,
+		tx:               tx,
+		policy:           policy,
+		workflow:         workflow,
+		permissions:      permissions,
+		files:            files,
+		schemas:          schemas,
+		scoring:          scoring,
+		attachments:      attachmentLimitsFromEnv(),
+		expiryNoticeDays: expiryNoticeDaysFromEnv(),
+		urlSecret:        urlSecret,
 	}
 }
 
@@ -50,8 +104,13 @@ func (s *AchievementService) writeActivityLog(ctx context.Context, logEntry *pgM
 	_ = s.activityRepo.Create(ctx, logEntry)
 }
 
-// CreateDraft saves achievement doc to Mongo and creates a reference row in Postgres (status=draft)
+// CreateDraft creates a reference row in Postgres (status=draft) and saves the doc to Mongo through the outbox
 func (s *AchievementService) CreateDraft(ctx context.Context, userID string, doc *mongoModel.Achievement) (*pgModel.AchievementReference, error) {
+	return s.createDraft(ctx, userID, doc, nil)
+}
+
+// createDraft creates the draft; renewalOf links a renewed certificate to the one it replaces
+func (s *AchievementService) createDraft(ctx context.Context, userID string, doc *mongoModel.Achievement, renewalOf *string) (*pgModel.AchievementReference, error) {
 	// 1. validate student
 	student, err := s.studentRepo.GetByUserID(ctx, userID)
 	if err != nil {
@@ -61,30 +120,62 @@ func (s *AchievementService) CreateDraft(ctx context.Context, userID string, doc
 		return nil, errors.New("student profile not found")
 	}
 
-	// 2. save to mongo
-	doc.StudentID = student.ID
-	oid, err := s.achievementMongo.Create(ctx, doc)
-	if err != nil {
+	// 2. details must match the category schema (if the category has one)
+	if err := s.schemas.ValidateDetails(ctx, doc.Category, doc.Details); err != nil {
 		return nil, err
 	}
 
-	// 3. create reference in postgres
+	// 3. the document id is assigned here so the reference can point at it before Mongo has it
+	now := time.Now()
+	doc.ID = primitive.NewObjectID()
+	doc.StudentID = student.ID
+	doc.CreatedAt = now
+	doc.UpdatedAt = now
+	doc.Attachments = nil // files are added through AddAttachment
+	doc.ExpiresAt = certificateExpiry(doc.Category, doc.Details)
+
 	ref := &pgModel.AchievementReference{
 		ID:                 uuid.New().String(),
 		StudentID:          student.ID,
-		MongoAchievementID: oid.Hex(),
-		Status:             "draft",
-		CreatedAt:          time.Now(),
-		UpdatedAt:          time.Now(),
+		MongoAchievementID: doc.ID.Hex(),
+		Status:             s.workflow.InitialState,
+		ExpiresAt:          doc.ExpiresAt,
+		RenewalOf:          renewalOf,
+		CreatedAt:          now,
+		UpdatedAt:          now,
 	}
 
-	if err := s.achievementRefPG.Create(ctx, ref); err != nil {
-		// cleanup mongo doc best-effort
-		_ = s.achievementMongo.SoftDelete(ctx, oid)
+	// 4. reference, its leader and the outbox message in one transaction; the Mongo insert follows (retried by the relay)
+	msg, err := newOutboxMessage(ref.ID, pgModel.OutboxCreateDocument, doc)
+	if err != nil {
+		return nil, err
+	}
+	leader := &pgModel.AchievementMember{
+		ReferenceID: ref.ID,
+		StudentID:   student.ID,
+		Role:        pgModel.MemberRoleLeader,
+		Status:      pgModel.MemberAccepted,
+		InvitedBy:   &userID,
+		InvitedAt:   now,
+		RespondedAt: &now,
+	}
+	if err := s.writeWithOutbox(ctx, func(tx *sql.Tx) error {
+		if err := s.achievementRefPG.WithTx(tx).Create(ctx, ref); err != nil {
+			return err
+		}
+		return s.memberRepo.WithTx(tx).Upsert(ctx, leader)
+	}, msg); err != nil {
 		return nil, err
 	}
 
-	// 4. write activity log (created)
+	// 5. write activity log (created)
+	current := map[string]interface{}{
+		"status":               ref.Status,
+		"mongo_achievement_id": ref.MongoAchievementID,
+	}
+	if renewalOf != nil {
+		current["renewal_of"] = *renewalOf
+	}
 	logEntry := &pgModel.ActivityLog{
 		ID:         uuid.New().String(),
 		EntityType: "achievement_reference",
@@ -92,220 +183,111 @@ func (s *AchievementService) CreateDraft(ctx context.Context, userID string, doc
 		EventType:  "created",
 		ActorID:    &userID,
 		Previous:   nil,
-		Current: map[string]interface{}{
-			"status":               ref.Status,
-			"mongo_achievement_id": ref.MongoAchievementID,
-		},
-		CreatedAt: time.Now(),
+		Current:    current,
+		CreatedAt:  time.Now(),
 	}
 	s.writeActivityLog(ctx, logEntry)
 
 	return ref, nil
 }
 
-// Submit transitions draft -> submitted
-func (s *AchievementService) Submit(ctx context.Context, refID string, userID string) error {
-	// validate student
-	student, err := s.studentRepo.GetByUserID(ctx, userID)
-	if err != nil {
-		return err
-	}
-	if student == nil {
-		return errors.New("student not found")
-	}
+// Submit transitions draft (or rejected, as a new review round) -> submitted.
+// overrideReason is required when the achievement looks like an existing one (see DuplicateError).
+func (s *AchievementService) Submit(ctx context.Context, refID string, actor Actor, overrideReason string) error {
+	_, err := s.Transition(ctx, refID, actor, "submit", TransitionInput{OverrideReason: overrideReason})
+	return err
+}
 
-	// get reference
-	ref, err := s.achievementRefPG.GetByID(ctx, refID)
-	if err != nil {
-		return err
-	}
-	if ref == nil {
-		return errors.New("achievement reference not found")
-	}
-	if ref.StudentID != student.ID {
-		return errors.New("not owner")
-	}
-	if ref.Status != "draft" {
-		return errors.New("invalid status transition: only draft can be submitted")
-	}
+// Verification authority recorded in activity_logs.metadata.authority
+const (
+	AuthorityAdvisor       = "advisor"
+	AuthorityDelegate      = "delegate"
+	AuthorityAdminOverride = "admin_override"
+)
 
-	// update status
-	now := time.Now()
-	ref.SubmittedAt = &now
-	if err := s.achievementRefPG.UpdateStatus(ctx, refID, "submitted", nil); err != nil {
-		return err
-	}
+var (
+	ErrNotAdvisor       = &CustomError{"not_advisor", "only the student's advisor or an active delegate can review this achievement", 403}
+	ErrSelfVerification = &CustomError{"self_verification", "you cannot review your own achievement", 403}
+)
 
-	// activity log
-	logEntry := &pgModel.ActivityLog{
-		ID:         uuid.New().String(),
-		EntityType: "achievement_reference",
-		EntityID:   ref.ID,
-		EventType:  "status_changed",
-		ActorID:    &userID,
-		Previous:   map[string]interface{}{"status": "draft"},
-		Current:    map[string]interface{}{"status": "submitted", "submitted_at": now},
-		CreatedAt:  time.Now(),
-	}
-	s.writeActivityLog(ctx, logEntry)
-	return nil
+// verificationGrant describes why the actor may review an achievement
+type verificationGrant struct {
+	Authority    string
+	DelegationID string
 }
 
-// Verify transitions submitted -> verified
-func (s *AchievementService) Verify(ctx context.Context, refID string, verifierUserID string) error {
-	// verifier existence check
-	verifier, err := s.userRepo.GetByID(ctx, verifierUserID)
-	if err != nil {
-		return err
-	}
-	if verifier == nil {
-		return errors.New("verifier user not found")
-	}
-
-	// get reference
-	ref, err := s.achievementRefPG.GetByID(ctx, refID)
+// authorizeReviewer allows the student's advisor, a lecturer holding an active
+// delegation from that advisor, or an admin (override). Nobody may review their own work.
+func (s *AchievementService) authorizeReviewer(ctx context.Context, actor Actor, ref *pgModel.AchievementReference) (*verificationGrant, error) {
+	student, err := s.studentRepo.GetByID(ctx, ref.StudentID)
 	if err != nil {
-		return err
-	}
-	if ref == nil {
-		return errors.New("achievement reference not found")
-	}
-	if ref.Status != "submitted" {
-		return errors.New("only submitted achievements can be verified")
-	}
-
-	// update status in db (use UpdateStatus which sets verified_by & verified_at when provided)
-	if err := s.achievementRefPG.UpdateStatus(ctx, refID, "verified", &verifierUserID); err != nil {
-		return err
+		return nil, err
 	}
-
-	// activity log
-	now := time.Now()
-	logEntry := &pgModel.ActivityLog{
-		ID:         uuid.New().String(),
-		EntityType: "achievement_reference",
-		EntityID:   ref.ID,
-		EventType:  "status_changed",
-		ActorID:    &verifierUserID,
-		ActorRole:  nil, // optional: you can fetch role name if needed
-		Previous:   map[string]interface{}{"status": "submitted"},
-		Current:    map[string]interface{}{"status": "verified", "verified_at": now, "verified_by": verifierUserID},
-		CreatedAt:  time.Now(),
+	if student.UserID == actor.UserID {
+		return nil, ErrSelfVerification
 	}
-	s.writeActivityLog(ctx, logEntry)
-	return nil
-}
 
-// Reject sets status to rejected and saves rejection note
-func (s *AchievementService) Reject(ctx context.Context, refID string, verifierUserID string, note string) error {
-	// verifier existence check
-	verifier, err := s.userRepo.GetByID(ctx, verifierUserID)
-	if err != nil {
-		return err
+	lecturer, err := s.lecturerRepo.GetByUserID(ctx, actor.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
 	}
-	if verifier == nil {
-		return errors.New("verifier user not found")
+	if lecturer != nil && student.AdvisorID != nil {
+		if *student.AdvisorID == lecturer.ID {
+			return &verificationGrant{Authority: AuthorityAdvisor}, nil
+		}
+		d, err := s.delegationRepo.FindActive(ctx, *student.AdvisorID, lecturer.ID, time.Now())
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if d != nil {
+			return &verificationGrant{Authority: AuthorityDelegate, DelegationID: d.ID}, nil
+		}
 	}
 
-	// get reference
-	ref, err := s.achievementRefPG.GetByID(ctx, refID)
+	admin, err := s.policy.IsAdmin(ctx, actor)
 	if err != nil {
-		return err
-	}
-	if ref == nil {
-		return errors.New("achievement reference not found")
+		return nil, err
 	}
-	if ref.Status != "submitted" {
-		return errors.New("only submitted achievements can be rejected")
+	if admin {
+		return &verificationGrant{Authority: AuthorityAdminOverride}, nil
 	}
+	return nil, ErrNotAdvisor
+}
 
-	// update rejection note and status
-	if err := s.achievementRefPG.UpdateRejectionNote(ctx, refID, note); err != nil {
-		return err
-	}
+// Verify transitions submitted -> verified
+func (s *AchievementService) Verify(ctx context.Context, refID string, actor Actor) error {
+	_, err := s.Transition(ctx, refID, actor, "verify", TransitionInput{})
+	return err
+}
 
-	// activity log
-	now := time.Now()
-	logEntry := &pgModel.ActivityLog{
-		ID:         uuid.New().String(),
-		EntityType: "achievement_reference",
-		EntityID:   ref.ID,
-		EventType:  "status_changed",
-		ActorID:    &verifierUserID,
-		Previous:   map[string]interface{}{"status": "submitted"},
-		Current:    map[string]interface{}{"status": "rejected", "rejection_note": note, "rejected_at": now},
-		CreatedAt:  time.Now(),
-	}
-	s.writeActivityLog(ctx, logEntry)
-	return nil
+// Reject sets status to rejected and saves rejection note
+func (s *AchievementService) Reject(ctx context.Context, refID string, actor Actor, note string) error {
+	_, err := s.Transition(ctx, refID, actor, "reject", TransitionInput{Note: note})
+	return err
 }
 
-// DeleteDraft: soft delete in Mongo + update reference in Postgres to 'deleted' (only owner, only draft)
-func (s *AchievementService) DeleteDraft(ctx context.Context, refID string, userID string) error {
-	// validate student
-	student, err := s.studentRepo.GetByUserID(ctx, userID)
-	if err != nil {
-		return err
-	}
-	if student == nil {
-		return errors.New("student not found")
-	}
+// DeleteDraft: soft delete in Mongo + update reference in Postgres to 'deleted' (only owner, only draft).
+// ifMatch is the reference version the caller expects (nil skips the check).
+func (s *AchievementService) DeleteDraft(ctx context.Context, refID string, actor Actor, ifMatch *int) error {
+	_, err := s.Transition(ctx, refID, actor, "delete", TransitionInput{IfMatch: ifMatch})
+	return err
+}
 
-	// get reference
+// GetDetail returns both Mongo document and Postgres reference (only if the actor may see it)
+func (s *AchievementService) GetDetail(ctx context.Context, actor Actor, refID string) (*mongoModel.Achievement, *pgModel.AchievementReference, error) {
 	ref, err := s.achievementRefPG.GetByID(ctx, refID)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	if ref == nil {
-		return errors.New("achievement reference not found")
-	}
-	if ref.StudentID != student.ID {
-		return errors.New("not owner")
-	}
-	if ref.Status != "draft" {
-		return errors.New("only draft achievements can be deleted")
-	}
-
-	// soft delete mongo doc
-	oid, err := primitive.ObjectIDFromHex(ref.MongoAchievementID)
-	if err != nil {
-		// still update postgres to deleted for safety
-		_ = s.achievementRefPG.UpdateStatus(ctx, refID, "deleted", nil)
-		return errors.New("invalid mongo object id")
-	}
-	if err := s.achievementMongo.SoftDelete(ctx, oid); err != nil {
-		// continue to update postgres anyway (best-effort)
-	}
-
-	// update postgres reference status to deleted
-	if err := s.achievementRefPG.UpdateStatus(ctx, refID, "deleted", nil); err != nil {
-		return err
-	}
-
-	// activity log
-	now := time.Now()
-	logEntry := &pgModel.ActivityLog{
-		ID:         uuid.New().String(),
-		EntityType: "achievement_reference",
-		EntityID:   ref.ID,
-		EventType:  "deleted",
-		ActorID:    &userID,
-		Previous:   map[string]interface{}{"status": "draft"},
-		Current:    map[string]interface{}{"status": "deleted", "deleted_at": now},
-		CreatedAt:  time.Now(),
+		return nil, nil, errors.New("reference not found")
 	}
-	s.writeActivityLog(ctx, logEntry)
-	return nil
-}
-
-// GetDetail returns both Mongo document and Postgres reference
-func (s *AchievementService) GetDetail(ctx context.Context, refID string) (*mongoModel.Achievement, *pgModel.AchievementReference, error) {
-	ref, err := s.achievementRefPG.GetByID(ctx, refID)
+	allowed, err := s.policy.CanViewAchievement(ctx, actor, ref)
 	if err != nil {
 		return nil, nil, err
 	}
-	if ref == nil {
-		return nil, nil, errors.New("reference not found")
+	if !allowed {
+		return nil, nil, ErrForbidden
 	}
 	oid, err := primitive.ObjectIDFromHex(ref.MongoAchievementID)
 	if err != nil {
@@ -318,72 +300,170 @@ func (s *AchievementService) GetDetail(ctx context.Context, refID string) (*mong
 	return ach, ref, nil
 }
 
+// DocumentVersion returns the version of the Mongo document GetDetail would serve for ref now
+// (0 when there is none); with the reference version it makes up the achievement's ETag.
+func (s *AchievementService) DocumentVersion(ctx context.Context, ref *pgModel.AchievementReference) (int, error) {
+	oid, err := primitive.ObjectIDFromHex(ref.MongoAchievementID)
+	if err != nil {
+		return 0, errors.New("invalid mongo id stored in reference")
+	}
+	doc, err := s.achievementMongo.GetByID(ctx, oid)
+	if err != nil || doc == nil {
+		return 0, err
+	}
+	return doc.Version, nil
+}
+
 // ListByStudent returns all achievements for a student
 func (s *AchievementService) ListByStudent(ctx context.Context, studentID string) ([]*pgModel.AchievementReference, error) {
 	return s.achievementRefPG.ListByStudent(ctx, studentID)
 }
 
-func (s *AchievementService) GetAllAchievements(ctx context.Context, filters map[string]interface{}) ([]*pgModel.AchievementReference, error) {
-    // Saat ini repo hanya punya ListAll tanpa filter dinamis, 
-    // Anda bisa update repository untuk menerima filter, atau ambil semua dulu (jika data sedikit).
-    return s.achievementRefPG.ListAll(ctx)
-}
-
-func (s *AchievementService) UpdateDraft(ctx context.Context, refID string, userID string, updates map[string]interface{}) error {
+// UpdateDraft changes document fields of the owner's editable achievement and returns the
+// updated reference. ifMatch is the reference version the caller expects (nil skips the check).
+func (s *AchievementService) UpdateDraft(ctx context.Context, refID string, userID string, updates map[string]interface{}, ifMatch *int) (*pgModel.AchievementReference, error) {
 	// validate student
 	student, err := s.studentRepo.GetByUserID(ctx, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if student == nil {
-		return errors.New("student not found")
+		return nil, errors.New("student not found")
 	}
 
 	// get reference
 	ref, err := s.achievementRefPG.GetByID(ctx, refID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if ref == nil {
-		return errors.New("achievement reference not found")
+		return nil, errors.New("achievement reference not found")
 	}
 	if ref.StudentID != student.ID {
-		return errors.New("not owner")
+		return nil, errors.New("not owner")
+	}
+	if ifMatch != nil && *ifMatch != ref.Version {
+		return nil, ErrPreconditionFailed
 	}
-	if ref.Status != "draft" {
-		return errors.New("only draft achievements can be updated")
+	// draft, and rejected achievements being revised before resubmission (per workflow config)
+	if st := s.workflow.State(ref.Status); st == nil || !st.Editable {
+		return nil, errors.New("achievement cannot be updated in its current status")
 	}
 
-	// update MongoDB document
-	oid, err := primitive.ObjectIDFromHex(ref.MongoAchievementID)
-if err != nil {
-    return errors.New("invalid mongo object id")
+	if err := checkDocumentUpdates(updates); err != nil {
+		return nil, err
+	}
+	doc, err := s.documentOf(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyDocumentUpdates(ctx, ref, doc, userID, updates, "updated", nil); err != nil {
+		return nil, err
+	}
+	return ref, nil
 }
 
-// Update MongoDB
-if err := s.achievementMongo.Update(ctx, oid, updates); err != nil {
-    return err
-}
+// applyDocumentUpdates validates checked updates against the category schema and writes them
+// through the outbox. The activity log keeps the replaced values; the whole prior document is
+// kept by the repository as a version.
+func (s *AchievementService) applyDocumentUpdates(ctx context.Context, ref *pgModel.AchievementReference, doc *mongoModel.Achievement, userID string, updates map[string]interface{}, eventType string, metadata map[string]interface{}) error {
+	// the resulting details must match the (possibly new) category schema
+	category, details := doc.Category, doc.Details
+	if v, ok := updates["category"]; ok {
+		category = v.(string)
+	}
+	if v, ok := updates["details"]; ok {
+		details, _ = v.(map[string]interface{})
+	}
+	if err := s.schemas.ValidateDetails(ctx, category, details); err != nil {
+		return err
+	}
 
-// Update Timestamp Postgres
-ref.UpdatedAt = time.Now()
-if err := s.achievementRefPG.Update(ctx, ref); err != nil {
-    return err
-}
+	// certifications: expiresAt follows details.expiry, and a new date needs a new warning
+	expiresAt := certificateExpiry(category, details)
+	if !sameTime(expiresAt, doc.ExpiresAt) {
+		if expiresAt != nil {
+			updates["expiresAt"] = *expiresAt
+		} else {
+			updates["expiresAt"] = nil
+		}
+	}
+	if !sameTime(expiresAt, ref.ExpiresAt) {
+		ref.ExpiresAt = expiresAt
+		ref.ExpiryFlaggedAt = nil
+	}
+
+	// Postgres timestamp + Mongo update (via outbox) in one transaction
+	msg, err := newOutboxMessage(ref.ID, pgModel.OutboxUpdateDocument, map[string]interface{}{
+		"mongoId": doc.ID, "updates": updates,
+	})
+	if err != nil {
+		return err
+	}
+	ref.UpdatedAt = time.Now()
+	if err := s.writeWithOutbox(ctx, func(tx *sql.Tx) error {
+		return s.achievementRefPG.WithTx(tx).Update(ctx, ref)
+	}, msg); err != nil {
+		return err
+	}
 
 	// activity log
+	before := achievementSnapshot(doc)
+	previous := map[string]interface{}{}
+	for k := range updates {
+		previous[k] = before[k]
+	}
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["base_version"] = doc.Version
 	logEntry := &pgModel.ActivityLog{
 		ID:         uuid.New().String(),
 		EntityType: "achievement_reference",
 		EntityID:   ref.ID,
-		EventType:  "updated",
+		EventType:  eventType,
 		ActorID:    &userID,
-		Previous:   nil,
+		Previous:   previous,
 		Current:    updates,
+		Metadata:   metadata,
 		CreatedAt:  time.Now(),
 	}
 	s.writeActivityLog(ctx, logEntry)
 
-	
+	return nil
+}
+
+// checkDocumentUpdates rejects fields outside mongoRepo.MutableAchievementFields and values of the wrong type
+func checkDocumentUpdates(updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return &CustomError{"empty_update", "no fields to update", 400}
+	}
+	for k, v := range updates {
+		if !mongoRepo.MutableAchievementFields[k] {
+			return &CustomError{"field_not_mutable", "field " + k + " cannot be updated", 400}
+		}
+		if k == "expiresAt" {
+			return &CustomError{"field_not_mutable", "expiresAt is taken from details.expiry", 400}
+		}
+		ok := true
+		switch k {
+		case "title", "type", "category", "level":
+			_, ok = v.(string)
+		case "details":
+			_, ok = v.(map[string]interface{})
+			ok = ok || v == nil
+		case "tags":
+			list, isList := v.([]interface{})
+			ok = isList || v == nil
+			for _, t := range list {
+				if _, isStr := t.(string); !isStr {
+					ok = false
+				}
+			}
+		}
+		if !ok {
+			return &CustomError{"invalid_field", "field " + k + " has an invalid type", 400}
+		}
+	}
 	return nil
 }
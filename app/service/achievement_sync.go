@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	mongoModel "clean-arch/app/model/mongo"
+	pgModel "clean-arch/app/model/postgre"
+	pgRepo "clean-arch/app/repository/postgre"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	driver "go.mongodb.org/mongo-driver/mongo"
+)
+
+// Mongo writes of achievements go through a transactional outbox: the message is stored in the
+// same Postgres transaction as the achievement_references change and applied in creation order
+// per achievement, right after commit when nothing older is still pending for it, otherwise by
+// the relay (StartOutboxRelay). A message that exhausts its retries is parked as failed and
+// reported by Reconcile; it no longer holds back later messages of its achievement.
+const (
+	outboxLease       = 30 * time.Second // relay leaves a fresh message to its writer for this long
+	outboxBatchSize   = 50
+	outboxMaxAttempts = 10
+	outboxMaxBackoff  = 10 * time.Minute
+)
+
+// newOutboxMessage encodes payload as canonical extended JSON so BSON types survive jsonb
+func newOutboxMessage(refID string, operation string, payload interface{}) (*pgModel.OutboxMessage, error) {
+	m := &pgModel.OutboxMessage{ID: uuid.New().String(), ReferenceID: refID, Operation: operation}
+	if payload != nil {
+		b, err := bson.MarshalExtJSON(payload, true, false)
+		if err != nil {
+			return nil, err
+		}
+		m.Payload = b
+	}
+	return m, nil
+}
+
+// writeWithOutbox runs fn and stores msgs in one transaction, then applies msgs unless an
+// earlier message of the same achievement is still pending (applying them now could be undone
+// by that one later). Once committed the call succeeds; the rest is left to the relay.
+// A reference changed since it was read fails with ErrPreconditionFailed.
+func (s *AchievementService) writeWithOutbox(ctx context.Context, fn func(tx *sql.Tx) error, msgs ...*pgModel.OutboxMessage) error {
+	notBefore := time.Now().Add(outboxLease)
+	err := s.tx.WithinTx(ctx, func(tx *sql.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		outbox := s.outboxRepo.WithTx(tx)
+		for _, m := range msgs {
+			if err := outbox.Enqueue(ctx, m, notBefore); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, pgRepo.ErrStaleVersion) {
+			return ErrPreconditionFailed
+		}
+		return err
+	}
+	for _, m := range msgs {
+		next, err := s.outboxRepo.ClaimIfNext(ctx, m.ID, outboxLease)
+		if err != nil {
+			log.Printf("outbox %s: claim: %v", m.ID, err)
+		}
+		if !next {
+			break // later messages wait behind this one
+		}
+		s.deliver(ctx, m)
+	}
+	return nil
+}
+
+// deliver applies one message and records the outcome
+func (s *AchievementService) deliver(ctx context.Context, m *pgModel.OutboxMessage) {
+	if err := s.applyOutbox(ctx, m); err != nil {
+		attempts := m.Attempts + 1
+		var retryAt *time.Time
+		if attempts < outboxMaxAttempts {
+			backoff := time.Duration(1<<uint(attempts)) * time.Second
+			if backoff > outboxMaxBackoff {
+				backoff = outboxMaxBackoff
+			}
+			t := time.Now().Add(backoff)
+			retryAt = &t
+		}
+		log.Printf("outbox %s (%s, ref %s) attempt %d failed: %v", m.ID, m.Operation, m.ReferenceID, attempts, err)
+		if err := s.outboxRepo.MarkRetry(ctx, m.ID, err.Error(), retryAt); err != nil {
+			log.Printf("outbox %s: record failure: %v", m.ID, err)
+		}
+		return
+	}
+	if err := s.outboxRepo.MarkDone(ctx, m.ID); err != nil {
+		log.Printf("outbox %s: mark done: %v", m.ID, err)
+	}
+}
+
+func (s *AchievementService) applyOutbox(ctx context.Context, m *pgModel.OutboxMessage) error {
+	switch m.Operation {
+	case pgModel.OutboxCreateDocument:
+		var doc mongoModel.Achievement
+		if err := bson.UnmarshalExtJSON(m.Payload, true, &doc); err != nil {
+			return err
+		}
+		return s.achievementMongo.InsertIfAbsent(ctx, &doc)
+	case pgModel.OutboxUpdateDocument:
+		var p struct {
+			MongoID primitive.ObjectID `bson:"mongoId"`
+			Updates bson.M             `bson:"updates"`
+		}
+		if err := bson.UnmarshalExtJSON(m.Payload, true, &p); err != nil {
+			return err
+		}
+		return s.achievementMongo.Update(ctx, p.MongoID, p.Updates)
+	case pgModel.OutboxSoftDeleteDocument:
+		var p struct {
+			MongoID primitive.ObjectID `bson:"mongoId"`
+		}
+		if err := bson.UnmarshalExtJSON(m.Payload, true, &p); err != nil {
+			return err
+		}
+		err := s.achievementMongo.SoftDelete(ctx, p.MongoID)
+		if errors.Is(err, driver.ErrNoDocuments) {
+			return nil // nothing left to hide
+		}
+		return err
+	case pgModel.OutboxRestoreDocument:
+		var p struct {
+			MongoID primitive.ObjectID `bson:"mongoId"`
+		}
+		if err := bson.UnmarshalExtJSON(m.Payload, true, &p); err != nil {
+			return err
+		}
+		return s.achievementMongo.Restore(ctx, p.MongoID)
+	case pgModel.OutboxPurgeDocument:
+		var p struct {
+			MongoID primitive.ObjectID `bson:"mongoId"`
+		}
+		if err := bson.UnmarshalExtJSON(m.Payload, true, &p); err != nil {
+			return err
+		}
+		docs, err := s.achievementMongo.GetByIDs(ctx, []primitive.ObjectID{p.MongoID})
+		if err != nil {
+			return err
+		}
+		// files first: the document is what lists them
+		if doc, ok := docs[p.MongoID.Hex()]; ok {
+			for _, att := range doc.Attachments {
+				if err := s.files.Delete(ctx, att.StorageKey); err != nil {
+					return err
+				}
+			}
+		}
+		return s.achievementMongo.HardDelete(ctx, p.MongoID)
+	case pgModel.OutboxAddAttachment:
+		var p struct {
+			MongoID    primitive.ObjectID    `bson:"mongoId"`
+			Attachment mongoModel.Attachment `bson:"attachment"`
+		}
+		if err := bson.UnmarshalExtJSON(m.Payload, true, &p); err != nil {
+			return err
+		}
+		return s.achievementMongo.AddAttachment(ctx, p.MongoID, p.Attachment)
+	case pgModel.OutboxRemoveAttachment:
+		var p struct {
+			MongoID      primitive.ObjectID `bson:"mongoId"`
+			AttachmentID string             `bson:"attachmentId"`
+			StorageKey   string             `bson:"storageKey"`
+		}
+		if err := bson.UnmarshalExtJSON(m.Payload, true, &p); err != nil {
+			return err
+		}
+		if err := s.achievementMongo.RemoveAttachment(ctx, p.MongoID, p.AttachmentID); err != nil {
+			return err
+		}
+		// the file goes only after Mongo no longer lists it
+		return s.files.Delete(ctx, p.StorageKey)
+	}
+	return errors.New("unknown outbox operation " + m.Operation)
+}
+
+// ProcessOutbox applies due messages left behind by crashed or failed writes.
+func (s *AchievementService) ProcessOutbox(ctx context.Context) (int, error) {
+	msgs, err := s.outboxRepo.ClaimPending(ctx, outboxBatchSize, outboxLease)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range msgs {
+		s.deliver(ctx, m)
+	}
+	return len(msgs), nil
+}
+
+// StartOutboxRelay drains the outbox at startup and then every interval until ctx is cancelled.
+func (s *AchievementService) StartOutboxRelay(ctx context.Context, interval time.Duration) {
+	if s.outboxRepo == nil || s.achievementMongo == nil {
+		return
+	}
+	drain := func() {
+		for {
+			rctx, cancel := context.WithTimeout(ctx, time.Minute)
+			n, err := s.ProcessOutbox(rctx)
+			cancel()
+			if err != nil {
+				log.Printf("outbox relay failed: %v", err)
+				return
+			}
+			if n < outboxBatchSize {
+				return
+			}
+		}
+	}
+
+	go func() {
+		drain()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				drain()
+			}
+		}
+	}()
+}
+
+// Drift kinds reported by Reconcile
+const (
+	DriftMissingDocument    = "missing_document"     // reference points at a document that does not exist
+	DriftDocumentDeleted    = "document_deleted"     // live reference, soft-deleted document
+	DriftDocumentNotDeleted = "document_not_deleted" // deleted reference, live document
+	DriftOrphanDocument     = "orphan_document"      // live document no reference points at
+	DriftFailedSync         = "failed_sync"          // outbox message that exhausted its retries
+)
+
+// DriftIssue is one inconsistency between achievement_references and the achievements collection.
+type DriftIssue struct {
+	Kind        string `json:"kind"`
+	ReferenceID string `json:"reference_id,omitempty"`
+	MongoID     string `json:"mongo_id,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+}
+
+// DriftReport is the result of one reconciliation run.
+type DriftReport struct {
+	CheckedAt     time.Time     `json:"checked_at"`
+	References    int           `json:"references"`
+	Documents     int           `json:"documents"`
+	PendingOutbox int           `json:"pending_outbox"`
+	Issues        []*DriftIssue `json:"issues"`
+}
+
+// Reconcile compares achievement_references.mongo_achievement_id with the achievements
+// collection. References with pending outbox messages are still converging and are skipped.
+func (s *AchievementService) Reconcile(ctx context.Context) (*DriftReport, error) {
+	report := &DriftReport{CheckedAt: time.Now(), Issues: []*DriftIssue{}}
+
+	unsettled, err := s.outboxRepo.ListUnsettled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inFlight := map[string]bool{}
+	for _, m := range unsettled {
+		inFlight[m.ReferenceID] = true
+		if m.Status == "pending" {
+			report.PendingOutbox++
+			continue
+		}
+		detail := m.Operation
+		if m.LastError != nil {
+			detail += ": " + *m.LastError
+		}
+		report.Issues = append(report.Issues, &DriftIssue{Kind: DriftFailedSync, ReferenceID: m.ReferenceID, Detail: detail})
+	}
+
+	refs, err := s.achievementRefPG.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := s.achievementMongo.ListIDStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.References, report.Documents = len(refs), len(docs)
+
+	referenced := map[string]bool{}
+	for _, ref := range refs {
+		referenced[ref.MongoAchievementID] = true
+		if inFlight[ref.ID] {
+			continue
+		}
+		deleted, exists := docs[ref.MongoAchievementID]
+		issue := &DriftIssue{ReferenceID: ref.ID, MongoID: ref.MongoAchievementID, Status: ref.Status}
+		switch {
+		case ref.Status == "deleted":
+			if exists && !deleted {
+				issue.Kind = DriftDocumentNotDeleted
+			}
+		case !exists:
+			issue.Kind = DriftMissingDocument
+		case deleted:
+			issue.Kind = DriftDocumentDeleted
+		}
+		if issue.Kind != "" {
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+	for id, deleted := range docs {
+		if !deleted && !referenced[id] {
+			report.Issues = append(report.Issues, &DriftIssue{Kind: DriftOrphanDocument, MongoID: id})
+		}
+	}
+	return report, nil
+}
+
+// StartReconciler logs drift every interval until ctx is cancelled.
+func (s *AchievementService) StartReconciler(ctx context.Context, interval time.Duration) {
+	if s.outboxRepo == nil || s.achievementMongo == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				rctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+				report, err := s.Reconcile(rctx)
+				cancel()
+				if err != nil {
+					log.Printf("achievement reconciliation failed: %v", err)
+					continue
+				}
+				if len(report.Issues) > 0 {
+					log.Printf("achievement reconciliation: %d drift issue(s) across %d references / %d documents",
+						len(report.Issues), report.References, report.Documents)
+					for _, is := range report.Issues {
+						log.Printf("  drift %s ref=%s mongo=%s status=%s %s", is.Kind, is.ReferenceID, is.MongoID, is.Status, is.Detail)
+					}
+				}
+			}
+		}
+	}()
+}
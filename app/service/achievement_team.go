@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	pgModel "clean-arch/app/model/postgre"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAlreadyMember      = &CustomError{"already_member", "the student is already on this achievement's team", 409}
+	ErrNotInvited         = &CustomError{"not_invited", "you have no pending invitation for this achievement", 404}
+	ErrNotMember          = &CustomError{"not_member", "the student is not on this achievement's team", 404}
+	ErrLeaderCannotLeave  = &CustomError{"leader_cannot_leave", "the team leader cannot be removed from the achievement", 409}
+	ErrPendingInvitations = &CustomError{"pending_invitations", "every invited team member must accept or decline before submission", 409}
+	ErrStudentOnly        = &CustomError{"student_only", "only students can take part in team achievements", 403}
+)
+
+// AchievementInvitation is a pending team invitation with a summary of the achievement.
+type AchievementInvitation struct {
+	*pgModel.AchievementMember
+	LeaderID string `json:"leader_id"` // students.id of the team leader
+	Status   string `json:"achievement_status"`
+	Title    string `json:"title"`
+	Category string `json:"category"`
+}
+
+// ListMembers returns the team of an achievement, leader first.
+func (s *AchievementService) ListMembers(ctx context.Context, actor Actor, refID string) ([]*pgModel.AchievementMember, error) {
+	if _, err := s.loadVisibleRef(ctx, actor, refID); err != nil {
+		return nil, err
+	}
+	return s.memberRepo.ListByReference(ctx, refID)
+}
+
+// InviteMember lets the leader invite another student as a team member. Invitations can
+// only be sent while the achievement is editable; a student who declined can be invited again.
+func (s *AchievementService) InviteMember(ctx context.Context, actor Actor, refID string, studentID string) (*pgModel.AchievementMember, error) {
+	ref, err := s.editableTeam(ctx, actor, refID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.studentRepo.GetByID(ctx, studentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	existing, err := s.memberRepo.Get(ctx, ref.ID, studentID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if existing != nil && existing.Status != pgModel.MemberDeclined {
+		return nil, ErrAlreadyMember
+	}
+
+	m := &pgModel.AchievementMember{
+		ReferenceID: ref.ID,
+		StudentID:   studentID,
+		Role:        pgModel.MemberRoleMember,
+		Status:      pgModel.MemberInvited,
+		InvitedBy:   &actor.UserID,
+		InvitedAt:   time.Now(),
+	}
+	if err := s.memberRepo.Upsert(ctx, m); err != nil {
+		return nil, err
+	}
+	s.writeMemberLog(ctx, actor, ref.ID, "member_invited", nil, m)
+	return m, nil
+}
+
+// RespondInvitation records the calling student's answer to a pending invitation.
+func (s *AchievementService) RespondInvitation(ctx context.Context, actor Actor, refID string, accept bool) (*pgModel.AchievementMember, error) {
+	student, err := s.actorStudent(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.memberRepo.Get(ctx, refID, student.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotInvited
+		}
+		return nil, err
+	}
+	if m.Status != pgModel.MemberInvited {
+		return nil, ErrNotInvited
+	}
+	ref, err := s.achievementRefPG.GetByID(ctx, refID)
+	if err != nil {
+		return nil, err
+	}
+	if st := s.workflow.State(ref.Status); st == nil || !st.Editable {
+		return nil, ErrNotEditable
+	}
+
+	status, event := pgModel.MemberDeclined, "member_declined"
+	if accept {
+		status, event = pgModel.MemberAccepted, "member_accepted"
+	}
+	if err := s.memberRepo.SetStatus(ctx, refID, student.ID, status); err != nil {
+		return nil, err
+	}
+	previous := *m
+	now := time.Now()
+	m.Status, m.RespondedAt = status, &now
+	s.writeMemberLog(ctx, actor, refID, event, &previous, m)
+	return m, nil
+}
+
+// RemoveMember takes a student off the team: the leader may remove anyone else, a member may
+// leave. Like invitations this is only possible while the achievement is editable.
+func (s *AchievementService) RemoveMember(ctx context.Context, actor Actor, refID string, studentID string) error {
+	ref, err := s.loadVisibleRef(ctx, actor, refID)
+	if err != nil {
+		return err
+	}
+	student, err := s.actorStudent(ctx, actor)
+	if err != nil {
+		return err
+	}
+	if student.ID != ref.StudentID && student.ID != studentID {
+		return ErrNotOwner
+	}
+	if studentID == ref.StudentID {
+		return ErrLeaderCannotLeave
+	}
+	if st := s.workflow.State(ref.Status); st == nil || !st.Editable {
+		return ErrNotEditable
+	}
+	m, err := s.memberRepo.Get(ctx, ref.ID, studentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotMember
+		}
+		return err
+	}
+	if err := s.memberRepo.Delete(ctx, ref.ID, studentID); err != nil {
+		return err
+	}
+	s.writeMemberLog(ctx, actor, ref.ID, "member_removed", m, nil)
+	return nil
+}
+
+// ListInvitations returns the calling student's pending invitations.
+func (s *AchievementService) ListInvitations(ctx context.Context, actor Actor) ([]*AchievementInvitation, error) {
+	student, err := s.actorStudent(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.memberRepo.ListByStudent(ctx, student.ID, pgModel.MemberInvited)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, m.ReferenceID)
+	}
+	byID, err := s.achievementRefPG.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]*pgModel.AchievementReference, 0, len(byID))
+	for _, ref := range byID {
+		refs = append(refs, ref)
+	}
+	docs, err := s.loadDocuments(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []*AchievementInvitation{}
+	for _, m := range members {
+		ref, ok := byID[m.ReferenceID]
+		if !ok {
+			continue // removed after the member row was read
+		}
+		inv := &AchievementInvitation{AchievementMember: m, LeaderID: ref.StudentID, Status: ref.Status}
+		if d, ok := docs[ref.MongoAchievementID]; ok {
+			inv.Title, inv.Category = d.Title, d.Category
+		}
+		out = append(out, inv)
+	}
+	return out, nil
+}
+
+// checkMembersAccepted is the members_accepted guard: no invitation may still be open
+func (s *AchievementService) checkMembersAccepted(ctx context.Context, ref *pgModel.AchievementReference) error {
+	members, err := s.memberRepo.ListByReference(ctx, ref.ID)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		if m.Status == pgModel.MemberInvited {
+			return ErrPendingInvitations
+		}
+	}
+	return nil
+}
+
+// editableTeam loads a reference whose team the actor (its leader) may change now
+func (s *AchievementService) editableTeam(ctx context.Context, actor Actor, refID string) (*pgModel.AchievementReference, error) {
+	ref, err := s.loadVisibleRef(ctx, actor, refID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkGuard(ctx, &transitionRun{ref: ref, actor: actor}, GuardOwner); err != nil {
+		return nil, err
+	}
+	if st := s.workflow.State(ref.Status); st == nil || !st.Editable {
+		return nil, ErrNotEditable
+	}
+	return ref, nil
+}
+
+// actorStudent returns the student profile of the actor
+func (s *AchievementService) actorStudent(ctx context.Context, actor Actor) (*pgModel.Student, error) {
+	student, err := s.studentRepo.GetByUserID(ctx, actor.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrStudentOnly
+		}
+		return nil, err
+	}
+	if student == nil {
+		return nil, ErrStudentOnly
+	}
+	return student, nil
+}
+
+func (s *AchievementService) writeMemberLog(ctx context.Context, actor Actor, refID string, event string, before, after *pgModel.AchievementMember) {
+	member := func(m *pgModel.AchievementMember) map[string]interface{} {
+		if m == nil {
+			return nil
+		}
+		return map[string]interface{}{"student_id": m.StudentID, "role": m.Role, "status": m.Status}
+	}
+	s.writeActivityLog(ctx, &pgModel.ActivityLog{
+		ID:         uuid.New().String(),
+		EntityType: "achievement_reference",
+		EntityID:   refID,
+		EventType:  event,
+		ActorID:    &actor.UserID,
+		Previous:   member(before),
+		Current:    member(after),
+		CreatedAt:  time.Now(),
+	})
+}
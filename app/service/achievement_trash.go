@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	pgModel "clean-arch/app/model/postgre"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// trashedAchievements lists deleted achievements as trash items labelled with their titles.
+func (s *AchievementService) trashedAchievements(ctx context.Context, before *time.Time) ([]*pgModel.TrashItem, error) {
+	refs, err := s.achievementRefPG.ListTrashed(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := s.loadDocuments(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*pgModel.TrashItem, 0, len(refs))
+	for _, ref := range refs {
+		item := &pgModel.TrashItem{
+			Entity:    pgModel.TrashAchievements,
+			ID:        ref.ID,
+			OwnerID:   &ref.StudentID,
+			DeletedAt: ref.UpdatedAt,
+			DeletedBy: ref.DeletedBy,
+		}
+		if ref.DeletedAt != nil {
+			item.DeletedAt = *ref.DeletedAt
+		}
+		if d, ok := docs[ref.MongoAchievementID]; ok {
+			item.Label = d.Title
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// trashedRef loads a reference that is in the trash (ErrNotFound otherwise)
+func (s *AchievementService) trashedRef(ctx context.Context, refID string) (*pgModel.AchievementReference, error) {
+	ref, err := s.achievementRefPG.GetByID(ctx, refID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if ref.Status != "deleted" {
+		return nil, ErrNotFound
+	}
+	return ref, nil
+}
+
+// restoreFromTrash puts a deleted achievement back in the status it was deleted from and
+// brings its document back through the outbox.
+func (s *AchievementService) restoreFromTrash(ctx context.Context, refID string) (*pgModel.AchievementReference, error) {
+	ref, err := s.trashedRef(ctx, refID)
+	if err != nil {
+		return nil, err
+	}
+	status := s.workflow.InitialState
+	if ref.DeletedFrom != nil && s.workflow.State(*ref.DeletedFrom) != nil {
+		status = *ref.DeletedFrom
+	}
+	oid, err := primitive.ObjectIDFromHex(ref.MongoAchievementID)
+	if err != nil {
+		return nil, errors.New("invalid mongo object id")
+	}
+	msg, err := newOutboxMessage(ref.ID, pgModel.OutboxRestoreDocument, map[string]interface{}{"mongoId": oid})
+	if err != nil {
+		return nil, err
+	}
+	ref.Status = status
+	ref.DeletedAt, ref.DeletedBy, ref.DeletedFrom = nil, nil, nil
+	if err := s.writeWithOutbox(ctx, func(tx *sql.Tx) error {
+		return s.achievementRefPG.WithTx(tx).Update(ctx, ref)
+	}, msg); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// purgeAchievements deletes refs for good, together with fn's changes (may be nil), in one
+// transaction. Their documents, versions and attachment files follow through the outbox.
+func (s *AchievementService) purgeAchievements(ctx context.Context, refs []*pgModel.AchievementReference, fn func(tx *sql.Tx) error) error {
+	msgs := make([]*pgModel.OutboxMessage, 0, len(refs))
+	for _, ref := range refs {
+		oid, err := primitive.ObjectIDFromHex(ref.MongoAchievementID)
+		if err != nil {
+			return errors.New("invalid mongo object id")
+		}
+		msg, err := newOutboxMessage(ref.ID, pgModel.OutboxPurgeDocument, map[string]interface{}{"mongoId": oid})
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+	return s.writeWithOutbox(ctx, func(tx *sql.Tx) error {
+		repo := s.achievementRefPG.WithTx(tx)
+		for _, ref := range refs {
+			if err := repo.Delete(ctx, ref.ID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+		if fn == nil {
+			return nil
+		}
+		return fn(tx)
+	}, msgs...)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	mongoModel "clean-arch/app/model/mongo"
+	pgModel "clean-arch/app/model/postgre"
+)
+
+var (
+	ErrVersionNotFound = &CustomError{"version_not_found", "achievement version not found", 404}
+	ErrCurrentVersion  = &CustomError{"current_version", "this is already the current version", 409}
+)
+
+// AchievementVersionInfo summarizes one version of an achievement document.
+// Version 0 is the document as created; every update adds one.
+type AchievementVersionInfo struct {
+	Version      int        `json:"version"`
+	Current      bool       `json:"current"`
+	Title        string     `json:"title"`
+	Category     string     `json:"category"`
+	ValidFrom    time.Time  `json:"valid_from"`
+	SupersededAt *time.Time `json:"superseded_at,omitempty"`
+}
+
+// AchievementVersionDiff lists the fields that changed from one version to another.
+type AchievementVersionDiff struct {
+	From    int                   `json:"from"`
+	To      int                   `json:"to"`
+	Changes []pgModel.FieldChange `json:"changes"`
+}
+
+// ListVersions returns every version of the achievement visible to the actor, oldest first.
+func (s *AchievementService) ListVersions(ctx context.Context, actor Actor, refID string) ([]*AchievementVersionInfo, error) {
+	ref, err := s.loadVisibleRef(ctx, actor, refID)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := s.documentOf(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.achievementMongo.ListVersions(ctx, doc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*AchievementVersionInfo, 0, len(versions)+1)
+	for _, v := range versions {
+		superseded := v.SupersededAt
+		out = append(out, &AchievementVersionInfo{
+			Version: v.Version, Title: v.Document.Title, Category: v.Document.Category,
+			ValidFrom: v.Document.UpdatedAt, SupersededAt: &superseded,
+		})
+	}
+	out = append(out, &AchievementVersionInfo{
+		Version: doc.Version, Current: true, Title: doc.Title, Category: doc.Category, ValidFrom: doc.UpdatedAt,
+	})
+	return out, nil
+}
+
+// GetVersion returns the achievement document as it was at the given version.
+func (s *AchievementService) GetVersion(ctx context.Context, actor Actor, refID string, version int) (*mongoModel.Achievement, error) {
+	ref, err := s.loadVisibleRef(ctx, actor, refID)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := s.documentOf(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return s.documentAtVersion(ctx, doc, version)
+}
+
+// DiffVersions compares two versions field by field; to < 0 means the current version.
+func (s *AchievementService) DiffVersions(ctx context.Context, actor Actor, refID string, from, to int) (*AchievementVersionDiff, error) {
+	ref, err := s.loadVisibleRef(ctx, actor, refID)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := s.documentOf(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if to < 0 {
+		to = doc.Version
+	}
+	before, err := s.documentAtVersion(ctx, doc, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.documentAtVersion(ctx, doc, to)
+	if err != nil {
+		return nil, err
+	}
+	return &AchievementVersionDiff{
+		From:    from,
+		To:      to,
+		Changes: diffSnapshots(achievementSnapshot(before), achievementSnapshot(after)),
+	}, nil
+}
+
+// RestoreVersion puts the content of an earlier version back into the owner's editable
+// achievement as a new version. Attachments are left as they are: they have their own endpoints.
+func (s *AchievementService) RestoreVersion(ctx context.Context, actor Actor, refID string, version int) error {
+	ref, doc, err := s.editableDocument(ctx, actor, refID)
+	if err != nil {
+		return err
+	}
+	if version == doc.Version {
+		return ErrCurrentVersion
+	}
+	old, err := s.documentAtVersion(ctx, doc, version)
+	if err != nil {
+		return err
+	}
+
+	snapshot := achievementSnapshot(old) // plain JSON values, as if sent by the client
+	updates := map[string]interface{}{}
+	for _, k := range []string{"title", "type", "category", "level", "details", "tags"} {
+		updates[k] = snapshot[k]
+	}
+	if err := checkDocumentUpdates(updates); err != nil {
+		return err
+	}
+	return s.applyDocumentUpdates(ctx, ref, doc, actor.UserID, updates, "restored",
+		map[string]interface{}{"restored_version": version})
+}
+
+// documentAtVersion returns doc itself for its current version, otherwise the stored earlier state
+func (s *AchievementService) documentAtVersion(ctx context.Context, doc *mongoModel.Achievement, version int) (*mongoModel.Achievement, error) {
+	if version == doc.Version {
+		return doc, nil
+	}
+	if version < 0 || version > doc.Version {
+		return nil, ErrVersionNotFound
+	}
+	v, err := s.achievementMongo.GetVersion(ctx, doc.ID, version)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrVersionNotFound
+	}
+	return &v.Document, nil
+}
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	pgModel "clean-arch/app/model/postgre"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PermissionChecker reports whether a role holds a permission (RBACService.HasPermissionByRoleID).
+type PermissionChecker func(ctx context.Context, roleID string, permission string) (bool, error)
+
+var (
+	ErrUnknownAction     = &CustomError{"unknown_action", "unknown workflow action", 404}
+	ErrInvalidTransition = &CustomError{"invalid_transition", "this action is not allowed from the current status", 409}
+	ErrNotOwner          = &CustomError{"not_owner", "only the owner of the achievement can do this", 403}
+	ErrNoteRequired      = &CustomError{"note_required", "a note is required for this action", 400}
+)
+
+// TransitionInput carries request data some guards and effects need.
+type TransitionInput struct {
+	Note           string `json:"note"`
+	OverrideReason string `json:"override_reason"` // why a likely duplicate is submitted anyway
+	IfMatch        *int   `json:"-"`               // expected reference version (If-Match header); nil skips the check
+	BatchID        string `json:"-"`               // set for items of a bulk request, recorded in the activity log
+}
+
+// AllowedAction is one action the caller can take on an achievement right now.
+type AllowedAction struct {
+	Action   string   `json:"action"`
+	Label    string   `json:"label"`
+	To       string   `json:"to"`
+	Requires []string `json:"requires,omitempty"` // request fields the action needs, e.g. note
+}
+
+// transitionRun is the state shared by guards and effects of one transition
+type transitionRun struct {
+	t            *WorkflowTransition
+	ref          *pgModel.AchievementReference
+	from         string
+	actor        Actor
+	input        TransitionInput
+	now          time.Time
+	grant        *verificationGrant
+	round        *pgModel.ReviewRound
+	resubmission bool
+	duplicates   []pgModel.DuplicateMatch // likely duplicates the student overrode
+	outbox       []*pgModel.OutboxMessage // Mongo writes stored with the reference change
+	current      map[string]interface{}
+	metadata     map[string]interface{}
+}
+
+// loadVisibleRef fetches the reference and checks the actor may see it
+func (s *AchievementService) loadVisibleRef(ctx context.Context, actor Actor, refID string) (*pgModel.AchievementReference, error) {
+	ref, err := s.achievementRefPG.GetByID(ctx, refID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	allowed, err := s.policy.CanViewAchievement(ctx, actor, ref)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+	return ref, nil
+}
+
+// Transition applies a workflow action to an achievement on behalf of the actor.
+func (s *AchievementService) Transition(ctx context.Context, refID string, actor Actor, action string, input TransitionInput) (*pgModel.AchievementReference, error) {
+	ref, err := s.loadVisibleRef(ctx, actor, refID)
+	if err != nil {
+		return nil, err
+	}
+	if input.IfMatch != nil && *input.IfMatch != ref.Version {
+		return nil, ErrPreconditionFailed
+	}
+	t := s.workflow.Transition(action, ref.Status)
+	if t == nil {
+		if !s.workflow.HasAction(action) {
+			return nil, ErrUnknownAction
+		}
+		return nil, ErrInvalidTransition
+	}
+
+	run := &transitionRun{
+		t: t, ref: ref, from: ref.Status, actor: actor, input: input, now: time.Now(),
+		current:  map[string]interface{}{"status": t.To},
+		metadata: map[string]interface{}{"action": t.Action},
+	}
+	if input.BatchID != "" {
+		run.metadata["batch_id"] = input.BatchID
+	}
+	if err := s.checkPermission(ctx, run); err != nil {
+		return nil, err
+	}
+	for _, g := range t.Guards {
+		if err := s.checkGuard(ctx, run, g); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, e := range t.Effects {
+		if err := s.prepareEffect(ctx, run, e); err != nil {
+			return nil, err
+		}
+	}
+	ref.Status = t.To
+	if err := s.writeWithOutbox(ctx, func(tx *sql.Tx) error {
+		if err := s.achievementRefPG.WithTx(tx).Update(ctx, ref); err != nil {
+			return err
+		}
+		for _, e := range t.Effects {
+			if err := s.commitEffect(ctx, tx, run, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, run.outbox...); err != nil {
+		return nil, err
+	}
+
+	s.writeActivityLog(ctx, transitionLog(run))
+	return ref, nil
+}
+
+// AllowedActions lists the transitions the actor could take on the achievement now.
+// Guards that depend on request input (note_required, no_duplicates when duplicates exist) are
+// reported in Requires instead of evaluated.
+func (s *AchievementService) AllowedActions(ctx context.Context, actor Actor, refID string) ([]*AllowedAction, error) {
+	ref, err := s.loadVisibleRef(ctx, actor, refID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the duplicate search is the expensive part: run it at most once, and only for a
+	// transition that needs it and passed its other guards
+	var duplicates *bool
+	hasDuplicates := func() (bool, error) {
+		if duplicates == nil {
+			matches, err := s.findDuplicates(ctx, ref)
+			if err != nil {
+				return false, err
+			}
+			found := len(matches) > 0
+			duplicates = &found
+		}
+		return *duplicates, nil
+	}
+
+	out := []*AllowedAction{}
+	for _, t := range s.workflow.TransitionsFrom(ref.Status) {
+		run := &transitionRun{t: t, ref: ref, from: ref.Status, actor: actor, now: time.Now()}
+		if err := s.checkPermission(ctx, run); err != nil {
+			if isDenial(err) {
+				continue
+			}
+			return nil, err
+		}
+		item := &AllowedAction{Action: t.Action, Label: t.Label, To: t.To}
+		allowed, checkDuplicates := true, false
+		for _, g := range t.Guards {
+			if g == GuardNoteRequired {
+				item.Requires = append(item.Requires, "note")
+				continue
+			}
+			if g == GuardNoDuplicates {
+				checkDuplicates = true
+				continue
+			}
+			if err := s.checkGuard(ctx, run, g); err != nil {
+				if isDenial(err) {
+					allowed = false
+					break
+				}
+				return nil, err
+			}
+		}
+		if !allowed {
+			continue
+		}
+		if checkDuplicates {
+			found, err := hasDuplicates()
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				item.Requires = append(item.Requires, "override_reason")
+			}
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// isDenial separates "guard said no" from infrastructure errors
+func isDenial(err error) bool {
+	var ce *CustomError
+	return errors.As(err, &ce)
+}
+
+func (s *AchievementService) checkPermission(ctx context.Context, run *transitionRun) error {
+	if run.t.Permission == "" || s.permissions == nil {
+		return nil
+	}
+	// any of the actor's roles may hold the permission
+	for _, roleID := range run.actor.Roles() {
+		ok, err := s.permissions(ctx, roleID, run.t.Permission)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+func (s *AchievementService) checkGuard(ctx context.Context, run *transitionRun, guard string) error {
+	switch guard {
+	case GuardOwner:
+		student, err := s.studentRepo.GetByUserID(ctx, run.actor.UserID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotOwner
+			}
+			return err
+		}
+		if student == nil || student.ID != run.ref.StudentID {
+			return ErrNotOwner
+		}
+	case GuardReviewer:
+		grant, err := s.authorizeReviewer(ctx, run.actor, run.ref)
+		if err != nil {
+			return err
+		}
+		run.grant = grant
+	case GuardAdmin:
+		admin, err := s.policy.IsAdmin(ctx, run.actor)
+		if err != nil {
+			return err
+		}
+		if !admin {
+			return ErrForbidden
+		}
+	case GuardNoteRequired:
+		if run.input.Note == "" {
+			return ErrNoteRequired
+		}
+	case GuardMembersAccepted:
+		return s.checkMembersAccepted(ctx, run.ref)
+	case GuardNoDuplicates:
+		return s.checkNoDuplicates(ctx, run)
+	}
+	return nil
+}
+
+// prepareEffect changes the reference before it is saved; Mongo writes are queued on run.outbox
+func (s *AchievementService) prepareEffect(ctx context.Context, run *transitionRun, effect string) error {
+	ref := run.ref
+	switch effect {
+	case EffectOpenReviewRound:
+		oid, err := primitive.ObjectIDFromHex(ref.MongoAchievementID)
+		if err != nil {
+			return errors.New("invalid mongo object id")
+		}
+		doc, err := s.achievementMongo.GetByID(ctx, oid)
+		if err != nil {
+			return err
+		}
+		if doc == nil {
+			return errors.New("achievement document not found")
+		}
+		last, err := s.reviewRoundRepo.GetLatest(ctx, ref.ID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if ref.Revision == 0 {
+			ref.Revision = 1
+		}
+		snapshot := achievementSnapshot(doc)
+		switch {
+		case last == nil:
+			run.round = &pgModel.ReviewRound{Round: ref.Revision, Snapshot: snapshot}
+		case last.Decision != nil:
+			// a decided round comes back as the next one; diff it against that round
+			ref.Revision++
+			run.resubmission = true
+			run.round = &pgModel.ReviewRound{Round: ref.Revision, Snapshot: snapshot,
+				Changes: diffSnapshots(last.Snapshot, snapshot)}
+			run.current["changes"] = run.round.Changes
+		}
+		// the rejection note stays on the previous round
+		ref.SubmittedAt = &run.now
+		ref.RejectionNote = nil
+		run.current["submitted_at"] = run.now
+		run.current["revision"] = ref.Revision
+	case EffectMarkVerified:
+		ref.VerifiedBy = &run.actor.UserID
+		ref.VerifiedAt = &run.now
+		run.current["verified_at"] = run.now
+		run.current["verified_by"] = run.actor.UserID
+		run.current["revision"] = ref.Revision
+	case EffectAwardPoints:
+		if s.scoring == nil {
+			return nil
+		}
+		doc, err := s.documentOf(ctx, ref)
+		if err != nil {
+			return err
+		}
+		score, err := s.scoring.Score(ctx, doc, run.now)
+		if err != nil {
+			return err
+		}
+		score.applyTo(ref)
+		run.current["points"] = score.Points
+		run.current["points_semester"] = score.Semester
+		if score.RuleID != nil {
+			run.metadata["scoring_rule_id"] = *score.RuleID
+		}
+	case EffectRecordRejection:
+		note := run.input.Note
+		ref.RejectionNote = &note
+		run.current["rejection_note"] = note
+		run.current["rejected_at"] = run.now
+		run.current["revision"] = ref.Revision
+	case EffectSoftDeleteDocument:
+		oid, err := primitive.ObjectIDFromHex(ref.MongoAchievementID)
+		if err != nil {
+			return errors.New("invalid mongo object id")
+		}
+		msg, err := newOutboxMessage(ref.ID, pgModel.OutboxSoftDeleteDocument, map[string]interface{}{"mongoId": oid})
+		if err != nil {
+			return err
+		}
+		run.outbox = append(run.outbox, msg)
+		// the trash restores the achievement to the status it was deleted from
+		now, by, from := run.now, run.actor.UserID, run.from
+		ref.DeletedAt, ref.DeletedBy, ref.DeletedFrom = &now, &by, &from
+		run.current["deleted_at"] = run.now
+	}
+	return nil
+}
+
+// commitEffect writes the rows of an effect in tx, the transaction that saves the reference,
+// so a failure leaves the status unchanged and the action can be retried
+func (s *AchievementService) commitEffect(ctx context.Context, tx *sql.Tx, run *transitionRun, effect string) error {
+	switch effect {
+	case EffectOpenReviewRound:
+		if run.round == nil {
+			return nil
+		}
+		run.round.ID = uuid.New().String()
+		run.round.ReferenceID = run.ref.ID
+		run.round.SubmittedBy = &run.actor.UserID
+		run.round.SubmittedAt = run.now
+		if len(run.duplicates) > 0 {
+			reason := strings.TrimSpace(run.input.OverrideReason)
+			run.round.Duplicates = run.duplicates
+			run.round.DuplicateOverride = &reason
+		}
+		if err := s.reviewRoundRepo.WithTx(tx).Create(ctx, run.round); err != nil {
+			return err
+		}
+		run.metadata["round_id"] = run.round.ID
+	case EffectCloseReviewRound:
+		rounds := s.reviewRoundRepo.WithTx(tx)
+		// references submitted before review rounds existed have none, which is not an error
+		round, err := rounds.GetLatest(ctx, run.ref.ID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+		var note *string
+		if run.input.Note != "" {
+			note = &run.input.Note
+		}
+		if err := rounds.Decide(ctx, round.ID, run.t.To, run.actor.UserID, note); err != nil {
+			return err
+		}
+		run.metadata["round_id"] = round.ID
+	}
+	return nil
+}
+
+// transitionLog builds the activity log of a transition; resubmissions and admin overrides get their own event type
+func transitionLog(run *transitionRun) *pgModel.ActivityLog {
+	eventType := run.t.Event
+	if eventType == "" {
+		eventType = "status_changed"
+	}
+	if run.resubmission {
+		eventType = "resubmitted"
+	}
+	var role *string
+	if run.grant != nil {
+		r := RoleLecturer
+		if run.grant.Authority == AuthorityAdminOverride {
+			eventType = "admin_override"
+			r = RoleAdmin
+		}
+		role = &r
+		run.metadata["authority"] = run.grant.Authority
+		if run.grant.DelegationID != "" {
+			run.metadata["delegation_id"] = run.grant.DelegationID
+		}
+	}
+	actorID := run.actor.UserID
+	return &pgModel.ActivityLog{
+		ID:         uuid.New().String(),
+		EntityType: "achievement_reference",
+		EntityID:   run.ref.ID,
+		EventType:  eventType,
+		ActorID:    &actorID,
+		ActorRole:  role,
+		Previous:   map[string]interface{}{"status": run.from},
+		Current:    run.current,
+		Metadata:   run.metadata,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// WorkflowPermissions maps each permission required by a workflow transition to those transitions.
+func (s *AchievementService) WorkflowPermissions() map[string][]string {
+	return s.workflow.Permissions()
+}
@@ -2,7 +2,13 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"log"
 	"os"
 	"time"
 
@@ -10,34 +16,84 @@ import (
 	pgRepo "clean-arch/app/repository/postgre"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // Definisikan interface untuk Token Repository di sini (atau import dari domain layer)
 // Implementasinya nanti bisa menggunakan Redis (disarankan) atau Database SQL
+// Yang disimpan adalah JWT ID (klaim jti), bukan string token lengkap.
 type TokenRepository interface {
-	AddToBlacklist(ctx context.Context, token string, expiresAt time.Time) error
-	IsBlacklisted(ctx context.Context, token string) (bool, error)
+	AddToBlacklist(ctx context.Context, jti string, expiresAt time.Time) error
+	IsBlacklisted(ctx context.Context, jti string) (bool, error)
+	ListActive(ctx context.Context) (map[string]time.Time, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrUserInactive        = errors.New("user is inactive")
+	ErrTokenRevoked        = errors.New("token has been invalidated (logged out)")
+)
+
+// ClientInfo identifies the device a session belongs to.
+type ClientInfo struct {
+	DeviceName string
+	UserAgent  string
+	IP         string
+}
+
+// TokenPair is returned by Login and Refresh.
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int64     `json:"expires_in"` // seconds
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	SessionID        string    `json:"session_id"`
 }
 
 type AuthService struct {
-	userRepo  pgRepo.UserRepository
-	tokenRepo TokenRepository // Tambahkan dependency ini
-	jwtSecret string
+	userRepo        pgRepo.UserRepository
+	tokenRepo       TokenRepository // Tambahkan dependency ini
+	sessionRepo     pgRepo.SessionRepository
+	jwtSecret       string
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+
+	revoked    *revocationCache
+	userStates *userStateCache
 }
 
 // Update constructor untuk menerima tokenRepo
 // Note: Anda perlu mengupdate wiring di service_factory.go juga nantinya
-func NewAuthService(userRepo pgRepo.UserRepository, tokenRepo TokenRepository) *AuthService {
+func NewAuthService(userRepo pgRepo.UserRepository, tokenRepo TokenRepository, sessionRepo pgRepo.SessionRepository) *AuthService {
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
 		secret = "dev-secret"
 	}
 	return &AuthService{
-		userRepo:  userRepo,
-		tokenRepo: tokenRepo,
-		jwtSecret: secret,
+		userRepo:        userRepo,
+		tokenRepo:       tokenRepo,
+		sessionRepo:     sessionRepo,
+		jwtSecret:       secret,
+		accessTokenTTL:  durationFromEnv("ACCESS_TOKEN_TTL", 15*time.Minute),
+		refreshTokenTTL: durationFromEnv("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+		revoked:         newRevocationCache(),
+		userStates:      newUserStateCache(30 * time.Second),
+	}
+}
+
+// durationFromEnv parses values like "15m" or "168h", falling back on empty/invalid input.
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
 	}
+	return fallback
 }
 
 func (s *AuthService) HashPassword(password string) (string, error) {
@@ -49,45 +105,187 @@ func (s *AuthService) ComparePassword(hash, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-// Login authenticates and returns JWT token
-func (s *AuthService) Login(ctx context.Context, username, password string) (string, *pgModel.User, error) {
+// Login authenticates, opens a new session for the device and returns an access/refresh token pair
+func (s *AuthService) Login(ctx context.Context, username, password string, client ClientInfo) (*TokenPair, *pgModel.User, error) {
 	user, err := s.userRepo.GetByUsername(ctx, username)
 	if err != nil {
-		return "", nil, err
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, errors.New("invalid credentials")
+		}
+		return nil, nil, err
 	}
 	if user == nil {
-		return "", nil, errors.New("invalid credentials")
+		return nil, nil, errors.New("invalid credentials")
 	}
 	if err := s.ComparePassword(user.PasswordHash, password); err != nil {
-		return "", nil, errors.New("invalid credentials")
+		return nil, nil, errors.New("invalid credentials")
 	}
-	// create token
-	claims := jwt.MapClaims{
-		"sub":  user.ID,
-		"role": user.RoleID,
-		"exp":  time.Now().Add(24 * time.Hour).Unix(),
-		"iat":  time.Now().Unix(),
+	if !user.IsActive {
+		return nil, nil, ErrUserInactive
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	ss, err := token.SignedString([]byte(s.jwtSecret))
+
+	session := &pgModel.UserSession{
+		ID:         uuid.New().String(),
+		UserID:     user.ID,
+		DeviceName: client.DeviceName,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IP,
+		ExpiresAt:  time.Now().Add(s.refreshTokenTTL),
+	}
+	if err := s.sessionRepo.CreateSession(ctx, session); err != nil {
+		return nil, nil, err
+	}
+
+	pair, err := s.issueTokenPair(ctx, user, session)
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
-	return ss, user, nil
+	return pair, user, nil
 }
 
-func (s *AuthService) Refresh(ctx context.Context, userID string) (string, error) {
-	if userID == "" {
-		return "", errors.New("user_id is required")
+// Refresh rotates the presented refresh token. Presenting a token that was already
+// rotated revokes the whole session (token family), since it must have been stolen.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error) {
+	if refreshToken == "" {
+		return nil, errors.New("refresh_token is required")
+	}
+	stored, err := s.sessionRepo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	session, err := s.sessionRepo.GetSession(ctx, stored.SessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if session.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// reuse detection: token sudah pernah dirotasi -> cabut seluruh family
+	if stored.UsedAt != nil {
+		_ = s.sessionRepo.RevokeSession(ctx, session.ID, "reuse_detected")
+		return nil, ErrRefreshTokenReused
+	}
+	now := time.Now()
+	if now.After(stored.ExpiresAt) || now.After(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	ok, err := s.sessionRepo.MarkRefreshTokenUsed(ctx, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// concurrent use of the same token
+		_ = s.sessionRepo.RevokeSession(ctx, session.ID, "reuse_detected")
+		return nil, ErrRefreshTokenReused
+	}
+
+	// ambil user terbaru agar role & status aktif selalu up to date
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		_ = s.sessionRepo.RevokeSession(ctx, session.ID, "user_inactive")
+		return nil, ErrUserInactive
+	}
+	_ = s.sessionRepo.TouchSession(ctx, session.ID, client.IP)
+
+	return s.issueTokenPair(ctx, user, session)
+}
+
+// issueTokenPair signs an access token and stores a fresh refresh token for the session
+func (s *AuthService) issueTokenPair(ctx context.Context, user *pgModel.User, session *pgModel.UserSession) (*TokenPair, error) {
+	now := time.Now()
+	roles := user.RoleIDs
+	if len(roles) == 0 && user.RoleID != "" {
+		roles = []string{user.RoleID}
 	}
 	claims := jwt.MapClaims{
-		"sub": userID,
-		"exp": time.Now().Add(24 * time.Hour).Unix(),
-		"iat": time.Now().Unix(),
+		"sub":   user.ID,
+		"role":  user.RoleID, // primary role, for clients that read a single role
+		"roles": roles,
+		"sid":   session.ID,
+		"jti":   uuid.New().String(),
+		"exp":   now.Add(s.accessTokenTTL).Unix(),
+		"iat":   now.Unix(),
+		// iat has second precision; the force-logout cutoff is compared in milliseconds
+		"iat_ms": now.UnixMilli(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	ss, err := token.SignedString([]byte(s.jwtSecret))
-	return ss, err
+	access, err := token.SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	rt := &pgModel.RefreshToken{
+		ID:        uuid.New().String(),
+		SessionID: session.ID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: session.ExpiresAt,
+	}
+	if err := s.sessionRepo.CreateRefreshToken(ctx, rt); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     raw,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(s.accessTokenTTL.Seconds()),
+		RefreshExpiresAt: rt.ExpiresAt,
+		SessionID:        session.ID,
+	}, nil
+}
+
+// ListSessions returns active sessions (devices) of a user
+func (s *AuthService) ListSessions(ctx context.Context, userID string) ([]*pgModel.UserSession, error) {
+	return s.sessionRepo.ListActiveByUser(ctx, userID)
+}
+
+// RevokeSession revokes one of the user's own sessions
+func (s *AuthService) RevokeSession(ctx context.Context, userID string, sessionID string) error {
+	session, err := s.sessionRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return s.sessionRepo.RevokeSession(ctx, sessionID, "revoked")
+}
+
+// RevokeAllSessions logs the user out from every device
+func (s *AuthService) RevokeAllSessions(ctx context.Context, userID string) error {
+	return s.sessionRepo.RevokeAllByUser(ctx, userID, "revoked")
+}
+
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 // Logout memasukkan token ke dalam blacklist hingga masa berlakunya habis
@@ -122,41 +320,194 @@ func (s *AuthService) Logout(ctx context.Context, tokenString string) error {
 		return nil 
 	}
 
-	// 3. Simpan token ke blacklist repository
-	// Token akan disimpan di DB/Redis sampai waktu 'expiresAt' tercapai
-	return s.tokenRepo.AddToBlacklist(ctx, tokenString, expiresAt)
-}
-
-func (s *AuthService) VerifyToken(tokenString string) (map[string]interface{}, error) {
-	// 1. Cek apakah token ada di blacklist
-	if s.tokenRepo != nil {
-		isBlacklisted, err := s.tokenRepo.IsBlacklisted(context.Background(), tokenString)
-		if err != nil {
-			// Fail safe: jika DB error, anggap invalid atau log error
-			return nil, err 
-		}
-		if isBlacklisted {
-			return nil, errors.New("token has been invalidated (logged out)")
+	// 3. Tutup session (refresh token tidak bisa dipakai lagi)
+	if sid, ok := claims["sid"].(string); ok && sid != "" && s.sessionRepo != nil {
+		if err := s.sessionRepo.RevokeSession(ctx, sid, "logout"); err != nil {
+			return err
 		}
 	}
 
-	// 2. Standard JWT verification
+	// 4. Simpan jti ke blacklist repository (dan cache lokal)
+	// Token akan disimpan di DB/Redis sampai waktu 'expiresAt' tercapai
+	jti := tokenID(claims, tokenString)
+	if err := s.tokenRepo.AddToBlacklist(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+	s.revoked.Add(jti, expiresAt)
+	return nil
+}
+
+// VerifyToken validates signature & expiry, then rejects revoked tokens (jti blacklist),
+// inactive users and tokens issued before the user's force-logout cutoff.
+func (s *AuthService) VerifyToken(ctx context.Context, tokenString string) (map[string]interface{}, error) {
+	// 1. Standard JWT verification
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
 		return []byte(s.jwtSecret), nil
-	})
+	}, jwt.WithLeeway(5*time.Second))
 	if err != nil {
 		return nil, err
 	}
-
 	if !token.Valid {
 		return nil, errors.New("invalid token")
 	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		return claims, nil
+	// 2. Cek blacklist di cache in-process; database hanya ditanya jika cache belum
+	// tersinkron dalam revocationMaxStaleness
+	jti := tokenID(claims, tokenString)
+	if s.revoked.Contains(jti) {
+		return nil, ErrTokenRevoked
+	}
+	if s.tokenRepo != nil && !s.revoked.Fresh(revocationMaxStaleness) {
+		blacklisted, err := s.tokenRepo.IsBlacklisted(ctx, jti)
+		if err != nil {
+			return nil, err
+		}
+		if blacklisted {
+			if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+				s.revoked.Add(jti, exp.Time)
+			}
+			return nil, ErrTokenRevoked
+		}
 	}
-	return nil, errors.New("invalid token claims")
-}
\ No newline at end of file
+
+	// 3. Cek status akun & cutoff force-logout
+	userID, _ := claims["sub"].(string)
+	if userID == "" {
+		return nil, errors.New("invalid token claims")
+	}
+	state, err := s.userAuthState(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("user not found")
+		}
+		// Fail safe: jika DB error, anggap invalid
+		return nil, err
+	}
+	if !state.IsActive {
+		return nil, ErrUserInactive
+	}
+	if state.TokensValidAfter != nil {
+		if issuedBefore(claims, *state.TokensValidAfter) {
+			return nil, ErrTokenRevoked
+		}
+	}
+	return claims, nil
+}
+
+func (s *AuthService) userAuthState(ctx context.Context, userID string) (*pgModel.UserAuthState, error) {
+	if st, ok := s.userStates.Get(userID); ok {
+		return st, nil
+	}
+	st, err := s.userRepo.GetAuthState(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.userStates.Set(st)
+	return st, nil
+}
+
+// ForceLogout invalidates every access token issued so far and revokes all sessions of the user
+func (s *AuthService) ForceLogout(ctx context.Context, userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return ErrNotFound
+	}
+	if err := s.userRepo.SetTokensValidAfter(ctx, userID, time.Now()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	s.userStates.Invalidate(userID)
+	return s.sessionRepo.RevokeAllByUser(ctx, userID, "force_logout")
+}
+
+// RevokeAccessTokens invalidates every access token issued so far but keeps the sessions, so
+// the next refresh issues tokens with the user's current roles (e.g. after a role change)
+func (s *AuthService) RevokeAccessTokens(ctx context.Context, userID string) error {
+	if err := s.userRepo.SetTokensValidAfter(ctx, userID, time.Now()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	s.userStates.Invalidate(userID)
+	return nil
+}
+
+// InvalidateUser drops cached account state, e.g. after the user was deactivated
+func (s *AuthService) InvalidateUser(userID string) {
+	s.userStates.Invalidate(userID)
+}
+
+// StartBlacklistSweeper re-syncs the in-process revocation cache every
+// revocationSyncInterval and deletes expired rows from token_blacklist every
+// interval. It stops when ctx is cancelled.
+func (s *AuthService) StartBlacklistSweeper(ctx context.Context, interval time.Duration) {
+	if s.tokenRepo == nil {
+		return
+	}
+	resync := func() {
+		sctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		active, err := s.tokenRepo.ListActive(sctx)
+		if err != nil {
+			log.Printf("token blacklist sync failed: %v", err)
+			return
+		}
+		s.revoked.Replace(active)
+	}
+	sweep := func() {
+		sctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		if n, err := s.tokenRepo.DeleteExpired(sctx); err != nil {
+			log.Printf("token blacklist sweep failed: %v", err)
+		} else if n > 0 {
+			log.Printf("token blacklist sweep: removed %d expired entries", n)
+		}
+		s.revoked.Prune(time.Now())
+	}
+
+	go func() {
+		sweep()
+		resync()
+		syncTicker := time.NewTicker(revocationSyncInterval)
+		defer syncTicker.Stop()
+		sweepTicker := time.NewTicker(interval)
+		defer sweepTicker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-syncTicker.C:
+				resync()
+			case <-sweepTicker.C:
+				sweep()
+			}
+		}
+	}()
+}
+
+// issuedBefore reports whether the token was issued at or before the cutoff. Tokens
+// without iat_ms only carry whole seconds, so any token from the cutoff's second counts.
+func issuedBefore(claims jwt.MapClaims, cutoff time.Time) bool {
+	if ms, ok := claims["iat_ms"].(float64); ok {
+		return int64(ms) <= cutoff.UnixMilli()
+	}
+	iat, _ := claims["iat"].(float64)
+	return int64(iat) <= cutoff.Unix()
+}
+
+// tokenID returns the jti claim; tokens issued before jti existed fall back to a hash of the token
+func tokenID(claims jwt.MapClaims, tokenString string) string {
+	if jti, ok := claims["jti"].(string); ok && jti != "" {
+		return jti
+	}
+	return hashToken(tokenString)
+}
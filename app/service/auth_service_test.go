@@ -0,0 +1,31 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestIssuedBefore(t *testing.T) {
+	cutoff := time.Date(2026, 1, 2, 3, 4, 5, 500*int(time.Millisecond), time.UTC)
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   bool
+	}{
+		{"earlier in the cutoff's second", jwt.MapClaims{"iat": float64(cutoff.Unix()), "iat_ms": float64(cutoff.UnixMilli() - 200)}, true},
+		{"at the cutoff", jwt.MapClaims{"iat": float64(cutoff.Unix()), "iat_ms": float64(cutoff.UnixMilli())}, true},
+		{"later in the cutoff's second", jwt.MapClaims{"iat": float64(cutoff.Unix()), "iat_ms": float64(cutoff.UnixMilli() + 1)}, false},
+		{"seconds only, same second", jwt.MapClaims{"iat": float64(cutoff.Unix())}, true},
+		{"seconds only, next second", jwt.MapClaims{"iat": float64(cutoff.Unix() + 1)}, false},
+		{"no iat", jwt.MapClaims{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := issuedBefore(tt.claims, cutoff); got != tt.want {
+				t.Fatalf("issuedBefore(%v) = %v, want %v", tt.claims, got, tt.want)
+			}
+		})
+	}
+}
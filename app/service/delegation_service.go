@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	pgModel "clean-arch/app/model/postgre"
+	pgRepo "clean-arch/app/repository/postgre"
+
+	"github.com/google/uuid"
+)
+
+// DelegationService manages verification delegations between lecturers.
+type DelegationService struct {
+	repo         pgRepo.DelegationRepository
+	lecturerRepo pgRepo.LecturerRepository
+	activityRepo pgRepo.ActivityLogRepository
+	policy       *AccessPolicy
+}
+
+func NewDelegationService(
+	repo pgRepo.DelegationRepository,
+	lecturerRepo pgRepo.LecturerRepository,
+	activityRepo pgRepo.ActivityLogRepository,
+	policy *AccessPolicy,
+) *DelegationService {
+	return &DelegationService{
+		repo:         repo,
+		lecturerRepo: lecturerRepo,
+		activityRepo: activityRepo,
+		policy:       policy,
+	}
+}
+
+// DelegationRequest is the body of POST /lecturers/delegations.
+// AdvisorID may only be set by admins; lecturers always delegate their own advisees.
+type DelegationRequest struct {
+	AdvisorID  string    `json:"advisor_id,omitempty"`
+	DelegateID string    `json:"delegate_id"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	Reason     string    `json:"reason"`
+}
+
+// callerLecturer returns the lecturer profile of the actor, or nil if none.
+func (s *DelegationService) callerLecturer(ctx context.Context, actor Actor) (*pgModel.Lecturer, error) {
+	l, err := s.lecturerRepo.GetByUserID(ctx, actor.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return l, nil
+}
+
+func (s *DelegationService) Create(ctx context.Context, actor Actor, req DelegationRequest) (*pgModel.VerificationDelegation, error) {
+	if req.DelegateID == "" {
+		return nil, errors.New("delegate_id is required")
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, errors.New("ends_at must be after starts_at")
+	}
+
+	admin, err := s.policy.IsAdmin(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	advisorID := req.AdvisorID
+	if !admin {
+		lecturer, err := s.callerLecturer(ctx, actor)
+		if err != nil {
+			return nil, err
+		}
+		if lecturer == nil {
+			return nil, ErrForbidden
+		}
+		if advisorID != "" && advisorID != lecturer.ID {
+			return nil, ErrForbidden
+		}
+		advisorID = lecturer.ID
+	}
+	if advisorID == "" {
+		return nil, errors.New("advisor_id is required")
+	}
+	if advisorID == req.DelegateID {
+		return nil, errors.New("cannot delegate to yourself")
+	}
+	if _, err := s.lecturerRepo.GetByID(ctx, req.DelegateID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("delegate lecturer not found")
+		}
+		return nil, err
+	}
+
+	d := &pgModel.VerificationDelegation{
+		ID:         uuid.New().String(),
+		AdvisorID:  advisorID,
+		DelegateID: req.DelegateID,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+		Reason:     req.Reason,
+		CreatedBy:  &actor.UserID,
+	}
+	if err := s.repo.Create(ctx, d); err != nil {
+		return nil, err
+	}
+
+	if s.activityRepo != nil {
+		_ = s.activityRepo.Create(ctx, &pgModel.ActivityLog{
+			ID:         uuid.New().String(),
+			EntityType: "verification_delegation",
+			EntityID:   d.ID,
+			EventType:  "created",
+			ActorID:    &actor.UserID,
+			Current: map[string]interface{}{
+				"advisor_id":  d.AdvisorID,
+				"delegate_id": d.DelegateID,
+				"starts_at":   d.StartsAt,
+				"ends_at":     d.EndsAt,
+			},
+			CreatedAt: time.Now(),
+		})
+	}
+	return d, nil
+}
+
+// ListMine returns delegations given or received by the calling lecturer.
+func (s *DelegationService) ListMine(ctx context.Context, actor Actor) ([]*pgModel.VerificationDelegation, error) {
+	lecturer, err := s.callerLecturer(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	if lecturer == nil {
+		return []*pgModel.VerificationDelegation{}, nil
+	}
+	return s.repo.ListByLecturer(ctx, lecturer.ID)
+}
+
+// Revoke ends a delegation early. Only the delegating advisor or an admin may revoke.
+func (s *DelegationService) Revoke(ctx context.Context, actor Actor, id string) error {
+	d, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	admin, err := s.policy.IsAdmin(ctx, actor)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		lecturer, err := s.callerLecturer(ctx, actor)
+		if err != nil {
+			return err
+		}
+		if lecturer == nil || lecturer.ID != d.AdvisorID {
+			return ErrForbidden
+		}
+	}
+	if err := s.repo.Revoke(ctx, id); err != nil {
+		return err
+	}
+	if s.activityRepo != nil {
+		_ = s.activityRepo.Create(ctx, &pgModel.ActivityLog{
+			ID:         uuid.New().String(),
+			EntityType: "verification_delegation",
+			EntityID:   d.ID,
+			EventType:  "revoked",
+			ActorID:    &actor.UserID,
+			CreatedAt:  time.Now(),
+		})
+	}
+	return nil
+}
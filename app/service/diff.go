@@ -0,0 +1,62 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	mongoModel "clean-arch/app/model/mongo"
+	pgModel "clean-arch/app/model/postgre"
+)
+
+// achievementSnapshot returns the reviewable fields of a document as plain JSON values,
+// so a fresh snapshot compares equal to one read back from a jsonb column.
+func achievementSnapshot(doc *mongoModel.Achievement) map[string]interface{} {
+	b, _ := json.Marshal(map[string]interface{}{
+		"title":       doc.Title,
+		"type":        doc.Type,
+		"category":    doc.Category,
+		"level":       doc.Level,
+		"details":     doc.Details,
+		"tags":        doc.Tags,
+		"attachments": doc.Attachments,
+	})
+	out := map[string]interface{}{}
+	_ = json.Unmarshal(b, &out)
+	return out
+}
+
+// diffSnapshots lists the fields that differ between two snapshots. Nested objects are
+// walked and reported by dotted path (details.rank); arrays are compared as a whole.
+func diffSnapshots(before, after map[string]interface{}) []pgModel.FieldChange {
+	changes := []pgModel.FieldChange{}
+	diffInto(&changes, "", before, after)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func diffInto(changes *[]pgModel.FieldChange, prefix string, before, after map[string]interface{}) {
+	keys := map[string]struct{}{}
+	for k := range before {
+		keys[k] = struct{}{}
+	}
+	for k := range after {
+		keys[k] = struct{}{}
+	}
+	for k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		b, a := before[k], after[k]
+		bm, bIsMap := b.(map[string]interface{})
+		am, aIsMap := a.(map[string]interface{})
+		if bIsMap && aIsMap {
+			diffInto(changes, path, bm, am)
+			continue
+		}
+		if !reflect.DeepEqual(b, a) {
+			*changes = append(*changes, pgModel.FieldChange{Path: path, Before: b, After: a})
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A small JSON Schema (draft 7) subset for achievement details. Supported keywords:
+// type, properties, required, additionalProperties (bool), enum, minLength, maxLength,
+// pattern, format (date, date-time, email, uri), minimum, maximum, items, minItems, maxItems.
+// Annotations ($schema, $id, title, description, default, examples) are accepted and ignored.
+
+// SchemaViolation is one place where a value does not satisfy its schema.
+type SchemaViolation struct {
+	Path    string `json:"path"` // dotted, e.g. details.rank or details.authors.0
+	Message string `json:"message"`
+}
+
+var schemaAnnotations = map[string]bool{
+	"$schema": true, "$id": true, "title": true, "description": true, "default": true, "examples": true,
+}
+
+var schemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "integer": true, "number": true, "boolean": true, "null": true,
+}
+
+// checkSchema rejects schemas using keywords or shapes the validator does not understand,
+// so an admin cannot store a schema that silently validates nothing.
+func checkSchema(path string, schema map[string]interface{}) error {
+	for key, v := range schema {
+		if schemaAnnotations[key] {
+			continue
+		}
+		switch key {
+		case "type":
+			types, ok := schemaTypeList(v)
+			if !ok || len(types) == 0 {
+				return fmt.Errorf("%s.type: must be a type name or a list of type names", path)
+			}
+			for _, t := range types {
+				if !schemaTypes[t] {
+					return fmt.Errorf("%s.type: unknown type %q", path, t)
+				}
+			}
+		case "properties":
+			props, ok := v.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s.properties: must be an object", path)
+			}
+			for name, sub := range props {
+				sm, ok := sub.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("%s.properties.%s: must be a schema object", path, name)
+				}
+				if err := checkSchema(path+".properties."+name, sm); err != nil {
+					return err
+				}
+			}
+		case "items":
+			sm, ok := v.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s.items: must be a schema object", path)
+			}
+			if err := checkSchema(path+".items", sm); err != nil {
+				return err
+			}
+		case "required":
+			list, ok := v.([]interface{})
+			if !ok {
+				return fmt.Errorf("%s.required: must be a list of property names", path)
+			}
+			for _, name := range list {
+				if _, ok := name.(string); !ok {
+					return fmt.Errorf("%s.required: must be a list of property names", path)
+				}
+			}
+		case "additionalProperties":
+			if _, ok := v.(bool); !ok {
+				return fmt.Errorf("%s.additionalProperties: only true or false is supported", path)
+			}
+		case "enum":
+			if list, ok := v.([]interface{}); !ok || len(list) == 0 {
+				return fmt.Errorf("%s.enum: must be a non-empty list", path)
+			}
+		case "minLength", "maxLength", "minItems", "maxItems":
+			if n, ok := v.(float64); !ok || n < 0 || n != math.Trunc(n) {
+				return fmt.Errorf("%s.%s: must be a non-negative integer", path, key)
+			}
+		case "minimum", "maximum":
+			if _, ok := v.(float64); !ok {
+				return fmt.Errorf("%s.%s: must be a number", path, key)
+			}
+		case "pattern":
+			s, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("%s.pattern: must be a string", path)
+			}
+			if _, err := regexp.Compile(s); err != nil {
+				return fmt.Errorf("%s.pattern: %v", path, err)
+			}
+		case "format":
+			switch v {
+			case "date", "date-time", "email", "uri":
+			default:
+				return fmt.Errorf("%s.format: unsupported format %v", path, v)
+			}
+		default:
+			return fmt.Errorf("%s: unsupported keyword %q", path, key)
+		}
+	}
+	return nil
+}
+
+func schemaTypeList(v interface{}) ([]string, bool) {
+	switch t := v.(type) {
+	case string:
+		return []string{t}, true
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, item := range t {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// validateSchema checks value (plain JSON values, see plainJSON) against a schema that
+// passed checkSchema. Violations are sorted by path.
+func validateSchema(path string, schema map[string]interface{}, value interface{}) []SchemaViolation {
+	out := []SchemaViolation{}
+	validateInto(&out, path, schema, value)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+func validateInto(out *[]SchemaViolation, path string, schema map[string]interface{}, value interface{}) {
+	fail := func(format string, args ...interface{}) {
+		*out = append(*out, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if v, ok := schema["type"]; ok {
+		types, _ := schemaTypeList(v)
+		matched := false
+		for _, t := range types {
+			if jsonTypeMatches(t, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("must be of type %s", strings.Join(types, " or "))
+			return // other keywords would only repeat the type error
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("must be one of %s", jsonText(enum))
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		n := float64(len([]rune(v)))
+		if min, ok := schema["minLength"].(float64); ok && n < min {
+			fail("must be at least %d characters", int(min))
+		}
+		if max, ok := schema["maxLength"].(float64); ok && n > max {
+			fail("must be at most %d characters", int(max))
+		}
+		if p, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(p); err == nil && !re.MatchString(v) {
+				fail("must match pattern %s", p)
+			}
+		}
+		if f, ok := schema["format"].(string); ok && !formatMatches(f, v) {
+			fail("must be a valid %s", f)
+		}
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && v < min {
+			fail("must be >= %v", min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && v > max {
+			fail("must be <= %v", max)
+		}
+	case []interface{}:
+		if min, ok := schema["minItems"].(float64); ok && float64(len(v)) < min {
+			fail("must have at least %d items", int(min))
+		}
+		if max, ok := schema["maxItems"].(float64); ok && float64(len(v)) > max {
+			fail("must have at most %d items", int(max))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateInto(out, fmt.Sprintf("%s.%d", path, i), items, item)
+			}
+		}
+	case map[string]interface{}:
+		props, _ := schema["properties"].(map[string]interface{})
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, present := v[name]; !present {
+					*out = append(*out, SchemaViolation{Path: path + "." + name, Message: "is required"})
+				}
+			}
+		}
+		for name, item := range v {
+			sub, ok := props[name].(map[string]interface{})
+			if !ok {
+				if extra, set := schema["additionalProperties"].(bool); set && !extra {
+					*out = append(*out, SchemaViolation{Path: path + "." + name, Message: "is not allowed"})
+				}
+				continue
+			}
+			validateInto(out, path+"."+name, sub, item)
+		}
+	}
+}
+
+func jsonTypeMatches(t string, value interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+func formatMatches(format string, s string) bool {
+	switch format {
+	case "date":
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	case "email":
+		a, err := mail.ParseAddress(s)
+		return err == nil && a.Address == s
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	}
+	return true
+}
+
+func jsonEqual(a, b interface{}) bool {
+	return jsonText(a) == jsonText(b)
+}
+
+func jsonText(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// plainJSON converts decoded request or BSON values to what encoding/json produces
+// (map[string]interface{}, []interface{}, float64, ...), which validateSchema expects.
+func plainJSON(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// certificationSchema mirrors the sertifikasi schema seeded by migrations 0010 and 0025
+const certificationSchema = `{
+	"type": "object",
+	"required": ["issuer", "number", "expiry"],
+	"additionalProperties": false,
+	"properties": {
+		"issuer":   {"type": "string", "minLength": 1, "maxLength": 200},
+		"number":   {"type": "string", "minLength": 1, "maxLength": 100},
+		"issuedAt": {"type": "string", "format": "date"},
+		"expiry":   {"type": "string", "format": "date"}
+	}
+}`
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+	return v
+}
+
+func TestCheckSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string // substring of the error; empty means valid
+	}{
+		{"seeded certification schema", certificationSchema, ""},
+		{"annotations are ignored", `{"$schema": "x", "title": "t", "description": "d", "type": "string"}`, ""},
+		{"type list", `{"type": ["string", "null"]}`, ""},
+		{"unknown type", `{"type": "date"}`, `schema.type: unknown type "date"`},
+		{"empty type list", `{"type": []}`, "schema.type: must be a type name"},
+		{"unsupported keyword", `{"oneOf": []}`, `unsupported keyword "oneOf"`},
+		{"properties not an object", `{"properties": []}`, "schema.properties: must be an object"},
+		{"nested property error has a path", `{"properties": {"rank": {"type": "int"}}}`, "schema.properties.rank.type"},
+		{"items must be a schema", `{"items": "string"}`, "schema.items: must be a schema object"},
+		{"required must be names", `{"required": [1]}`, "schema.required"},
+		{"additionalProperties schema", `{"additionalProperties": {"type": "string"}}`, "only true or false"},
+		{"empty enum", `{"enum": []}`, "schema.enum: must be a non-empty list"},
+		{"fractional minLength", `{"minLength": 1.5}`, "schema.minLength: must be a non-negative integer"},
+		{"negative maxItems", `{"maxItems": -1}`, "schema.maxItems"},
+		{"minimum must be a number", `{"minimum": "1"}`, "schema.minimum: must be a number"},
+		{"invalid pattern", `{"pattern": "("}`, "schema.pattern"},
+		{"unsupported format", `{"format": "uuid"}`, "unsupported format uuid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := decodeJSON(t, tt.schema).(map[string]interface{})
+			err := checkSchema("schema", schema)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("expected error containing %q", tt.wantErr)
+			case tt.wantErr != "" && !strings.Contains(err.Error(), tt.wantErr):
+				t.Fatalf("error %q does not contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		value  string
+		want   []SchemaViolation
+	}{
+		{
+			name:   "valid certification",
+			schema: certificationSchema,
+			value:  `{"issuer": "BNSP", "number": "A-1", "expiry": "2027-01-31"}`,
+		},
+		{
+			name:   "certification without expiry",
+			schema: certificationSchema,
+			value:  `{"issuer": "BNSP", "number": "A-1"}`,
+			want:   []SchemaViolation{{"details.expiry", "is required"}},
+		},
+		{
+			name:   "unknown property and bad date",
+			schema: certificationSchema,
+			value:  `{"issuer": "BNSP", "number": "A-1", "expiry": "31-01-2027", "studentId": "x"}`,
+			want: []SchemaViolation{
+				{"details.expiry", "must be a valid date"},
+				{"details.studentId", "is not allowed"},
+			},
+		},
+		{
+			name:   "wrong type stops further checks",
+			schema: `{"type": "object", "properties": {"rank": {"type": "integer", "minimum": 1}}}`,
+			value:  `{"rank": "first"}`,
+			want:   []SchemaViolation{{"details.rank", "must be of type integer"}},
+		},
+		{
+			name:   "integer rejects fractions",
+			schema: `{"type": "integer"}`,
+			value:  `1.5`,
+			want:   []SchemaViolation{{"details", "must be of type integer"}},
+		},
+		{
+			name:   "number bounds",
+			schema: `{"type": "number", "minimum": 1900, "maximum": 2100}`,
+			value:  `1800`,
+			want:   []SchemaViolation{{"details", "must be >= 1900"}},
+		},
+		{
+			name:   "string length counts runes",
+			schema: `{"type": "string", "maxLength": 2}`,
+			value:  `"éé"`,
+		},
+		{
+			name:   "pattern",
+			schema: `{"type": "string", "pattern": "^10\\.\\d{4,9}/\\S+$"}`,
+			value:  `"doi:123"`,
+			want:   []SchemaViolation{{"details", `must match pattern ^10\.\d{4,9}/\S+$`}},
+		},
+		{
+			name:   "enum",
+			schema: `{"enum": ["national", "international"]}`,
+			value:  `"regional"`,
+			want:   []SchemaViolation{{"details", `must be one of ["national","international"]`}},
+		},
+		{
+			name:   "array items are validated with their index",
+			schema: `{"type": "array", "minItems": 1, "items": {"type": "string", "minLength": 1}}`,
+			value:  `["Ana", ""]`,
+			want:   []SchemaViolation{{"details.1", "must be at least 1 characters"}},
+		},
+		{
+			name:   "empty array",
+			schema: `{"type": "array", "minItems": 1}`,
+			value:  `[]`,
+			want:   []SchemaViolation{{"details", "must have at least 1 items"}},
+		},
+		{
+			name:   "formats",
+			schema: `{"type": "object", "properties": {"at": {"format": "date-time"}, "mail": {"format": "email"}, "site": {"format": "uri"}}}`,
+			value:  `{"at": "2026-01-02T03:04:05Z", "mail": "Ana <ana@example.com>", "site": "example.com"}`,
+			want: []SchemaViolation{
+				{"details.mail", "must be a valid email"},
+				{"details.site", "must be a valid uri"},
+			},
+		},
+		{
+			name:   "type list accepts null",
+			schema: `{"type": ["string", "null"]}`,
+			value:  `null`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := decodeJSON(t, tt.schema).(map[string]interface{})
+			if err := checkSchema("schema", schema); err != nil {
+				t.Fatalf("test schema is invalid: %v", err)
+			}
+			got := validateSchema("details", schema, decodeJSON(t, tt.value))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("violation %d: got %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPlainJSON(t *testing.T) {
+	v, err := plainJSON(map[string]interface{}{"rank": 1, "tags": []string{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := v.(map[string]interface{})
+	if _, ok := m["rank"].(float64); !ok {
+		t.Errorf("rank is %T, want float64", m["rank"])
+	}
+	if _, ok := m["tags"].([]interface{}); !ok {
+		t.Errorf("tags is %T, want []interface{}", m["tags"])
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// permissionCache keeps the permission names of each role in memory for a TTL so that
+// RequirePermission does not query role_permissions on every request. Entries are dropped
+// as soon as a role's permissions change, locally or on another instance (see
+// RBACService.HandleChange); the TTL only bounds staleness if a notification is lost.
+type permissionCache struct {
+	mu    sync.RWMutex
+	ttl   time.Duration
+	items map[string]cachedPermissions
+	gen   uint64 // bumped by every invalidation
+
+	hits          atomic.Int64
+	misses        atomic.Int64
+	invalidations atomic.Int64
+}
+
+type cachedPermissions struct {
+	names     map[string]bool
+	fetchedAt time.Time
+}
+
+// PermissionCacheStats are the counters of the permission cache since startup.
+type PermissionCacheStats struct {
+	Entries       int     `json:"entries"`
+	Hits          int64   `json:"hits"`
+	Misses        int64   `json:"misses"`
+	HitRate       float64 `json:"hit_rate"` // hits / (hits + misses), 0 before the first lookup
+	Invalidations int64   `json:"invalidations"`
+	TTLSeconds    float64 `json:"ttl_seconds"`
+}
+
+func newPermissionCache(ttl time.Duration) *permissionCache {
+	return &permissionCache{ttl: ttl, items: make(map[string]cachedPermissions)}
+}
+
+// Get returns the cached permission set of a role and counts the hit or miss.
+func (c *permissionCache) Get(roleID string) (map[string]bool, bool) {
+	c.mu.RLock()
+	item, ok := c.items[roleID]
+	c.mu.RUnlock()
+	if !ok || time.Since(item.fetchedAt) > c.ttl {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	return item.names, true
+}
+
+// Generation is read before loading a set from the database and handed back to Set.
+func (c *permissionCache) Generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gen
+}
+
+// Set stores names as the permission set of roleID, unless something was invalidated since
+// gen: the set may have been read before the change and be stale already.
+func (c *permissionCache) Set(roleID string, names map[string]bool, gen uint64) {
+	c.mu.Lock()
+	if gen == c.gen {
+		c.items[roleID] = cachedPermissions{names: names, fetchedAt: time.Now()}
+	}
+	c.mu.Unlock()
+}
+
+// Invalidate drops one role; an empty roleID drops every role.
+func (c *permissionCache) Invalidate(roleID string) {
+	c.mu.Lock()
+	if roleID == "" {
+		c.items = make(map[string]cachedPermissions)
+	} else {
+		delete(c.items, roleID)
+	}
+	c.gen++
+	c.mu.Unlock()
+	c.invalidations.Add(1)
+}
+
+func (c *permissionCache) Stats() PermissionCacheStats {
+	c.mu.RLock()
+	n := len(c.items)
+	c.mu.RUnlock()
+	st := PermissionCacheStats{
+		Entries:       n,
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Invalidations: c.invalidations.Load(),
+		TTLSeconds:    c.ttl.Seconds(),
+	}
+	if total := st.Hits + st.Misses; total > 0 {
+		st.HitRate = float64(st.Hits) / float64(total)
+	}
+	return st
+}
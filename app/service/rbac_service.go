@@ -2,15 +2,28 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
 
 	pgRepo "clean-arch/app/repository/postgre"
 )
 
-// RBACService checks role permissions
+// RBACNotifyChannel is the Postgres NOTIFY channel on which role_permissions changes are
+// announced (payload: role id, empty for "all roles"); see migration 0020_rbac_notify.
+const RBACNotifyChannel = "rbac_changed"
+
+// permissionWildcard stands for any resource or any action in a permission name
+const permissionWildcard = "*"
+
+// RBACService checks role permissions. A role holds its own permissions plus those of its
+// parent chain, and a permission may be a pattern: achievement:* or *:read.
 type RBACService struct {
 	rolePermRepo pgRepo.RolePermissionRepository
 	permRepo     pgRepo.PermissionRepository
 	roleRepo     pgRepo.RoleRepository
+	cache        *permissionCache
 }
 
 func NewRBACService(rp pgRepo.RolePermissionRepository, pr pgRepo.PermissionRepository, rr pgRepo.RoleRepository) *RBACService {
@@ -18,19 +31,130 @@ func NewRBACService(rp pgRepo.RolePermissionRepository, pr pgRepo.PermissionRepo
 		rolePermRepo: rp,
 		permRepo:     pr,
 		roleRepo:     rr,
+		cache:        newPermissionCache(durationFromEnv("RBAC_CACHE_TTL", 5*time.Minute)),
 	}
 }
 
+// PermissionGrant is one permission (or pattern) held by a role, directly or through a parent.
+type PermissionGrant struct {
+	Permission string `json:"permission"`
+	Pattern    bool   `json:"pattern"`
+	RoleID     string `json:"role_id"` // role the permission is assigned to
+	RoleName   string `json:"role_name"`
+	Inherited  bool   `json:"inherited"`
+}
+
 // HasPermissionByRoleID returns true if the role has permission name (e.g. "achievement:verify")
 func (s *RBACService) HasPermissionByRoleID(ctx context.Context, roleID string, permName string) (bool, error) {
-	perms, err := s.rolePermRepo.ListByRole(ctx, roleID)
+	names, err := s.permissionSet(ctx, roleID)
 	if err != nil {
 		return false, err
 	}
-	for _, p := range perms {
-		if p.Name == permName {
-			return true, nil
+	return permissionGranted(names, permName), nil
+}
+
+// HasAnyPermission reports whether any of roleIDs has permission name: a user holds the union
+// of the permissions of their roles.
+func (s *RBACService) HasAnyPermission(ctx context.Context, roleIDs []string, permName string) (bool, error) {
+	for _, roleID := range roleIDs {
+		ok, err := s.HasPermissionByRoleID(ctx, roleID, permName)
+		if err != nil || ok {
+			return ok, err
 		}
 	}
 	return false, nil
 }
+
+// permissionGranted reports whether a set of permission names and patterns covers permName
+func permissionGranted(names map[string]bool, permName string) bool {
+	if names[permName] {
+		return true
+	}
+	resource, action, ok := strings.Cut(permName, ":")
+	if !ok {
+		return false
+	}
+	return names[resource+":"+permissionWildcard] ||
+		names[permissionWildcard+":"+action] ||
+		names[permissionWildcard+":"+permissionWildcard]
+}
+
+// permissionMatches reports whether a single permission name or pattern covers permName
+func permissionMatches(pattern, permName string) bool {
+	return permissionGranted(map[string]bool{pattern: true}, permName)
+}
+
+// isPermissionPattern reports whether a permission name contains a wildcard
+func isPermissionPattern(name string) bool {
+	return strings.Contains(name, permissionWildcard)
+}
+
+// permissionSet returns the effective permission names of a role, from the cache when possible
+func (s *RBACService) permissionSet(ctx context.Context, roleID string) (map[string]bool, error) {
+	if names, ok := s.cache.Get(roleID); ok {
+		return names, nil
+	}
+	gen := s.cache.Generation()
+	grants, err := s.Grants(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(grants))
+	for _, g := range grants {
+		names[g.Permission] = true
+	}
+	s.cache.Set(roleID, names, gen)
+	return names, nil
+}
+
+// Grants lists the permissions of a role and of its ancestors, nearest role first. A missing
+// role has no permissions; a cycle in the parent chain is cut where it closes.
+func (s *RBACService) Grants(ctx context.Context, roleID string) ([]PermissionGrant, error) {
+	out := []PermissionGrant{}
+	seen := map[string]bool{}
+	for id := roleID; id != "" && !seen[id]; {
+		seen[id] = true
+		role, err := s.roleRepo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				break
+			}
+			return nil, err
+		}
+		perms, err := s.rolePermRepo.ListByRole(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range perms {
+			out = append(out, PermissionGrant{
+				Permission: p.Name,
+				Pattern:    isPermissionPattern(p.Name),
+				RoleID:     role.ID,
+				RoleName:   role.Name,
+				Inherited:  role.ID != roleID,
+			})
+		}
+		id = ""
+		if role.ParentID != nil {
+			id = *role.ParentID
+		}
+	}
+	return out, nil
+}
+
+// InvalidateRole drops cached permissions after a change to roleID. Cached sets include
+// inherited permissions, so descendants are affected too; roles are few, so all are dropped.
+func (s *RBACService) InvalidateRole(roleID string) {
+	s.cache.Invalidate("")
+}
+
+// HandleChange is the RBACNotifyChannel handler (payload: changed role id, or empty when
+// notifications may have been missed). Like InvalidateRole it drops every cached role.
+func (s *RBACService) HandleChange(payload string) {
+	s.InvalidateRole(payload)
+}
+
+// CacheStats reports hit/miss counters of the permission cache.
+func (s *RBACService) CacheStats() PermissionCacheStats {
+	return s.cache.Stats()
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	pgModel "clean-arch/app/model/postgre"
+	pgRepo "clean-arch/app/repository/postgre"
+)
+
+// fakeRoleRepo serves roles from a map; methods the tests do not use panic through the nil interface.
+type fakeRoleRepo struct {
+	pgRepo.RoleRepository
+	roles map[string]*pgModel.Role
+}
+
+func (f *fakeRoleRepo) GetByID(ctx context.Context, id string) (*pgModel.Role, error) {
+	r, ok := f.roles[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return r, nil
+}
+
+// fakeRolePermRepo maps role ids to the names of their directly assigned permissions;
+// a permission's id is its name.
+type fakeRolePermRepo struct {
+	pgRepo.RolePermissionRepository
+	perms map[string][]string
+}
+
+func (f *fakeRolePermRepo) ListByRole(ctx context.Context, roleID string) ([]*pgModel.Permission, error) {
+	out := []*pgModel.Permission{}
+	for _, name := range f.perms[roleID] {
+		out = append(out, &pgModel.Permission{ID: name, Name: name})
+	}
+	return out, nil
+}
+
+func role(id, name string, parent string) *pgModel.Role {
+	r := &pgModel.Role{ID: id, Name: name}
+	if parent != "" {
+		r.ParentID = &parent
+	}
+	return r
+}
+
+// testRoles: staff inherits from base, lecturer from staff; loop-a and loop-b are each other's parent
+func testRoles() (*fakeRoleRepo, *fakeRolePermRepo) {
+	roles := &fakeRoleRepo{roles: map[string]*pgModel.Role{}}
+	for _, r := range []*pgModel.Role{
+		role("base", "Base", ""),
+		role("staff", "Staff", "base"),
+		role("lecturer", "Dosen Wali", "staff"),
+		role("admin", RoleAdmin, ""),
+		role("loop-a", "Loop A", "loop-b"),
+		role("loop-b", "Loop B", "loop-a"),
+		role("orphan", "Orphan", "missing"),
+	} {
+		roles.roles[r.ID] = r
+	}
+	perms := &fakeRolePermRepo{perms: map[string][]string{
+		"base":     {"profile:read"},
+		"staff":    {"report:*"},
+		"lecturer": {"achievement:verify"},
+		"admin":    {"*:*"},
+		"loop-a":   {"a:read"},
+		"loop-b":   {"b:read"},
+		"orphan":   {"orphan:read"},
+	}}
+	return roles, perms
+}
+
+func TestPermissionGranted(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		perm  string
+		want  bool
+	}{
+		{"exact", []string{"achievement:verify"}, "achievement:verify", true},
+		{"other action", []string{"achievement:verify"}, "achievement:delete", false},
+		{"resource wildcard", []string{"achievement:*"}, "achievement:delete", true},
+		{"resource wildcard other resource", []string{"achievement:*"}, "user:delete", false},
+		{"action wildcard", []string{"*:read"}, "user:read", true},
+		{"action wildcard other action", []string{"*:read"}, "user:delete", false},
+		{"full wildcard", []string{"*:*"}, "role:manage", true},
+		{"name without action", []string{"*:*"}, "admin", false},
+		{"exact name without action", []string{"admin"}, "admin", true},
+		{"empty set", nil, "user:read", false},
+		{"pattern is not matched literally", []string{"achievement:ver*"}, "achievement:verify", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := map[string]bool{}
+			for _, n := range tt.names {
+				names[n] = true
+			}
+			if got := permissionGranted(names, tt.perm); got != tt.want {
+				t.Fatalf("permissionGranted(%v, %q) = %v, want %v", tt.names, tt.perm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionMatches(t *testing.T) {
+	if !permissionMatches("report:*", "report:reconcile") {
+		t.Error("report:* should cover report:reconcile")
+	}
+	if permissionMatches("report:read", "report:reconcile") {
+		t.Error("report:read should not cover report:reconcile")
+	}
+	if !isPermissionPattern("*:read") || isPermissionPattern("user:read") {
+		t.Error("isPermissionPattern: only names with a wildcard are patterns")
+	}
+}
+
+func TestRBACGrantsInheritance(t *testing.T) {
+	roles, perms := testRoles()
+	rbac := NewRBACService(perms, nil, roles)
+	ctx := context.Background()
+
+	grants, err := rbac.Grants(ctx, "lecturer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PermissionGrant{
+		{Permission: "achievement:verify", RoleID: "lecturer", RoleName: "Dosen Wali"},
+		{Permission: "report:*", Pattern: true, RoleID: "staff", RoleName: "Staff", Inherited: true},
+		{Permission: "profile:read", RoleID: "base", RoleName: "Base", Inherited: true},
+	}
+	if len(grants) != len(want) {
+		t.Fatalf("got %+v, want %+v", grants, want)
+	}
+	for i := range want {
+		if grants[i] != want[i] {
+			t.Errorf("grant %d: got %+v, want %+v", i, grants[i], want[i])
+		}
+	}
+
+	// a cycle is cut where it closes, a missing parent ends the chain
+	if g, err := rbac.Grants(ctx, "loop-a"); err != nil || len(g) != 2 {
+		t.Errorf("loop-a grants = %+v, %v; want a:read and b:read", g, err)
+	}
+	if g, err := rbac.Grants(ctx, "orphan"); err != nil || len(g) != 1 {
+		t.Errorf("orphan grants = %+v, %v; want orphan:read only", g, err)
+	}
+	if g, err := rbac.Grants(ctx, "missing"); err != nil || len(g) != 0 {
+		t.Errorf("missing role grants = %+v, %v; want none", g, err)
+	}
+}
+
+func TestRBACHasPermission(t *testing.T) {
+	roles, perms := testRoles()
+	rbac := NewRBACService(perms, nil, roles)
+	ctx := context.Background()
+
+	tests := []struct {
+		roles []string
+		perm  string
+		want  bool
+	}{
+		{[]string{"lecturer"}, "achievement:verify", true},
+		{[]string{"lecturer"}, "report:reconcile", true}, // staff's report:* pattern
+		{[]string{"lecturer"}, "profile:read", true},     // from base, two levels up
+		{[]string{"staff"}, "achievement:verify", false}, // children's permissions do not flow up
+		{[]string{"base"}, "report:read", false},
+		{[]string{"base", "lecturer"}, "achievement:verify", true}, // union of roles
+		{[]string{"admin"}, "role:manage", true},
+		{nil, "profile:read", false},
+	}
+	for _, tt := range tests {
+		got, err := rbac.HasAnyPermission(ctx, tt.roles, tt.perm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("HasAnyPermission(%v, %q) = %v, want %v", tt.roles, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestRoleServiceCheckLockout(t *testing.T) {
+	roles, perms := testRoles()
+	perms.perms["staff"] = append(perms.perms["staff"], "role:manage")
+	perms.perms["manager"] = []string{"role:*"}
+	roles.roles["manager"] = role("manager", "Manager", "")
+	svc := &RoleService{roleRepo: roles, rolePermRepo: perms}
+	ctx := context.Background()
+
+	lecturer := Actor{UserID: "u1", RoleID: "lecturer"}
+	both := Actor{UserID: "u2", RoleID: "lecturer", RoleIDs: []string{"lecturer", "manager"}}
+	tests := []struct {
+		name   string
+		actor  Actor
+		change roleChange
+		want   error
+	}{
+		{"unrelated permission", lecturer, roleChange{roleID: "staff", removePermission: "report:*"}, nil},
+		{"inherited role:manage removed", lecturer, roleChange{roleID: "staff", removePermission: "role:manage"}, ErrRoleManageLockout},
+		{"parent dropped", lecturer, roleChange{roleID: "lecturer", setParent: true}, ErrRoleManageLockout},
+		{"parent moved to a role without it", lecturer, roleChange{roleID: "lecturer", setParent: true, parentID: strPtr("base")}, ErrRoleManageLockout},
+		{"ancestor re-parented", lecturer, roleChange{roleID: "staff", setParent: true, parentID: strPtr("admin")}, nil},
+		{"another role still grants it", both, roleChange{roleID: "staff", removePermission: "role:manage"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.checkLockout(ctx, tt.actor, tt.change)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("checkLockout = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	// the check only reads: nothing was written
+	if got := perms.perms["staff"]; len(got) != 2 {
+		t.Errorf("staff permissions changed: %v", got)
+	}
+}
+
+func strPtr(s string) *string { return &s }
@@ -2,7 +2,12 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"sort"
+	"time"
+
+	pgModel "clean-arch/app/model/postgre"
 
 	pgRepo "clean-arch/app/repository/postgre"
 )
@@ -13,6 +18,10 @@ type ReportService struct {
 	studentRepo        pgRepo.StudentRepository
 	lecturerRepo       pgRepo.LecturerRepository
 	activityLogRepo    pgRepo.ActivityLogRepository // <-- Tambahkan ini
+	reviewRoundRepo    pgRepo.ReviewRoundRepository
+	memberRepo         pgRepo.AchievementMemberRepository
+	scoring            *ScoringService
+	policy             *AccessPolicy
 }
 
 // Update Constructor: Tambahkan parameter activityLogRepo
@@ -21,19 +30,29 @@ func NewReportService(
 	studentRepo pgRepo.StudentRepository,
 	lecturerRepo pgRepo.LecturerRepository,
 	activityLogRepo pgRepo.ActivityLogRepository, // <-- Tambahkan parameter
+	reviewRoundRepo pgRepo.ReviewRoundRepository,
+	memberRepo pgRepo.AchievementMemberRepository,
+	scoring *ScoringService,
+	policy *AccessPolicy,
 ) *ReportService {
 	return &ReportService{
 		achievementRefRepo: achievementRefRepo,
 		studentRepo:        studentRepo,
 		lecturerRepo:       lecturerRepo,
 		activityLogRepo:    activityLogRepo, // <-- Assign
+		reviewRoundRepo:    reviewRoundRepo,
+		memberRepo:         memberRepo,
+		scoring:            scoring,
+		policy:             policy,
 	}
 }
 
 // AchievementStatistics holds statistics data
 type AchievementStatistics struct {
-	TotalAchievements    int              `json:"total_achievements"`
-	AchievementsByStatus map[string]int   `json:"achievements_by_status"`
+	TotalAchievements    int              `json:"total_achievements"`     // a team achievement counts once
+	TeamAchievements     int              `json:"team_achievements"`      // achievements credited to more than one student
+	AchievementsByStatus map[string]int   `json:"achievements_by_status"` // expired certifications are counted as expired
+	ExpiringSoon         int              `json:"expiring_soon"`          // verified certifications flagged by the expiry check
 	TopStudents          []TopStudentData `json:"top_students"`
 	VerificationRate     float64          `json:"verification_rate"`
 }
@@ -57,22 +76,52 @@ func (s *ReportService) GetAllAchievementsStatistics(ctx context.Context) (*Achi
 		TopStudents:          []TopStudentData{},
 	}
 
+	// accepted team members per achievement; each member is credited once per achievement
+	credited := make(map[string]map[string]bool)
+	if s.memberRepo != nil {
+		members, err := s.memberRepo.ListAccepted(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			if credited[m.ReferenceID] == nil {
+				credited[m.ReferenceID] = make(map[string]bool)
+			}
+			credited[m.ReferenceID][m.StudentID] = true
+		}
+	}
+
 	stats.TotalAchievements = len(refs)
 	verifiedCount := 0
 	studentCounts := make(map[string]int)
+	now := time.Now()
 
 	// 2. Agregasi Data in-memory
 	for _, ref := range refs {
-		// Hitung per status
-		stats.AchievementsByStatus[ref.Status]++
+		// Hitung per status (sertifikat yang lewat masa berlaku dihitung expired)
+		status := EffectiveStatus(ref, now)
+		stats.AchievementsByStatus[status]++
 
-		// Hitung verified
+		// Hitung verified (termasuk yang sudah expired)
 		if ref.Status == "verified" {
 			verifiedCount++
 		}
+		if status == "verified" && ref.ExpiryFlaggedAt != nil {
+			stats.ExpiringSoon++
+		}
 
-		// Hitung per mahasiswa
-		studentCounts[ref.StudentID]++
+		// Hitung per mahasiswa (pemilik + anggota tim yang sudah menerima)
+		students := credited[ref.ID]
+		if students == nil {
+			students = make(map[string]bool)
+		}
+		students[ref.StudentID] = true
+		for id := range students {
+			studentCounts[id]++
+		}
+		if len(students) > 1 {
+			stats.TeamAchievements++
+		}
 	}
 
 	// 3. Hitung Rate
@@ -118,13 +167,24 @@ func (s *ReportService) GetAllAchievementsStatistics(ctx context.Context) (*Achi
 	return stats, nil
 }
 
-// GetStudentStatistics returns statistics for a specific student
-func (s *ReportService) GetStudentStatistics(ctx context.Context, studentID string) (map[string]interface{}, error) {
+// GetStudentStatistics returns statistics for a specific student (scoped to the actor)
+func (s *ReportService) GetStudentStatistics(ctx context.Context, actor Actor, studentID string) (map[string]interface{}, error) {
 	result := make(map[string]interface{})
 
+	allowed, err := s.policy.CanViewStudent(ctx, actor, studentID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+
 	// Get student basic info
 	student, err := s.studentRepo.GetByID(ctx, studentID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
 
@@ -137,8 +197,8 @@ func (s *ReportService) GetStudentStatistics(ctx context.Context, studentID stri
 	result["program_study"] = student.Program
 	result["academic_year"] = student.AcademicYear
 
-	// Get student achievements
-	achievements, err := s.achievementRefRepo.ListByStudent(ctx, studentID)
+	// Get student achievements, including team achievements the student accepted
+	achievements, err := s.achievementRefRepo.ListByMember(ctx, studentID)
 	if err != nil {
 		return nil, err
 	}
@@ -146,20 +206,28 @@ func (s *ReportService) GetStudentStatistics(ctx context.Context, studentID stri
 	totalAchievements := len(achievements)
 	statusCount := make(map[string]int)
 	verifiedCount := 0
+	memberCount := 0
+	now := time.Now()
 
 	for _, ach := range achievements {
-		statusCount[ach.Status]++
+		statusCount[EffectiveStatus(ach, now)]++
 		if ach.Status == "verified" {
 			verifiedCount++
 		}
+		if ach.StudentID != studentID {
+			memberCount++
+		}
 	}
 
 	result["total_achievements"] = totalAchievements
+	result["led_count"] = totalAchievements - memberCount
+	result["team_member_count"] = memberCount
 	result["achievements_by_status"] = statusCount
 	result["verified_count"] = verifiedCount
 	result["draft_count"] = statusCount["draft"]
 	result["submitted_count"] = statusCount["submitted"]
 	result["rejected_count"] = statusCount["rejected"]
+	result["expired_count"] = statusCount[pgModel.AchievementExpired]
 
 	if totalAchievements > 0 {
 		result["verification_rate"] = float64(verifiedCount) / float64(totalAchievements)
@@ -167,25 +235,59 @@ func (s *ReportService) GetStudentStatistics(ctx context.Context, studentID stri
 		result["verification_rate"] = 0.0
 	}
 
+	// SKPI points of verified achievements (team members each get the achievement's points)
+	if s.scoring != nil {
+		points, err := s.scoring.Totals(ctx, achievements)
+		if err != nil {
+			return nil, err
+		}
+		result["points"] = points
+	}
+
 	return result, nil
 }
 
 // GetAchievementHistory retrieves activity logs for a specific achievement reference
-func (s *ReportService) GetAchievementHistory(ctx context.Context, refID string) (map[string]interface{}, error) {
+func (s *ReportService) GetAchievementHistory(ctx context.Context, actor Actor, refID string) (map[string]interface{}, error) {
+	ref, err := s.achievementRefRepo.GetByID(ctx, refID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	allowed, err := s.policy.CanViewAchievement(ctx, actor, ref)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+
 	// Panggil repository activity log
 	logs, err := s.activityLogRepo.ListByEntity(ctx, "achievement_reference", refID, 100, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	// Bungkus dalam map agar format JSON rapi: { "history": [...] }
+	// Setiap pengajuan (round) beserta keputusan reviewer dan perubahan dari round sebelumnya
+	rounds, err := s.reviewRoundRepo.ListByReference(ctx, refID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bungkus dalam map agar format JSON rapi: { "history": [...], "rounds": [...] }
 	return map[string]interface{}{
 		"entity_id": refID,
+		"revision":  ref.Revision,
 		"history":   logs,
+		"rounds":    rounds,
 	}, nil
 }
 
 var ErrNotFound = &CustomError{"resource_not_found", "resource not found", 404}
+var ErrForbidden = &CustomError{"forbidden", "you are not allowed to access this resource", 403}
+var ErrPreconditionFailed = &CustomError{"precondition_failed", "the resource was changed by another request; reload it and try again", 412}
 
 type CustomError struct {
 	Code    string
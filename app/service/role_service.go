@@ -0,0 +1,882 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	pgModel "clean-arch/app/model/postgre"
+	pgRepo "clean-arch/app/repository/postgre"
+
+	"github.com/google/uuid"
+)
+
+// roleManagePermission guards the role and permission administration API; it cannot be
+// deleted or renamed, and no change may take it away from the caller's own role.
+const roleManagePermission = "role:manage"
+
+// permissionNamePattern is resource:action in lower case, e.g. achievement:verify; either part
+// may be the wildcard * (achievement:*, *:read)
+var permissionNamePattern = regexp.MustCompile(`^([a-z][a-z0-9_]*|\*):([a-z][a-z0-9_]*|\*)$`)
+
+// builtinRoles are referenced by name in code (AccessPolicy) and cannot be renamed or deleted
+var builtinRoles = map[string]bool{RoleAdmin: true, RoleStudent: true, RoleLecturer: true}
+
+var (
+	ErrInvalidRole         = &CustomError{"invalid_role", "role name is required (max 50 characters)", 400}
+	ErrDuplicateRole       = &CustomError{"duplicate_role", "a role with this name already exists", 409}
+	ErrBuiltinRole         = &CustomError{"builtin_role", "built-in roles cannot be renamed or deleted", 409}
+	ErrInvalidPermission   = &CustomError{"invalid_permission", "permission name must look like resource:action (either may be *)", 400}
+	ErrRoleParent          = &CustomError{"invalid_parent_role", "parent role does not exist or would make the role inherit from itself", 400}
+	ErrDuplicatePermission = &CustomError{"duplicate_permission", "a permission with this name already exists", 409}
+	ErrProtectedPermission = &CustomError{"protected_permission", "role:manage cannot be renamed or deleted", 409}
+	ErrPermissionNotFound  = &CustomError{"permission_not_found", "permission not found", 404}
+	ErrPermissionRequired  = &CustomError{"invalid_assignment", "permission is required", 400}
+	ErrRoleManageLockout   = &CustomError{"role_manage_lockout", "this change would take role:manage away from your own role", 409}
+	ErrUserRolesRequired   = &CustomError{"invalid_user_roles", "role_ids must list at least one existing role", 400}
+)
+
+// errRoleInUse stops deleting a role that users still hold
+func errRoleInUse(users int) error {
+	return &CustomError{"role_in_use", fmt.Sprintf("role is still assigned to %d user(s)", users), 409}
+}
+
+// RoleService administers roles, permissions and which roles hold which permission. Every
+// change is written to activity_logs with the permission sets before and after it.
+type RoleService struct {
+	roleRepo     pgRepo.RoleRepository
+	permRepo     pgRepo.PermissionRepository
+	rolePermRepo pgRepo.RolePermissionRepository
+	userRepo     pgRepo.UserRepository
+	activityRepo pgRepo.ActivityLogRepository
+	rbac         *RBACService // permission cache, invalidated on every change
+}
+
+func NewRoleService(
+	roleRepo pgRepo.RoleRepository,
+	permRepo pgRepo.PermissionRepository,
+	rolePermRepo pgRepo.RolePermissionRepository,
+	userRepo pgRepo.UserRepository,
+	activityRepo pgRepo.ActivityLogRepository,
+	rbac *RBACService,
+) *RoleService {
+	return &RoleService{
+		roleRepo:     roleRepo,
+		permRepo:     permRepo,
+		rolePermRepo: rolePermRepo,
+		userRepo:     userRepo,
+		activityRepo: activityRepo,
+		rbac:         rbac,
+	}
+}
+
+// RoleRequest is the body of POST/PUT /roles. Permissions (names) are only read on create.
+// ParentID is the role to inherit from: nil keeps the current parent, "" clears it.
+type RoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	ParentID    *string  `json:"parent_id,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// PermissionRequest is the body of POST/PUT /permissions; resource and action come from the name.
+type PermissionRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// EffectivePermission is a concrete permission a role ends up with and the grants giving it.
+type EffectivePermission struct {
+	Name    string            `json:"name"`
+	Sources []PermissionGrant `json:"sources"`
+}
+
+// EffectivePermissions is the expanded permission set of a role (or of a user's roles): the
+// grants along the inheritance chains and every permission they cover.
+type EffectivePermissions struct {
+	UserID      string                `json:"user_id,omitempty"`
+	RoleID      string                `json:"role_id"` // the role, or the user's primary role
+	RoleName    string                `json:"role_name"`
+	RoleIDs     []string              `json:"role_ids,omitempty"` // every role of the user
+	Inheritance []string              `json:"inheritance"`        // role names, the role itself first
+	Grants      []PermissionGrant     `json:"grants"`
+	Permissions []EffectivePermission `json:"permissions"`
+}
+
+// RoleDetail is a role with its permissions and the number of users holding it.
+type RoleDetail struct {
+	*pgModel.Role
+	Permissions []*pgModel.Permission `json:"permissions"`
+	UserCount   int                   `json:"user_count"`
+}
+
+func (s *RoleService) ListRoles(ctx context.Context) ([]*RoleDetail, error) {
+	roles, err := s.roleRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*RoleDetail, 0, len(roles))
+	for _, r := range roles {
+		d, err := s.detail(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func (s *RoleService) GetRole(ctx context.Context, id string) (*RoleDetail, error) {
+	role, err := s.getRole(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.detail(ctx, role)
+}
+
+// CreateRole creates a role, optionally with an initial set of permissions.
+func (s *RoleService) CreateRole(ctx context.Context, actor Actor, req RoleRequest) (*RoleDetail, error) {
+	role := &pgModel.Role{ID: uuid.New().String()}
+	if err := s.applyRoleRequest(ctx, role, req); err != nil {
+		return nil, err
+	}
+	perms := make([]*pgModel.Permission, 0, len(req.Permissions))
+	for _, name := range req.Permissions {
+		p, err := s.resolvePermission(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		perms = append(perms, p)
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+	for _, p := range perms {
+		if err := s.rolePermRepo.Assign(ctx, role.ID, p.ID); err != nil {
+			return nil, err
+		}
+	}
+	s.rbac.InvalidateRole(role.ID)
+	d, err := s.detail(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	s.log(ctx, actor, "role", role.ID, "role_created", nil, roleSnapshot(role, d.Permissions))
+	return d, nil
+}
+
+// UpdateRole changes the name, description and parent of a role; built-in roles keep their names.
+func (s *RoleService) UpdateRole(ctx context.Context, actor Actor, id string, req RoleRequest) (*RoleDetail, error) {
+	role, err := s.getRole(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	perms, err := s.rolePermRepo.ListByRole(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	previous := roleSnapshot(role, perms)
+	oldParent := role.ParentID
+	if builtinRoles[role.Name] && strings.TrimSpace(req.Name) != role.Name {
+		return nil, ErrBuiltinRole
+	}
+	if err := s.applyRoleRequest(ctx, role, req); err != nil {
+		return nil, err
+	}
+	if !sameParent(oldParent, role.ParentID) {
+		if err := s.checkLockout(ctx, actor, roleChange{roleID: role.ID, setParent: true, parentID: role.ParentID}); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if !sameParent(oldParent, role.ParentID) {
+		s.rbac.InvalidateRole(role.ID)
+	}
+	s.log(ctx, actor, "role", role.ID, "role_updated", previous, roleSnapshot(role, perms))
+	return s.detail(ctx, role)
+}
+
+// DeleteRole removes a role that no user (trashed ones included) holds any more.
+func (s *RoleService) DeleteRole(ctx context.Context, actor Actor, id string) error {
+	role, err := s.getRole(ctx, id)
+	if err != nil {
+		return err
+	}
+	if builtinRoles[role.Name] {
+		return ErrBuiltinRole
+	}
+	n, err := s.roleRepo.CountUsers(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return errRoleInUse(n)
+	}
+	perms, err := s.rolePermRepo.ListByRole(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.roleRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	s.rbac.InvalidateRole(id)
+	s.log(ctx, actor, "role", id, "role_deleted", roleSnapshot(role, perms), nil)
+	return nil
+}
+
+// AssignPermission grants a permission (id or name) to a role; granting it twice is a no-op.
+func (s *RoleService) AssignPermission(ctx context.Context, actor Actor, roleID string, permission string) (*RoleDetail, error) {
+	return s.changeRolePermission(ctx, actor, roleID, permission, true)
+}
+
+// RemovePermission takes a permission (id or name) away from a role.
+func (s *RoleService) RemovePermission(ctx context.Context, actor Actor, roleID string, permission string) (*RoleDetail, error) {
+	return s.changeRolePermission(ctx, actor, roleID, permission, false)
+}
+
+func (s *RoleService) changeRolePermission(ctx context.Context, actor Actor, roleID string, permission string, assign bool) (*RoleDetail, error) {
+	role, err := s.getRole(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.resolvePermission(ctx, permission)
+	if err != nil {
+		return nil, err
+	}
+	before, err := s.rolePermRepo.ListByRole(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	event := "permission_assigned"
+	if assign {
+		err = s.rolePermRepo.Assign(ctx, roleID, p.ID)
+	} else {
+		event = "permission_removed"
+		if err := s.checkLockout(ctx, actor, roleChange{roleID: roleID, removePermission: p.ID}); err != nil {
+			return nil, err
+		}
+		err = s.rolePermRepo.Remove(ctx, roleID, p.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.rbac.InvalidateRole(roleID)
+	d, err := s.detail(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	if equalStrings(permissionNames(before), permissionNames(d.Permissions)) {
+		return d, nil // nothing changed
+	}
+	s.logPermissions(ctx, actor, role.ID, event, p.Name, before, d.Permissions)
+	return d, nil
+}
+
+func (s *RoleService) ListPermissions(ctx context.Context) ([]*pgModel.Permission, error) {
+	return s.permRepo.ListAll(ctx)
+}
+
+func (s *RoleService) CreatePermission(ctx context.Context, actor Actor, req PermissionRequest) (*pgModel.Permission, error) {
+	p := &pgModel.Permission{ID: uuid.New().String()}
+	if err := s.applyPermissionRequest(ctx, p, req); err != nil {
+		return nil, err
+	}
+	if err := s.permRepo.Create(ctx, p); err != nil {
+		return nil, err
+	}
+	s.log(ctx, actor, "permission", p.ID, "permission_created", nil, permissionSnapshot(p))
+	return p, nil
+}
+
+// UpdatePermission renames or re-describes a permission; the roles holding it keep it.
+func (s *RoleService) UpdatePermission(ctx context.Context, actor Actor, id string, req PermissionRequest) (*pgModel.Permission, error) {
+	p, err := s.getPermission(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	previous := permissionSnapshot(p)
+	if p.Name == roleManagePermission && strings.TrimSpace(req.Name) != p.Name {
+		return nil, ErrProtectedPermission
+	}
+	if err := s.applyPermissionRequest(ctx, p, req); err != nil {
+		return nil, err
+	}
+	if err := s.permRepo.Update(ctx, p); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPermissionNotFound
+		}
+		return nil, err
+	}
+	s.rbac.InvalidateRole("")
+	s.log(ctx, actor, "permission", p.ID, "permission_updated", previous, permissionSnapshot(p))
+	return p, nil
+}
+
+// DeletePermission removes a permission; each role that held it gets a permission_removed log.
+func (s *RoleService) DeletePermission(ctx context.Context, actor Actor, id string) error {
+	p, err := s.getPermission(ctx, id)
+	if err != nil {
+		return err
+	}
+	if p.Name == roleManagePermission {
+		return ErrProtectedPermission
+	}
+	roleIDs, err := s.rolePermRepo.ListRoleIDs(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	before := map[string][]*pgModel.Permission{}
+	for _, roleID := range roleIDs {
+		if before[roleID], err = s.rolePermRepo.ListByRole(ctx, roleID); err != nil {
+			return err
+		}
+	}
+	if err := s.permRepo.Delete(ctx, p.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPermissionNotFound
+		}
+		return err
+	}
+	s.rbac.InvalidateRole("")
+	s.log(ctx, actor, "permission", p.ID, "permission_deleted", permissionSnapshot(p), nil)
+	for _, roleID := range roleIDs {
+		after := make([]*pgModel.Permission, 0, len(before[roleID]))
+		for _, held := range before[roleID] {
+			if held.ID != p.ID {
+				after = append(after, held)
+			}
+		}
+		s.logPermissions(ctx, actor, roleID, "permission_removed", p.Name, before[roleID], after)
+	}
+	return nil
+}
+
+// SetUserRoles replaces the roles of a user with roleIDs; the first one becomes the primary
+// role (users.role_id). The new roles show up in the user's tokens from the next refresh.
+func (s *RoleService) SetUserRoles(ctx context.Context, actor Actor, userID string, roleIDs []string) ([]*pgModel.Role, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrNotFound
+	}
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	ids := []string{}
+	roles := []*pgModel.Role{}
+	seen := map[string]bool{}
+	for _, id := range roleIDs {
+		id = strings.TrimSpace(id)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		role, err := s.getRole(ctx, id)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil, ErrUserRolesRequired
+			}
+			return nil, err
+		}
+		ids = append(ids, role.ID)
+		roles = append(roles, role)
+	}
+	if len(ids) == 0 {
+		return nil, ErrUserRolesRequired
+	}
+	if u.ID == actor.UserID {
+		ok, err := s.rbac.HasAnyPermission(ctx, ids, roleManagePermission)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrRoleManageLockout
+		}
+	}
+	if err := s.userRepo.SetRoles(ctx, u.ID, ids); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	s.log(ctx, actor, "user", u.ID, "roles_changed",
+		map[string]interface{}{"role_id": u.RoleID, "role_ids": u.RoleIDs},
+		map[string]interface{}{"role_id": ids[0], "role_ids": ids})
+	return roles, nil
+}
+
+// SetPrimaryRole replaces the primary role of a user (users.role_id) and keeps the others,
+// with the same checks and activity log as SetUserRoles.
+func (s *RoleService) SetPrimaryRole(ctx context.Context, actor Actor, userID string, roleID string) ([]*pgModel.Role, error) {
+	roleID = strings.TrimSpace(roleID)
+	if roleID == "" {
+		return nil, ErrUserRolesRequired
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrNotFound
+	}
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	ids := []string{roleID}
+	for _, id := range u.RoleIDs {
+		if id != u.RoleID && id != roleID {
+			ids = append(ids, id)
+		}
+	}
+	return s.SetUserRoles(ctx, actor, userID, ids)
+}
+
+// EffectiveForRole expands the permissions of a role: its own and inherited grants, and every
+// permission those grants (patterns included) cover, each with the grants that give it.
+func (s *RoleService) EffectiveForRole(ctx context.Context, roleID string) (*EffectivePermissions, error) {
+	return s.effective(ctx, []string{roleID})
+}
+
+// EffectiveForUser is EffectiveForRole for the union of a user's roles.
+func (s *RoleService) EffectiveForUser(ctx context.Context, userID string) (*EffectivePermissions, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrNotFound
+	}
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	roleIDs := u.RoleIDs
+	if len(roleIDs) == 0 && u.RoleID != "" {
+		roleIDs = []string{u.RoleID}
+	}
+	if len(roleIDs) == 0 {
+		return &EffectivePermissions{UserID: u.ID, RoleIDs: []string{}, Inheritance: []string{}, Grants: []PermissionGrant{}, Permissions: []EffectivePermission{}}, nil
+	}
+	out, err := s.effective(ctx, roleIDs)
+	if err != nil {
+		return nil, err
+	}
+	out.UserID = u.ID
+	out.RoleIDs = roleIDs
+	return out, nil
+}
+
+// effective expands the union of roleIDs; the first role names the result. An ancestor shared
+// by several roles is listed, and its grants counted, once.
+func (s *RoleService) effective(ctx context.Context, roleIDs []string) (*EffectivePermissions, error) {
+	out := &EffectivePermissions{
+		Inheritance: []string{},
+		Grants:      []PermissionGrant{},
+		Permissions: []EffectivePermission{},
+	}
+	seenRoles, seenGrants := map[string]bool{}, map[string]bool{}
+	for i, roleID := range roleIDs {
+		chain, err := s.roleChain(ctx, roleID)
+		if err != nil {
+			return nil, err
+		}
+		grants, err := s.rbac.Grants(ctx, roleID)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			out.RoleID, out.RoleName = chain[0].ID, chain[0].Name
+		}
+		for _, r := range chain {
+			if !seenRoles[r.ID] {
+				seenRoles[r.ID] = true
+				out.Inheritance = append(out.Inheritance, r.Name)
+			}
+		}
+		for _, g := range grants {
+			if key := g.RoleID + " " + g.Permission; !seenGrants[key] {
+				seenGrants[key] = true
+				out.Grants = append(out.Grants, g)
+			}
+		}
+	}
+	all, err := s.permRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range all {
+		if isPermissionPattern(p.Name) {
+			continue
+		}
+		ep := EffectivePermission{Name: p.Name, Sources: []PermissionGrant{}}
+		for _, g := range out.Grants {
+			if permissionMatches(g.Permission, p.Name) {
+				ep.Sources = append(ep.Sources, g)
+			}
+		}
+		if len(ep.Sources) > 0 {
+			out.Permissions = append(out.Permissions, ep)
+		}
+	}
+	return out, nil
+}
+
+// PermissionSyncResult reports a SyncPermissions run.
+type PermissionSyncResult struct {
+	Declared int      `json:"declared"` // permissions required by routes and the workflow
+	Created  []string `json:"created"`  // declared permissions that were missing
+	Orphaned []string `json:"orphaned"` // stored permissions nothing requires (patterns: nothing they match)
+}
+
+// SyncPermissions creates the declared permissions (name -> where it is required) that are
+// missing from the permissions table and reports the stored ones nothing declares. Orphans
+// are only reported: they may still be granted to roles on purpose.
+func (s *RoleService) SyncPermissions(ctx context.Context, declared map[string][]string) (*PermissionSyncResult, error) {
+	stored, err := s.permRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]bool{}
+	for _, p := range stored {
+		existing[p.Name] = true
+	}
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	res := &PermissionSyncResult{Declared: len(names), Created: []string{}, Orphaned: []string{}}
+	for _, name := range names {
+		if existing[name] {
+			continue
+		}
+		if !permissionNamePattern.MatchString(name) {
+			return res, &CustomError{ErrInvalidPermission.Code, "declared permission " + name + " is not resource:action", ErrInvalidPermission.Status}
+		}
+		p := &pgModel.Permission{ID: uuid.New().String(), Name: name, Description: "Required by " + strings.Join(declared[name], ", ")}
+		p.Resource, p.Action, _ = strings.Cut(name, ":")
+		if err := s.permRepo.Create(ctx, p); err != nil {
+			return res, err
+		}
+		res.Created = append(res.Created, name)
+		s.writeLog(ctx, &pgModel.ActivityLog{
+			ID:         uuid.New().String(),
+			EntityType: "permission",
+			EntityID:   p.ID,
+			EventType:  "permission_created",
+			Current:    permissionSnapshot(p),
+			Metadata:   map[string]interface{}{"source": "permission_sync", "required_by": declared[name]},
+			CreatedAt:  time.Now(),
+		})
+	}
+	for _, p := range stored {
+		used := false
+		for _, name := range names {
+			if permissionMatches(p.Name, name) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			res.Orphaned = append(res.Orphaned, p.Name)
+		}
+	}
+	return res, nil
+}
+
+func (s *RoleService) detail(ctx context.Context, role *pgModel.Role) (*RoleDetail, error) {
+	perms, err := s.rolePermRepo.ListByRole(ctx, role.ID)
+	if err != nil {
+		return nil, err
+	}
+	if perms == nil {
+		perms = []*pgModel.Permission{}
+	}
+	n, err := s.roleRepo.CountUsers(ctx, role.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &RoleDetail{Role: role, Permissions: perms, UserCount: n}, nil
+}
+
+// applyRoleRequest validates req and copies it into role; names stay unique (case-insensitive)
+func (s *RoleService) applyRoleRequest(ctx context.Context, role *pgModel.Role, req RoleRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 50 {
+		return ErrInvalidRole
+	}
+	roles, err := s.roleRepo.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, r := range roles {
+		if r.ID != role.ID && strings.EqualFold(r.Name, name) {
+			return ErrDuplicateRole
+		}
+	}
+	if req.ParentID != nil {
+		role.ParentID = nil
+		if parentID := strings.TrimSpace(*req.ParentID); parentID != "" {
+			if err := s.checkParent(ctx, role.ID, parentID); err != nil {
+				return err
+			}
+			role.ParentID = &parentID
+		}
+	}
+	role.Name = name
+	role.Description = strings.TrimSpace(req.Description)
+	return nil
+}
+
+// checkParent makes sure parentID exists and does not inherit from roleID already
+func (s *RoleService) checkParent(ctx context.Context, roleID, parentID string) error {
+	chain, err := s.roleChain(ctx, parentID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrRoleParent
+		}
+		return err
+	}
+	for _, r := range chain {
+		if r.ID == roleID {
+			return ErrRoleParent
+		}
+	}
+	return nil
+}
+
+// roleChain returns the role followed by its ancestors, stopping where a cycle would close
+func (s *RoleService) roleChain(ctx context.Context, roleID string) ([]*pgModel.Role, error) {
+	chain := []*pgModel.Role{}
+	seen := map[string]bool{}
+	for id := roleID; id != "" && !seen[id]; {
+		seen[id] = true
+		role, err := s.getRole(ctx, id)
+		if err != nil {
+			if len(chain) > 0 && errors.Is(err, ErrNotFound) {
+				break
+			}
+			return nil, err
+		}
+		chain = append(chain, role)
+		id = ""
+		if role.ParentID != nil {
+			id = *role.ParentID
+		}
+	}
+	return chain, nil
+}
+
+// roleChange is a change to one role that has not been written yet
+type roleChange struct {
+	roleID           string
+	setParent        bool
+	parentID         *string // new parent when setParent
+	removePermission string  // id of a permission taken away from the role
+}
+
+// checkLockout returns ErrRoleManageLockout when change would leave the actor's own roles
+// without role:manage. It runs before the change is written, so nothing has to be undone.
+func (s *RoleService) checkLockout(ctx context.Context, actor Actor, change roleChange) error {
+	for _, roleID := range actor.Roles() {
+		names, err := s.permissionsWith(ctx, roleID, change)
+		if err != nil {
+			return err
+		}
+		if permissionGranted(names, roleManagePermission) {
+			return nil
+		}
+	}
+	return ErrRoleManageLockout
+}
+
+// permissionsWith is the effective permission set of a role (see RBACService.Grants) as it
+// would be after change
+func (s *RoleService) permissionsWith(ctx context.Context, roleID string, change roleChange) (map[string]bool, error) {
+	names := map[string]bool{}
+	seen := map[string]bool{}
+	for id := roleID; id != "" && !seen[id]; {
+		seen[id] = true
+		role, err := s.roleRepo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				break
+			}
+			return nil, err
+		}
+		perms, err := s.rolePermRepo.ListByRole(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range perms {
+			if id == change.roleID && p.ID == change.removePermission {
+				continue
+			}
+			names[p.Name] = true
+		}
+		parent := role.ParentID
+		if id == change.roleID && change.setParent {
+			parent = change.parentID
+		}
+		id = ""
+		if parent != nil {
+			id = *parent
+		}
+	}
+	return names, nil
+}
+
+func sameParent(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// applyPermissionRequest validates req and copies it into p; names stay unique
+func (s *RoleService) applyPermissionRequest(ctx context.Context, p *pgModel.Permission, req PermissionRequest) error {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if !permissionNamePattern.MatchString(name) || len(name) > 100 {
+		return ErrInvalidPermission
+	}
+	if existing, err := s.permRepo.GetByName(ctx, name); err == nil && existing.ID != p.ID {
+		return ErrDuplicatePermission
+	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	p.Name = name
+	p.Resource, p.Action, _ = strings.Cut(name, ":")
+	p.Description = strings.TrimSpace(req.Description)
+	return nil
+}
+
+func (s *RoleService) getRole(ctx context.Context, id string) (*pgModel.Role, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrNotFound
+	}
+	role, err := s.roleRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *RoleService) getPermission(ctx context.Context, id string) (*pgModel.Permission, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrPermissionNotFound
+	}
+	p, err := s.permRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPermissionNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// resolvePermission finds a permission by id or by name (resource:action)
+func (s *RoleService) resolvePermission(ctx context.Context, ref string) (*pgModel.Permission, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, ErrPermissionRequired
+	}
+	if !strings.Contains(ref, ":") {
+		return s.getPermission(ctx, ref)
+	}
+	p, err := s.permRepo.GetByName(ctx, strings.ToLower(ref))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPermissionNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// permissionNames is the sorted set of permission names
+func permissionNames(perms []*pgModel.Permission) []string {
+	out := make([]string, 0, len(perms))
+	for _, p := range perms {
+		out = append(out, p.Name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func roleSnapshot(r *pgModel.Role, perms []*pgModel.Permission) map[string]interface{} {
+	return map[string]interface{}{
+		"name": r.Name, "description": r.Description, "parent_id": r.ParentID, "permissions": permissionNames(perms),
+	}
+}
+
+func permissionSnapshot(p *pgModel.Permission) map[string]interface{} {
+	return map[string]interface{}{
+		"name": p.Name, "resource": p.Resource, "action": p.Action, "description": p.Description,
+	}
+}
+
+// logPermissions records a change of a role's permission set
+func (s *RoleService) logPermissions(ctx context.Context, actor Actor, roleID, event, permission string, before, after []*pgModel.Permission) {
+	s.writeLog(ctx, &pgModel.ActivityLog{
+		ID:         uuid.New().String(),
+		EntityType: "role",
+		EntityID:   roleID,
+		EventType:  event,
+		ActorID:    &actor.UserID,
+		Previous:   map[string]interface{}{"permissions": permissionNames(before)},
+		Current:    map[string]interface{}{"permissions": permissionNames(after)},
+		Metadata:   map[string]interface{}{"permission": permission},
+		CreatedAt:  time.Now(),
+	})
+}
+
+func (s *RoleService) log(ctx context.Context, actor Actor, entityType, entityID, event string, previous, current map[string]interface{}) {
+	s.writeLog(ctx, &pgModel.ActivityLog{
+		ID:         uuid.New().String(),
+		EntityType: entityType,
+		EntityID:   entityID,
+		EventType:  event,
+		ActorID:    &actor.UserID,
+		Previous:   previous,
+		Current:    current,
+		CreatedAt:  time.Now(),
+	})
+}
+
+func (s *RoleService) writeLog(ctx context.Context, entry *pgModel.ActivityLog) {
+	if s.activityRepo == nil {
+		return
+	}
+	_ = s.activityRepo.Create(ctx, entry)
+}
@@ -0,0 +1,571 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mongoModel "clean-arch/app/model/mongo"
+	pgModel "clean-arch/app/model/postgre"
+	mongoRepo "clean-arch/app/repository/mongo"
+	pgRepo "clean-arch/app/repository/postgre"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidScoringRule = &CustomError{"invalid_scoring_rule", "category, level and non-negative points are required", 400}
+	ErrDuplicateRule      = &CustomError{"duplicate_rule", "a rule for this category, level and rank already exists", 409}
+)
+
+// recalcPageSize is how many verified achievements a recalculation loads at a time
+const recalcPageSize = 200
+
+// detailDateFields are the details keys (in order) that hold the date of an achievement
+var detailDateFields = []string{"date", "eventDate", "issuedAt", "publishedAt"}
+
+// ScoringService turns verified achievements into SKPI credit points. Points are stored on the
+// reference when it is verified; semester caps apply when totals are computed, so the order in
+// which achievements are verified does not matter. Rule and cap changes need scoring:manage,
+// checked by the routes.
+// Rule changes recalculate stored points in the background (StartRecalculator).
+type ScoringService struct {
+	repo             pgRepo.ScoringRuleRepository
+	achievementRefPG pgRepo.AchievementRefRepository
+	achievementMongo mongoRepo.AchievementRepository
+	activityRepo     pgRepo.ActivityLogRepository
+
+	recalc   chan struct{} // requests coalesce: one pending run covers them all
+	recalcMu sync.Mutex
+	status   RecalculationStatus
+}
+
+func NewScoringService(
+	repo pgRepo.ScoringRuleRepository,
+	achievementRefPG pgRepo.AchievementRefRepository,
+	achievementMongo mongoRepo.AchievementRepository,
+	activityRepo pgRepo.ActivityLogRepository,
+) *ScoringService {
+	return &ScoringService{
+		repo:             repo,
+		achievementRefPG: achievementRefPG,
+		achievementMongo: achievementMongo,
+		activityRepo:     activityRepo,
+		recalc:           make(chan struct{}, 1),
+	}
+}
+
+// ScoringRuleRequest is the body of POST/PUT /scoring/rules.
+type ScoringRuleRequest struct {
+	Category    string  `json:"category"`
+	Level       string  `json:"level"`
+	Rank        string  `json:"rank"`
+	Points      float64 `json:"points"`
+	Description string  `json:"description"`
+}
+
+// Score is the result of applying the rules to one achievement.
+type Score struct {
+	Points   float64
+	RuleID   *string
+	Semester string
+}
+
+// RecalculationResult reports what a recalculation changed.
+type RecalculationResult struct {
+	Checked    int        `json:"checked"` // verified achievements looked at
+	Changed    int        `json:"changed"` // achievements whose points changed
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// RecalculationStatus is the state of the background recalculation.
+type RecalculationStatus struct {
+	Queued  bool                 `json:"queued"` // a run starts once the current one (if any) ends
+	Running bool                 `json:"running"`
+	Last    *RecalculationResult `json:"last,omitempty"` // the run in progress, or the latest one
+}
+
+// SemesterPoints are a student's points in one semester, raw and after the category caps.
+type SemesterPoints struct {
+	Semester   string             `json:"semester"`
+	Raw        float64            `json:"raw"`
+	Awarded    float64            `json:"awarded"`
+	ByCategory map[string]float64 `json:"by_category"` // awarded, after caps
+}
+
+// StudentPoints are the SKPI point totals of a student.
+type StudentPoints struct {
+	Total      float64           `json:"total"`
+	Uncapped   float64           `json:"uncapped"`
+	BySemester []*SemesterPoints `json:"by_semester"`
+}
+
+func (s *ScoringService) ListRules(ctx context.Context) ([]*pgModel.ScoringRule, error) {
+	return s.repo.ListRules(ctx)
+}
+
+func (s *ScoringService) ListCaps(ctx context.Context) ([]*pgModel.PointCap, error) {
+	return s.repo.ListCaps(ctx)
+}
+
+// CreateRule adds a rule and queues a recalculation of the points of verified achievements.
+func (s *ScoringService) CreateRule(ctx context.Context, actor Actor, req ScoringRuleRequest) (*pgModel.ScoringRule, *RecalculationStatus, error) {
+	rule := &pgModel.ScoringRule{ID: uuid.New().String(), UpdatedBy: &actor.UserID}
+	if err := s.applyRequest(ctx, rule, req); err != nil {
+		return nil, nil, err
+	}
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return nil, nil, err
+	}
+	s.log(ctx, actor, "scoring_rule", rule.ID, "created", nil, ruleSnapshot(rule))
+	return rule, s.queueRecalculation(), nil
+}
+
+// UpdateRule changes a rule and queues a recalculation.
+func (s *ScoringService) UpdateRule(ctx context.Context, actor Actor, id string, req ScoringRuleRequest) (*pgModel.ScoringRule, *RecalculationStatus, error) {
+	rule, err := s.getRule(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	previous := ruleSnapshot(rule)
+	rule.UpdatedBy = &actor.UserID
+	if err := s.applyRequest(ctx, rule, req); err != nil {
+		return nil, nil, err
+	}
+	if err := s.repo.UpdateRule(ctx, rule); err != nil {
+		return nil, nil, err
+	}
+	s.log(ctx, actor, "scoring_rule", rule.ID, "updated", previous, ruleSnapshot(rule))
+	return rule, s.queueRecalculation(), nil
+}
+
+// DeleteRule removes a rule and queues a recalculation.
+func (s *ScoringService) DeleteRule(ctx context.Context, actor Actor, id string) (*RecalculationStatus, error) {
+	rule, err := s.getRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteRule(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	s.log(ctx, actor, "scoring_rule", id, "deleted", ruleSnapshot(rule), nil)
+	return s.queueRecalculation(), nil
+}
+
+// PutCap sets the per-semester cap of a category. Caps only affect totals,
+// so no recalculation is needed.
+func (s *ScoringService) PutCap(ctx context.Context, actor Actor, category string, maxPoints float64) (*pgModel.PointCap, error) {
+	category = normalizeScoringKey(category)
+	if category == "" || maxPoints < 0 || math.IsNaN(maxPoints) {
+		return nil, &CustomError{"invalid_point_cap", "category and a non-negative max_points are required", 400}
+	}
+	c := &pgModel.PointCap{Category: category, MaxPoints: maxPoints, UpdatedBy: &actor.UserID}
+	if err := s.repo.UpsertCap(ctx, c); err != nil {
+		return nil, err
+	}
+	s.log(ctx, actor, "point_cap", category, "updated", nil, map[string]interface{}{"max_points": maxPoints})
+	return c, nil
+}
+
+// DeleteCap removes the cap of a category.
+func (s *ScoringService) DeleteCap(ctx context.Context, actor Actor, category string) error {
+	category = normalizeScoringKey(category)
+	if err := s.repo.DeleteCap(ctx, category); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		return err
+	}
+	s.log(ctx, actor, "point_cap", category, "deleted", nil, nil)
+	return nil
+}
+
+// Score applies the current rules to an achievement document; verifiedAt dates achievements
+// whose details carry no date.
+func (s *ScoringService) Score(ctx context.Context, doc *mongoModel.Achievement, verifiedAt time.Time) (*Score, error) {
+	rules, err := s.repo.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return scoreDocument(rules, doc, verifiedAt), nil
+}
+
+// Recalculate queues a recalculation of the points of every verified achievement on request.
+func (s *ScoringService) Recalculate(ctx context.Context, actor Actor) *RecalculationStatus {
+	s.log(ctx, actor, "scoring_rule", "*", "recalculation_requested", nil, nil)
+	return s.queueRecalculation()
+}
+
+// RecalculationStatus reports the background recalculation.
+func (s *ScoringService) RecalculationStatus() *RecalculationStatus {
+	s.recalcMu.Lock()
+	defer s.recalcMu.Unlock()
+	return s.statusLocked()
+}
+
+// StartRecalculator runs queued recalculations until ctx is cancelled.
+func (s *ScoringService) StartRecalculator(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.recalc:
+				s.runRecalculation(ctx)
+			}
+		}
+	}()
+}
+
+func (s *ScoringService) queueRecalculation() *RecalculationStatus {
+	s.recalcMu.Lock()
+	defer s.recalcMu.Unlock()
+	select {
+	case s.recalc <- struct{}{}:
+	default: // already queued
+	}
+	s.status.Queued = true
+	return s.statusLocked()
+}
+
+// statusLocked copies the status; callers hold recalcMu
+func (s *ScoringService) statusLocked() *RecalculationStatus {
+	st := s.status
+	if st.Last != nil {
+		last := *st.Last
+		st.Last = &last
+	}
+	return &st
+}
+
+func (s *ScoringService) runRecalculation(ctx context.Context) {
+	res := &RecalculationResult{StartedAt: time.Now()}
+	s.recalcMu.Lock()
+	s.status = RecalculationStatus{Running: true, Last: res}
+	s.recalcMu.Unlock()
+
+	err := s.recalculate(ctx, res)
+
+	s.recalcMu.Lock()
+	now := time.Now()
+	res.FinishedAt = &now
+	if err != nil {
+		res.Error = err.Error()
+	}
+	s.status.Running = false
+	s.status.Queued = len(s.recalc) > 0
+	s.recalcMu.Unlock()
+
+	if err != nil {
+		log.Printf("points recalculation failed after %d achievements: %v", res.Checked, err)
+		return
+	}
+	log.Printf("points recalculation: %d achievements checked, %d changed", res.Checked, res.Changed)
+}
+
+// recalculate re-applies the rules to every verified achievement, a page at a time, and stores
+// changed points; res is updated (under recalcMu) after every page.
+func (s *ScoringService) recalculate(ctx context.Context, res *RecalculationResult) error {
+	rules, err := s.repo.ListRules(ctx)
+	if err != nil {
+		return err
+	}
+	f := pgRepo.AchievementRefFilter{
+		Statuses: []string{"verified", pgModel.AchievementExpired}, // expired certificates keep their points
+		Limit:    recalcPageSize,
+	}
+	for {
+		pctx, cancel := context.WithTimeout(ctx, time.Minute)
+		refs, checked, changed, err := s.recalculatePage(pctx, rules, f)
+		cancel()
+		s.recalcMu.Lock()
+		res.Checked += checked
+		res.Changed += changed
+		s.recalcMu.Unlock()
+		if err != nil || len(refs) < recalcPageSize {
+			return err
+		}
+		last := refs[len(refs)-1]
+		after := f.SortValue(last)
+		f.AfterValue, f.AfterID = &after, last.ID
+	}
+}
+
+// recalculatePage scores one page of the filter and returns it with the counts
+func (s *ScoringService) recalculatePage(ctx context.Context, rules []*pgModel.ScoringRule, f pgRepo.AchievementRefFilter) ([]*pgModel.AchievementReference, int, int, error) {
+	refs, err := s.achievementRefPG.Search(ctx, f)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(refs))
+	for _, ref := range refs {
+		if oid, err := primitive.ObjectIDFromHex(ref.MongoAchievementID); err == nil {
+			ids = append(ids, oid)
+		}
+	}
+	docs, err := s.achievementMongo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	checked, changed := 0, 0
+	for _, ref := range refs {
+		doc, ok := docs[ref.MongoAchievementID]
+		if !ok {
+			continue
+		}
+		checked++
+		verifiedAt := ref.UpdatedAt
+		if ref.VerifiedAt != nil {
+			verifiedAt = *ref.VerifiedAt
+		}
+		score := scoreDocument(rules, doc, verifiedAt)
+		if sameScore(ref, score) {
+			continue
+		}
+		score.applyTo(ref)
+		if err := s.achievementRefPG.UpdatePoints(ctx, ref.ID, ref.Points, ref.PointsRuleID, ref.PointsSemester); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue // no longer verified
+			}
+			return refs, checked, changed, err
+		}
+		ref.Version++
+		changed++
+	}
+	return refs, checked, changed, nil
+}
+
+// Totals sums the points of the verified achievements among refs per semester and applies the
+// category caps. Points count under the category of the rule that awarded them.
+func (s *ScoringService) Totals(ctx context.Context, refs []*pgModel.AchievementReference) (*StudentPoints, error) {
+	rules, err := s.repo.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	caps, err := s.repo.ListCaps(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ruleCategory := make(map[string]string, len(rules))
+	for _, r := range rules {
+		ruleCategory[r.ID] = r.Category
+	}
+	capOf := make(map[string]float64, len(caps))
+	for _, c := range caps {
+		capOf[c.Category] = c.MaxPoints
+	}
+
+	// semester -> category -> raw points
+	raw := map[string]map[string]float64{}
+	for _, ref := range refs {
+		if ref.Status != "verified" || ref.Points == nil || ref.PointsRuleID == nil || ref.PointsSemester == nil {
+			continue
+		}
+		category, ok := ruleCategory[*ref.PointsRuleID]
+		if !ok {
+			continue
+		}
+		if raw[*ref.PointsSemester] == nil {
+			raw[*ref.PointsSemester] = map[string]float64{}
+		}
+		raw[*ref.PointsSemester][category] += *ref.Points
+	}
+
+	out := &StudentPoints{BySemester: []*SemesterPoints{}}
+	for semester, byCategory := range raw {
+		sp := &SemesterPoints{Semester: semester, ByCategory: map[string]float64{}}
+		for category, points := range byCategory {
+			awarded := points
+			if limit, ok := capOf[category]; ok && awarded > limit {
+				awarded = limit
+			}
+			sp.Raw += points
+			sp.Awarded += awarded
+			sp.ByCategory[category] = awarded
+		}
+		out.Total += sp.Awarded
+		out.Uncapped += sp.Raw
+		out.BySemester = append(out.BySemester, sp)
+	}
+	sort.Slice(out.BySemester, func(i, j int) bool { return out.BySemester[i].Semester < out.BySemester[j].Semester })
+	return out, nil
+}
+
+// scoreDocument picks the rule for the document's category and level, preferring the one for
+// its exact rank over the "any rank" rule. No matching rule means no points.
+func scoreDocument(rules []*pgModel.ScoringRule, doc *mongoModel.Achievement, verifiedAt time.Time) *Score {
+	category := normalizeScoringKey(doc.Category)
+	level := normalizeScoringKey(doc.Level)
+	rank := ""
+	if doc.Details != nil {
+		rank = rankString(doc.Details["rank"])
+	}
+
+	var match *pgModel.ScoringRule
+	for _, r := range rules {
+		if r.Category != category || r.Level != level {
+			continue
+		}
+		if r.Rank == rank && rank != "" {
+			match = r
+			break
+		}
+		if r.Rank == "" {
+			match = r
+		}
+	}
+
+	score := &Score{Semester: semesterOf(achievementDate(doc, verifiedAt))}
+	if match != nil {
+		id := match.ID
+		score.Points = match.Points
+		score.RuleID = &id
+	}
+	return score
+}
+
+func (sc *Score) applyTo(ref *pgModel.AchievementReference) {
+	points, semester := sc.Points, sc.Semester
+	ref.Points = &points
+	ref.PointsRuleID = sc.RuleID
+	ref.PointsSemester = &semester
+}
+
+func sameScore(ref *pgModel.AchievementReference, sc *Score) bool {
+	if ref.Points == nil || *ref.Points != sc.Points || ref.PointsSemester == nil || *ref.PointsSemester != sc.Semester {
+		return false
+	}
+	if (ref.PointsRuleID == nil) != (sc.RuleID == nil) {
+		return false
+	}
+	return ref.PointsRuleID == nil || *ref.PointsRuleID == *sc.RuleID
+}
+
+// achievementDate reads the achievement date from its details, falling back to verifiedAt
+func achievementDate(doc *mongoModel.Achievement, verifiedAt time.Time) time.Time {
+	if t, ok := detailDate(doc); ok {
+		return t
+	}
+	return verifiedAt
+}
+
+// detailDate returns the first date found in detailDateFields (YYYY-MM-DD or RFC3339)
+func detailDate(doc *mongoModel.Achievement) (time.Time, bool) {
+	for _, k := range detailDateFields {
+		v, ok := doc.Details[k].(string)
+		if !ok {
+			continue
+		}
+		if t, err := time.Parse("2006-01-02", v); err == nil {
+			return t, true
+		}
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// semesterOf names the academic semester of t: August-January is the odd semester (1),
+// February-July the even one (2), e.g. 2025/2026-1.
+func semesterOf(t time.Time) string {
+	year, month := t.Year(), t.Month()
+	switch {
+	case month >= time.August:
+		return fmt.Sprintf("%d/%d-1", year, year+1)
+	case month == time.January:
+		return fmt.Sprintf("%d/%d-1", year-1, year)
+	default:
+		return fmt.Sprintf("%d/%d-2", year-1, year)
+	}
+}
+
+// rankString turns details.rank (a number after JSON decoding, or a string) into a rule key
+func rankString(v interface{}) string {
+	switch r := v.(type) {
+	case string:
+		return normalizeScoringKey(r)
+	case float64:
+		return strconv.FormatFloat(r, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(r)
+	case int32:
+		return strconv.Itoa(int(r))
+	case int64:
+		return strconv.FormatInt(r, 10)
+	}
+	return ""
+}
+
+func normalizeScoringKey(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// applyRequest validates req and copies it into rule; (category, level, rank) must stay unique
+func (s *ScoringService) applyRequest(ctx context.Context, rule *pgModel.ScoringRule, req ScoringRuleRequest) error {
+	category, level, rank := normalizeScoringKey(req.Category), normalizeScoringKey(req.Level), normalizeScoringKey(req.Rank)
+	if category == "" || level == "" || req.Points < 0 || math.IsNaN(req.Points) {
+		return ErrInvalidScoringRule
+	}
+	rules, err := s.repo.ListRules(ctx)
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		if r.ID != rule.ID && r.Category == category && r.Level == level && r.Rank == rank {
+			return ErrDuplicateRule
+		}
+	}
+	rule.Category, rule.Level, rule.Rank = category, level, rank
+	rule.Points = req.Points
+	rule.Description = req.Description
+	return nil
+}
+
+func (s *ScoringService) getRule(ctx context.Context, id string) (*pgModel.ScoringRule, error) {
+	rule, err := s.repo.GetRule(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+func ruleSnapshot(r *pgModel.ScoringRule) map[string]interface{} {
+	return map[string]interface{}{
+		"category": r.Category, "level": r.Level, "rank": r.Rank, "points": r.Points,
+	}
+}
+
+func (s *ScoringService) log(ctx context.Context, actor Actor, entityType, entityID, event string, previous, current map[string]interface{}) {
+	if s.activityRepo == nil {
+		return
+	}
+	_ = s.activityRepo.Create(ctx, &pgModel.ActivityLog{
+		ID:         uuid.New().String(),
+		EntityType: entityType,
+		EntityID:   entityID,
+		EventType:  event,
+		ActorID:    &actor.UserID,
+		Previous:   previous,
+		Current:    current,
+		CreatedAt:  time.Now(),
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	mongoRepo "clean-arch/app/repository/mongo"
 	pgRepo "clean-arch/app/repository/postgre"
+	"clean-arch/app/storage"
 )
 
 // Repos set of repo interfaces needed to create services
@@ -21,6 +22,18 @@ type Repos struct {
 	AchievementRepo    mongoRepo.AchievementRepository
 	ActivityLogRepo    pgRepo.ActivityLogRepository // Pastikan ini ada
 	TokenRepo          TokenRepository
+	SessionRepo        pgRepo.SessionRepository
+	DelegationRepo     pgRepo.DelegationRepository
+	ReviewRoundRepo    pgRepo.ReviewRoundRepository
+	MemberRepo         pgRepo.AchievementMemberRepository
+	CommentRepo        pgRepo.AchievementCommentRepository
+	AttachmentRepo     pgRepo.AchievementAttachmentRepository
+	OutboxRepo         pgRepo.OutboxRepository
+	SchemaRepo         pgRepo.AchievementSchemaRepository
+	ScoringRepo        pgRepo.ScoringRuleRepository
+	TrashRepo          pgRepo.TrashRepository
+	Transactor         pgRepo.Transactor
+	Storage            storage.Storage // attachment files
 }
 
 type Services struct {
@@ -31,22 +44,50 @@ type Services struct {
 	Student     *StudentService
 	Lecturer    *LecturerService
 	Report      *ReportService
+	Delegation  *DelegationService
+	Schema      *AchievementSchemaService
+	Scoring     *ScoringService
+	Trash       *TrashService
+	Role        *RoleService
 }
 
-func NewServices(db *sql.DB, mongoDB *mongodriver.Database, repos *Repos) *Services {
+// workflow is the achievement state machine (see LoadWorkflow); nil means DefaultWorkflow.
+// attachmentURLSecret signs attachment download links (ATTACHMENT_URL_SECRET).
+func NewServices(db *sql.DB, mongoDB *mongodriver.Database, repos *Repos, workflow *WorkflowDefinition, attachmentURLSecret string) *Services {
 	// ... (kode lain tetap sama)
 
+	policy := NewAccessPolicy(repos.RoleRepo, repos.StudentRepo, repos.LecturerRepo, repos.DelegationRepo, repos.MemberRepo)
+	rbacSvc := NewRBACService(repos.RolePermissionRepo, repos.PermissionRepo, repos.RoleRepo)
+	roleSvc := NewRoleService(repos.RoleRepo, repos.PermissionRepo, repos.RolePermissionRepo, repos.UserRepo, repos.ActivityLogRepo, rbacSvc)
+	schemaSvc := NewAchievementSchemaService(repos.SchemaRepo, repos.ActivityLogRepo)
+	scoringSvc := NewScoringService(repos.ScoringRepo, repos.AchievementRefRepo, repos.AchievementRepo, repos.ActivityLogRepo)
+
 	achSvc := NewAchievementService(
 		repos.AchievementRepo,
 		repos.AchievementRefRepo,
 		repos.StudentRepo,
+		repos.LecturerRepo,
+		repos.DelegationRepo,
 		repos.UserRepo,
 		repos.ActivityLogRepo,
+		repos.ReviewRoundRepo,
+		repos.MemberRepo,
+		repos.CommentRepo,
+		repos.AttachmentRepo,
+		repos.OutboxRepo,
+		repos.Transactor,
+		policy,
+		workflow,
+		rbacSvc.HasPermissionByRoleID,
+		repos.Storage,
+		schemaSvc,
+		scoringSvc,
+		[]byte(attachmentURLSecret),
 	)
 
-	userSvc := NewUserService(repos.UserRepo)
-	authSvc := NewAuthService(repos.UserRepo, repos.TokenRepo)
-	rbacSvc := NewRBACService(repos.RolePermissionRepo, repos.PermissionRepo, repos.RoleRepo)
+	trashSvc := NewTrashService(repos.TrashRepo, repos.AchievementRefRepo, achSvc, repos.ActivityLogRepo)
+	userSvc := NewUserService(repos.UserRepo, trashSvc)
+	authSvc := NewAuthService(repos.UserRepo, repos.TokenRepo, repos.SessionRepo)
 	studentSvc := NewStudentService(repos.StudentRepo)
 	lecturerSvc := NewLecturerService(repos.LecturerRepo)
 
@@ -56,8 +97,14 @@ func NewServices(db *sql.DB, mongoDB *mongodriver.Database, repos *Repos) *Servi
 		repos.StudentRepo,
 		repos.LecturerRepo,
 		repos.ActivityLogRepo, // <-- Masukkan dependency ActivityLogRepo
+		repos.ReviewRoundRepo,
+		repos.MemberRepo,
+		scoringSvc,
+		policy,
 	)
 
+	delegationSvc := NewDelegationService(repos.DelegationRepo, repos.LecturerRepo, repos.ActivityLogRepo, policy)
+
 	return &Services{
 		Achievement: achSvc,
 		User:        userSvc,
@@ -66,5 +113,10 @@ func NewServices(db *sql.DB, mongoDB *mongodriver.Database, repos *Repos) *Servi
 		Student:     studentSvc,
 		Lecturer:    lecturerSvc,
 		Report:      reportSvc,
+		Delegation:  delegationSvc,
+		Schema:      schemaSvc,
+		Scoring:     scoringSvc,
+		Trash:       trashSvc,
+		Role:        roleSvc,
 	}
 }
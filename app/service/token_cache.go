@@ -0,0 +1,113 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	pgModel "clean-arch/app/model/postgre"
+)
+
+const (
+	revocationSyncInterval = 15 * time.Second
+	revocationMaxStaleness = 2 * revocationSyncInterval
+)
+
+// revocationCache keeps revoked jti values in memory so the JWT middleware
+// does not query token_blacklist on every request. The sweeper re-syncs it
+// from the database every revocationSyncInterval, so a logout on another
+// instance is honoured here after at most that long. While the last sync is
+// older than revocationMaxStaleness (sweeper not running, database errors)
+// the cache is not trusted and VerifyToken falls back to the database.
+type revocationCache struct {
+	mu       sync.RWMutex
+	revoked  map[string]time.Time // jti -> token expiry
+	syncedAt time.Time
+}
+
+func newRevocationCache() *revocationCache {
+	return &revocationCache{revoked: make(map[string]time.Time)}
+}
+
+func (c *revocationCache) Add(jti string, expiresAt time.Time) {
+	c.mu.Lock()
+	c.revoked[jti] = expiresAt
+	c.mu.Unlock()
+}
+
+func (c *revocationCache) Contains(jti string) bool {
+	c.mu.RLock()
+	_, ok := c.revoked[jti]
+	c.mu.RUnlock()
+	return ok
+}
+
+// Replace swaps the whole set with a fresh snapshot from the database,
+// keeping local entries that are newer than the snapshot.
+func (c *revocationCache) Replace(snapshot map[string]time.Time) {
+	now := time.Now()
+	c.mu.Lock()
+	for jti, exp := range c.revoked {
+		if _, ok := snapshot[jti]; !ok && exp.After(now) {
+			snapshot[jti] = exp
+		}
+	}
+	c.revoked = snapshot
+	c.syncedAt = now
+	c.mu.Unlock()
+}
+
+// Fresh reports whether the last database sync is at most maxAge old.
+func (c *revocationCache) Fresh(maxAge time.Duration) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.syncedAt.IsZero() && time.Since(c.syncedAt) <= maxAge
+}
+
+// Prune drops entries whose tokens have expired anyway.
+func (c *revocationCache) Prune(now time.Time) {
+	c.mu.Lock()
+	for jti, exp := range c.revoked {
+		if !exp.After(now) {
+			delete(c.revoked, jti)
+		}
+	}
+	c.mu.Unlock()
+}
+
+// userStateCache caches is_active / tokens_valid_after per user for a short TTL.
+type userStateCache struct {
+	mu    sync.RWMutex
+	ttl   time.Duration
+	items map[string]cachedUserState
+}
+
+type cachedUserState struct {
+	state     *pgModel.UserAuthState
+	fetchedAt time.Time
+}
+
+func newUserStateCache(ttl time.Duration) *userStateCache {
+	return &userStateCache{ttl: ttl, items: make(map[string]cachedUserState)}
+}
+
+func (c *userStateCache) Get(userID string) (*pgModel.UserAuthState, bool) {
+	c.mu.RLock()
+	item, ok := c.items[userID]
+	c.mu.RUnlock()
+	if !ok || time.Since(item.fetchedAt) > c.ttl {
+		return nil, false
+	}
+	return item.state, true
+}
+
+func (c *userStateCache) Set(st *pgModel.UserAuthState) {
+	c.mu.Lock()
+	c.items[st.UserID] = cachedUserState{state: st, fetchedAt: time.Now()}
+	c.mu.Unlock()
+}
+
+func (c *userStateCache) Invalidate(userID string) {
+	c.mu.Lock()
+	delete(c.items, userID)
+	c.mu.Unlock()
+}
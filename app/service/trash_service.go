@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	pgModel "clean-arch/app/model/postgre"
+	pgRepo "clean-arch/app/repository/postgre"
+
+	"github.com/google/uuid"
+)
+
+// defaultTrashRetentionDays is how long trashed rows are kept before the purger removes them
+// (TRASH_RETENTION_DAYS)
+const defaultTrashRetentionDays = 30
+
+var (
+	ErrUnknownTrashEntity = &CustomError{"unknown_trash_entity", "unknown trash entity", 400}
+	ErrTrashSelf          = &CustomError{"trash_self", "you cannot delete your own account", 409}
+	ErrOwnerInTrash       = &CustomError{"owner_in_trash", "restore the user account first", 409}
+)
+
+// trashEntityTypes maps a trash entity to the entity_type of its activity logs
+var trashEntityTypes = map[string]string{
+	pgModel.TrashUsers:        "user",
+	pgModel.TrashStudents:     "student",
+	pgModel.TrashLecturers:    "lecturer",
+	pgModel.TrashAchievements: "achievement_reference",
+}
+
+// TrashService is the trash of users, students, lecturers and achievements. Deleting moves a row
+// to the trash; holders of trash:manage (checked by the routes) can list, restore or purge it,
+// and rows older than the retention window are purged automatically (StartTrashPurger). Achievements enter the trash through the workflow
+// (DeleteDraft) rather than MoveToTrash.
+type TrashService struct {
+	repo             pgRepo.TrashRepository
+	achievementRefPG pgRepo.AchievementRefRepository
+	achievements     *AchievementService
+	activityRepo     pgRepo.ActivityLogRepository
+	retention        time.Duration
+}
+
+func NewTrashService(
+	repo pgRepo.TrashRepository,
+	achievementRefPG pgRepo.AchievementRefRepository,
+	achievements *AchievementService,
+	activityRepo pgRepo.ActivityLogRepository,
+) *TrashService {
+	days := defaultTrashRetentionDays
+	if n, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS")); err == nil && n > 0 {
+		days = n
+	}
+	return &TrashService{
+		repo:             repo,
+		achievementRefPG: achievementRefPG,
+		achievements:     achievements,
+		activityRepo:     activityRepo,
+		retention:        time.Duration(days) * 24 * time.Hour,
+	}
+}
+
+// MoveToTrash soft-deletes a user, student or lecturer. A user takes their profiles with them.
+// A non-nil ifMatch (users only) is checked by the delete itself (ErrPreconditionFailed).
+func (s *TrashService) MoveToTrash(ctx context.Context, actor Actor, entity string, id string, ifMatch *int) error {
+	if _, ok := trashEntityTypes[entity]; !ok || entity == pgModel.TrashAchievements {
+		return ErrUnknownTrashEntity
+	}
+	if entity == pgModel.TrashUsers && id == actor.UserID {
+		return ErrTrashSelf
+	}
+	if err := s.repo.SoftDelete(ctx, entity, id, actor.UserID, time.Now(), ifMatch); err != nil {
+		return trashError(err)
+	}
+	s.log(ctx, actor, entity, id, "trashed")
+	return nil
+}
+
+// List returns the trash of one entity, newest first, with the time each row will be purged.
+func (s *TrashService) List(ctx context.Context, entity string) ([]*pgModel.TrashItem, error) {
+	items, err := s.list(ctx, entity, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		item.PurgeAt = item.DeletedAt.Add(s.retention)
+	}
+	return items, nil
+}
+
+// Restore takes a row out of the trash. Students and lecturers whose user account is still in
+// the trash cannot be restored on their own; achievements go back to the status they were
+// deleted from.
+func (s *TrashService) Restore(ctx context.Context, actor Actor, entity string, id string) error {
+	switch entity {
+	case pgModel.TrashAchievements:
+		if _, err := s.achievements.restoreFromTrash(ctx, id); err != nil {
+			return err
+		}
+	case pgModel.TrashStudents, pgModel.TrashLecturers:
+		item, err := s.repo.Get(ctx, entity, id)
+		if err != nil {
+			return trashError(err)
+		}
+		if item.OwnerID != nil {
+			if _, err := s.repo.Get(ctx, pgModel.TrashUsers, *item.OwnerID); err == nil {
+				return ErrOwnerInTrash
+			} else if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+		if err := s.repo.Restore(ctx, entity, id); err != nil {
+			return trashError(err)
+		}
+	default:
+		if err := s.repo.Restore(ctx, entity, id); err != nil {
+			return trashError(err)
+		}
+	}
+	s.log(ctx, actor, entity, id, "trash_restored")
+	return nil
+}
+
+// Purge deletes a trashed row for good. Purging a user or student also purges the student's
+// achievements, including their documents and attachment files.
+func (s *TrashService) Purge(ctx context.Context, actor Actor, entity string, id string) error {
+	if err := s.purge(ctx, entity, id); err != nil {
+		return err
+	}
+	s.log(ctx, actor, entity, id, "purged")
+	return nil
+}
+
+// TrashPurgeResult reports one run of PurgeExpired, per entity.
+type TrashPurgeResult struct {
+	Purged map[string]int `json:"purged"`
+}
+
+// PurgeExpired purges everything that has been in the trash longer than the retention window.
+// Achievements go first so that purging their owners finds less to cascade.
+func (s *TrashService) PurgeExpired(ctx context.Context) (*TrashPurgeResult, error) {
+	res := &TrashPurgeResult{Purged: map[string]int{}}
+	before := time.Now().Add(-s.retention)
+	for _, entity := range []string{pgModel.TrashAchievements, pgModel.TrashStudents, pgModel.TrashLecturers, pgModel.TrashUsers} {
+		items, err := s.list(ctx, entity, &before)
+		if err != nil {
+			return res, err
+		}
+		for _, item := range items {
+			if err := s.purge(ctx, entity, item.ID); err != nil {
+				if errors.Is(err, ErrNotFound) {
+					continue // purged with its owner
+				}
+				return res, err
+			}
+			res.Purged[entity]++
+			s.writeLog(ctx, &pgModel.ActivityLog{
+				ID:         uuid.New().String(),
+				EntityType: trashEntityTypes[entity],
+				EntityID:   item.ID,
+				EventType:  "purged",
+				Metadata:   map[string]interface{}{"retention_days": int(s.retention.Hours() / 24)},
+				CreatedAt:  time.Now(),
+			})
+		}
+	}
+	return res, nil
+}
+
+// StartTrashPurger runs PurgeExpired now and then every interval until ctx is cancelled.
+func (s *TrashService) StartTrashPurger(ctx context.Context, interval time.Duration) {
+	run := func() {
+		rctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		defer cancel()
+		res, err := s.PurgeExpired(rctx)
+		if err != nil {
+			log.Printf("trash purge failed: %v", err)
+			return
+		}
+		for entity, n := range res.Purged {
+			log.Printf("trash purge: %d %s older than %s removed", n, entity, s.retention)
+		}
+	}
+	go func() {
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
+
+func (s *TrashService) list(ctx context.Context, entity string, before *time.Time) ([]*pgModel.TrashItem, error) {
+	if entity == pgModel.TrashAchievements {
+		return s.achievements.trashedAchievements(ctx, before)
+	}
+	items, err := s.repo.List(ctx, entity, before)
+	return items, trashError(err)
+}
+
+func (s *TrashService) purge(ctx context.Context, entity string, id string) error {
+	switch entity {
+	case pgModel.TrashAchievements:
+		ref, err := s.achievements.trashedRef(ctx, id)
+		if err != nil {
+			return err
+		}
+		return s.achievements.purgeAchievements(ctx, []*pgModel.AchievementReference{ref}, nil)
+	case pgModel.TrashUsers, pgModel.TrashStudents:
+		if _, err := s.repo.Get(ctx, entity, id); err != nil {
+			return trashError(err)
+		}
+		studentIDs := []string{id}
+		if entity == pgModel.TrashUsers {
+			ids, err := s.repo.StudentIDsOfUser(ctx, id)
+			if err != nil {
+				return err
+			}
+			studentIDs = ids
+		}
+		// the rows cascade with the owner; their documents go through the outbox
+		refs := []*pgModel.AchievementReference{}
+		for _, studentID := range studentIDs {
+			owned, err := s.achievementRefPG.ListByStudent(ctx, studentID)
+			if err != nil {
+				return err
+			}
+			refs = append(refs, owned...)
+		}
+		return trashError(s.achievements.purgeAchievements(ctx, refs, func(tx *sql.Tx) error {
+			return s.repo.WithTx(tx).Purge(ctx, entity, id)
+		}))
+	default:
+		return trashError(s.repo.Purge(ctx, entity, id))
+	}
+}
+
+// trashError maps repository errors to service errors
+func trashError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case errors.Is(err, pgRepo.ErrUnknownTrashEntity):
+		return ErrUnknownTrashEntity
+	case errors.Is(err, pgRepo.ErrStaleVersion):
+		return ErrPreconditionFailed
+	}
+	return err
+}
+
+func (s *TrashService) log(ctx context.Context, actor Actor, entity, id, event string) {
+	s.writeLog(ctx, &pgModel.ActivityLog{
+		ID:         uuid.New().String(),
+		EntityType: trashEntityTypes[entity],
+		EntityID:   id,
+		EventType:  event,
+		ActorID:    &actor.UserID,
+		CreatedAt:  time.Now(),
+	})
+}
+
+func (s *TrashService) writeLog(ctx context.Context, entry *pgModel.ActivityLog) {
+	if s.activityRepo == nil {
+		return
+	}
+	_ = s.activityRepo.Create(ctx, entry)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"time"
 
@@ -13,10 +14,11 @@ import (
 
 type UserService struct {
 	userRepo pgRepo.UserRepository
+	trash    *TrashService
 }
 
-func NewUserService(userRepo pgRepo.UserRepository) *UserService {
-	return &UserService{userRepo: userRepo}
+func NewUserService(userRepo pgRepo.UserRepository, trash *TrashService) *UserService {
+	return &UserService{userRepo: userRepo, trash: trash}
 }
 
 // Register creates a new user (password hashing done in AuthService)
@@ -40,22 +42,45 @@ func (s *UserService) GetByUsername(ctx context.Context, username string) (*pgMo
 	return s.userRepo.GetByUsername(ctx, username)
 }
 
-func (s *UserService) Update(ctx context.Context, u *pgModel.User) error {
-	u.UpdatedAt = time.Now()
-	return s.userRepo.Update(ctx, u)
+// Update replaces the profile fields of u.ID; the password and role are kept (roles change
+// through RoleService, which checks user:assign_role and the role:manage lockout). ifMatch is
+// the user version the caller expects (nil skips the check); u.Version is the new version afterwards.
+func (s *UserService) Update(ctx context.Context, u *pgModel.User, ifMatch *int) error {
+	current, err := s.loadUser(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	if ifMatch != nil && *ifMatch != current.Version {
+		return ErrPreconditionFailed
+	}
+	u.PasswordHash = current.PasswordHash
+	u.RoleID = current.RoleID
+	u.Version = current.Version
+	if err := s.userRepo.Update(ctx, u); err != nil {
+		if errors.Is(err, pgRepo.ErrStaleVersion) {
+			return ErrPreconditionFailed
+		}
+		return err
+	}
+	return nil
 }
 
-func (s *UserService) Delete(ctx context.Context, id string) error {
-	return s.userRepo.Delete(ctx, id)
+// Delete moves the user to the trash; ifMatch is the version the caller expects (nil skips the check).
+func (s *UserService) Delete(ctx context.Context, actor Actor, id string, ifMatch *int) error {
+	return s.trash.MoveToTrash(ctx, actor, pgModel.TrashUsers, id, ifMatch)
 }
 
-func (s *UserService) ListAll(ctx context.Context) ([]*pgModel.User, error) {
-	return s.userRepo.ListAll(ctx)
+func (s *UserService) loadUser(ctx context.Context, id string) (*pgModel.User, error) {
+	u, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return u, nil
 }
 
-func (s *UserService) UpdateRole(ctx context.Context, userID string, roleID string) error {
-	if userID == "" || roleID == "" {
-		return errors.New("user_id and role_id are required")
-	}
-	return s.userRepo.UpdateRole(ctx, userID, roleID)
+func (s *UserService) ListAll(ctx context.Context) ([]*pgModel.User, error) {
+	return s.userRepo.ListAll(ctx)
 }
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// WorkflowDefinition is the achievement state machine, loaded from config/workflow.json.
+// Guards and side effects are referenced by name and implemented in achievement_workflow.go.
+type WorkflowDefinition struct {
+	InitialState string               `json:"initial_state"`
+	States       []WorkflowState      `json:"states"`
+	Transitions  []WorkflowTransition `json:"transitions"`
+}
+
+type WorkflowState struct {
+	Name     string `json:"name"`
+	Label    string `json:"label"`
+	Editable bool   `json:"editable"` // owner may still change the document (PUT /achievements/:id)
+}
+
+// WorkflowTransition moves an achievement from one of From to To when the caller
+// has Permission and every guard passes; effects run in the listed order.
+type WorkflowTransition struct {
+	Action     string   `json:"action"`
+	Label      string   `json:"label"`
+	From       []string `json:"from"`
+	To         string   `json:"to"`
+	Permission string   `json:"permission"`
+	Guards     []string `json:"guards"`
+	Effects    []string `json:"effects"`
+	Event      string   `json:"event"` // activity_logs.event_type, default status_changed
+}
+
+// Guard names
+const (
+	GuardOwner           = "owner"            // caller is the student who owns the achievement
+	GuardReviewer        = "reviewer"         // advisor, active delegate or admin override
+	GuardAdmin           = "admin"            // caller has the admin role
+	GuardNoteRequired    = "note_required"    // request must carry a non-empty note
+	GuardMembersAccepted = "members_accepted" // no team invitation is still waiting for an answer
+	GuardNoDuplicates    = "no_duplicates"    // no likely duplicate, unless the request gives override_reason
+)
+
+// Effect names
+const (
+	EffectOpenReviewRound    = "open_review_round"    // submitted_at, revision on resubmission, new review round
+	EffectMarkVerified       = "mark_verified"        // verified_by / verified_at
+	EffectRecordRejection    = "record_rejection"     // rejection_note
+	EffectCloseReviewRound   = "close_review_round"   // decision on the current review round
+	EffectSoftDeleteDocument = "soft_delete_document" // soft delete the Mongo document
+	EffectAwardPoints        = "award_points"         // SKPI points from the scoring rules
+)
+
+var (
+	knownGuards = map[string]bool{
+		GuardOwner: true, GuardReviewer: true, GuardAdmin: true, GuardNoteRequired: true,
+		GuardMembersAccepted: true, GuardNoDuplicates: true,
+	}
+	knownEffects = map[string]bool{
+		EffectOpenReviewRound: true, EffectMarkVerified: true, EffectRecordRejection: true,
+		EffectCloseReviewRound: true, EffectSoftDeleteDocument: true, EffectAwardPoints: true,
+	}
+)
+
+// DefaultWorkflow is the built-in flow: draft -> submitted -> verified | rejected -> submitted ...
+func DefaultWorkflow() *WorkflowDefinition {
+	return &WorkflowDefinition{
+		InitialState: "draft",
+		States: []WorkflowState{
+			{Name: "draft", Label: "Draft", Editable: true},
+			{Name: "submitted", Label: "Diajukan"},
+			{Name: "verified", Label: "Terverifikasi"},
+			{Name: "rejected", Label: "Ditolak", Editable: true},
+			{Name: "deleted", Label: "Dihapus"},
+		},
+		Transitions: []WorkflowTransition{
+			{Action: "submit", Label: "Ajukan", From: []string{"draft", "rejected"}, To: "submitted",
+				Permission: "achievement:submit", Guards: []string{GuardOwner, GuardMembersAccepted, GuardNoDuplicates},
+				Effects: []string{EffectOpenReviewRound}},
+			{Action: "verify", Label: "Verifikasi", From: []string{"submitted"}, To: "verified",
+				Permission: "achievement:verify", Guards: []string{GuardReviewer},
+				Effects: []string{EffectMarkVerified, EffectAwardPoints, EffectCloseReviewRound}},
+			{Action: "reject", Label: "Tolak", From: []string{"submitted"}, To: "rejected",
+				Permission: "achievement:verify", Guards: []string{GuardReviewer, GuardNoteRequired},
+				Effects: []string{EffectRecordRejection, EffectCloseReviewRound}},
+			{Action: "delete", Label: "Hapus", From: []string{"draft"}, To: "deleted",
+				Permission: "achievement:delete", Guards: []string{GuardOwner},
+				Effects: []string{EffectSoftDeleteDocument}, Event: "deleted"},
+		},
+	}
+}
+
+// Permissions maps each permission required by a transition to those transitions ("workflow <action>").
+func (wf *WorkflowDefinition) Permissions() map[string][]string {
+	out := map[string][]string{}
+	for _, t := range wf.Transitions {
+		if t.Permission != "" {
+			out[t.Permission] = append(out[t.Permission], "workflow "+t.Action)
+		}
+	}
+	return out
+}
+
+// LoadWorkflow reads a workflow definition from a JSON file. A missing file
+// means the built-in DefaultWorkflow; an invalid one is an error.
+func LoadWorkflow(path string) (*WorkflowDefinition, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return DefaultWorkflow(), nil
+		}
+		return nil, err
+	}
+	var wf WorkflowDefinition
+	if err := json.Unmarshal(b, &wf); err != nil {
+		return nil, fmt.Errorf("workflow %s: %w", path, err)
+	}
+	if err := wf.Validate(); err != nil {
+		return nil, fmt.Errorf("workflow %s: %w", path, err)
+	}
+	return &wf, nil
+}
+
+// Validate checks that states referenced by transitions exist, guard/effect names
+// are known and no two transitions share an action from the same state.
+func (wf *WorkflowDefinition) Validate() error {
+	if wf.State(wf.InitialState) == nil {
+		return fmt.Errorf("initial_state %q is not a declared state", wf.InitialState)
+	}
+	seen := map[string]bool{}
+	for _, t := range wf.Transitions {
+		if t.Action == "" {
+			return errors.New("transition without action")
+		}
+		if wf.State(t.To) == nil {
+			return fmt.Errorf("transition %q: unknown target state %q", t.Action, t.To)
+		}
+		if len(t.From) == 0 {
+			return fmt.Errorf("transition %q: from is empty", t.Action)
+		}
+		for _, from := range t.From {
+			if wf.State(from) == nil {
+				return fmt.Errorf("transition %q: unknown source state %q", t.Action, from)
+			}
+			key := t.Action + "@" + from
+			if seen[key] {
+				return fmt.Errorf("transition %q is declared twice from state %q", t.Action, from)
+			}
+			seen[key] = true
+		}
+		for _, g := range t.Guards {
+			if !knownGuards[g] {
+				return fmt.Errorf("transition %q: unknown guard %q", t.Action, g)
+			}
+		}
+		for _, e := range t.Effects {
+			if !knownEffects[e] {
+				return fmt.Errorf("transition %q: unknown effect %q", t.Action, e)
+			}
+		}
+	}
+	return nil
+}
+
+// State returns the state with the given name, or nil.
+func (wf *WorkflowDefinition) State(name string) *WorkflowState {
+	for i := range wf.States {
+		if wf.States[i].Name == name {
+			return &wf.States[i]
+		}
+	}
+	return nil
+}
+
+// Transition returns the transition for action that starts at state, or nil.
+func (wf *WorkflowDefinition) Transition(action string, state string) *WorkflowTransition {
+	for i := range wf.Transitions {
+		t := &wf.Transitions[i]
+		if t.Action != action {
+			continue
+		}
+		for _, from := range t.From {
+			if from == state {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// TransitionsFrom lists the transitions that start at state, in declaration order.
+func (wf *WorkflowDefinition) TransitionsFrom(state string) []*WorkflowTransition {
+	out := []*WorkflowTransition{}
+	for i := range wf.Transitions {
+		for _, from := range wf.Transitions[i].From {
+			if from == state {
+				out = append(out, &wf.Transitions[i])
+				break
+			}
+		}
+	}
+	return out
+}
+
+// HasAction reports whether any transition uses the action name.
+func (wf *WorkflowDefinition) HasAction(action string) bool {
+	for i := range wf.Transitions {
+		if wf.Transitions[i].Action == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	pgModel "clean-arch/app/model/postgre"
+	pgRepo "clean-arch/app/repository/postgre"
+)
+
+func TestDefaultWorkflowIsValid(t *testing.T) {
+	if err := DefaultWorkflow().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkflowValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(wf *WorkflowDefinition)
+		wantErr string
+	}{
+		{"unknown initial state", func(wf *WorkflowDefinition) { wf.InitialState = "new" }, `initial_state "new"`},
+		{"transition without action", func(wf *WorkflowDefinition) { wf.Transitions[0].Action = "" }, "transition without action"},
+		{"unknown target", func(wf *WorkflowDefinition) { wf.Transitions[0].To = "archived" }, `unknown target state "archived"`},
+		{"empty from", func(wf *WorkflowDefinition) { wf.Transitions[0].From = nil }, `"submit": from is empty`},
+		{"unknown source", func(wf *WorkflowDefinition) { wf.Transitions[0].From = []string{"archived"} }, `unknown source state "archived"`},
+		{"unknown guard", func(wf *WorkflowDefinition) { wf.Transitions[1].Guards = []string{"dean"} }, `unknown guard "dean"`},
+		{"unknown effect", func(wf *WorkflowDefinition) { wf.Transitions[1].Effects = []string{"email"} }, `unknown effect "email"`},
+		{"action declared twice from a state", func(wf *WorkflowDefinition) {
+			wf.Transitions = append(wf.Transitions, WorkflowTransition{Action: "verify", From: []string{"submitted"}, To: "verified"})
+		}, `"verify" is declared twice from state "submitted"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := DefaultWorkflow()
+			tt.edit(wf)
+			err := wf.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkflowTransitions(t *testing.T) {
+	wf := DefaultWorkflow()
+	tests := []struct {
+		action, from string
+		wantTo       string // empty: no transition
+	}{
+		{"submit", "draft", "submitted"},
+		{"submit", "rejected", "submitted"},
+		{"submit", "submitted", ""},
+		{"verify", "submitted", "verified"},
+		{"verify", "draft", ""},
+		{"reject", "submitted", "rejected"},
+		{"reject", "verified", ""},
+		{"delete", "draft", "deleted"},
+		{"delete", "submitted", ""},
+		{"archive", "draft", ""},
+	}
+	for _, tt := range tests {
+		tr := wf.Transition(tt.action, tt.from)
+		switch {
+		case tt.wantTo == "" && tr != nil:
+			t.Errorf("%s from %s: got transition to %s, want none", tt.action, tt.from, tr.To)
+		case tt.wantTo != "" && (tr == nil || tr.To != tt.wantTo):
+			t.Errorf("%s from %s: got %+v, want transition to %s", tt.action, tt.from, tr, tt.wantTo)
+		}
+	}
+
+	var actions []string
+	for _, tr := range wf.TransitionsFrom("submitted") {
+		actions = append(actions, tr.Action)
+	}
+	if strings.Join(actions, ",") != "verify,reject" {
+		t.Errorf("TransitionsFrom(submitted) = %v, want [verify reject]", actions)
+	}
+	if len(wf.TransitionsFrom("deleted")) != 0 {
+		t.Error("deleted is a final state")
+	}
+	if !wf.HasAction("reject") || wf.HasAction("archive") {
+		t.Error("HasAction should know reject and not archive")
+	}
+	if st := wf.State("rejected"); st == nil || !st.Editable {
+		t.Error("rejected achievements are editable")
+	}
+	if st := wf.State("submitted"); st == nil || st.Editable {
+		t.Error("submitted achievements are not editable")
+	}
+	if got := wf.Permissions()["achievement:verify"]; strings.Join(got, ",") != "workflow verify,workflow reject" {
+		t.Errorf("Permissions()[achievement:verify] = %v", got)
+	}
+}
+
+func TestLoadWorkflow(t *testing.T) {
+	dir := t.TempDir()
+
+	wf, err := LoadWorkflow(filepath.Join(dir, "missing.json"))
+	if err != nil || wf.InitialState != "draft" || len(wf.Transitions) != len(DefaultWorkflow().Transitions) {
+		t.Fatalf("missing file: got %+v, %v; want the default workflow", wf, err)
+	}
+
+	custom := filepath.Join(dir, "workflow.json")
+	body := `{"initial_state": "draft",
+		"states": [{"name": "draft", "editable": true}, {"name": "approved"}],
+		"transitions": [{"action": "approve", "from": ["draft"], "to": "approved", "guards": ["admin"]}]}`
+	if err := os.WriteFile(custom, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	wf, err = LoadWorkflow(custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr := wf.Transition("approve", "draft"); tr == nil || tr.To != "approved" {
+		t.Errorf("custom transition not loaded: %+v", tr)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"initial_state": "draft", "states": [{"name": "draft"}],
+		"transitions": [{"action": "x", "from": ["draft"], "to": "gone"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadWorkflow(invalid); err == nil || !strings.Contains(err.Error(), "invalid.json") {
+		t.Errorf("invalid workflow: got %v, want an error naming the file", err)
+	}
+}
+
+// fakes for the guards; unused interface methods panic through the nil embedded interface
+
+type fakeStudentRepo struct {
+	pgRepo.StudentRepository
+	students map[string]*pgModel.Student // by id
+}
+
+func (f *fakeStudentRepo) GetByID(ctx context.Context, id string) (*pgModel.Student, error) {
+	if s, ok := f.students[id]; ok {
+		return s, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeStudentRepo) GetByUserID(ctx context.Context, userID string) (*pgModel.Student, error) {
+	for _, s := range f.students {
+		if s.UserID == userID {
+			return s, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+type fakeLecturerRepo struct {
+	pgRepo.LecturerRepository
+	lecturers []*pgModel.Lecturer
+}
+
+func (f *fakeLecturerRepo) GetByUserID(ctx context.Context, userID string) (*pgModel.Lecturer, error) {
+	for _, l := range f.lecturers {
+		if l.UserID == userID {
+			return l, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+type fakeDelegationRepo struct {
+	pgRepo.DelegationRepository
+	delegations []*pgModel.VerificationDelegation
+}
+
+func (f *fakeDelegationRepo) FindActive(ctx context.Context, advisorID, delegateID string, at time.Time) (*pgModel.VerificationDelegation, error) {
+	for _, d := range f.delegations {
+		if d.AdvisorID == advisorID && d.DelegateID == delegateID {
+			return d, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+type fakeMemberRepo struct {
+	pgRepo.AchievementMemberRepository
+	members []*pgModel.AchievementMember
+}
+
+func (f *fakeMemberRepo) ListByReference(ctx context.Context, referenceID string) ([]*pgModel.AchievementMember, error) {
+	out := []*pgModel.AchievementMember{}
+	for _, m := range f.members {
+		if m.ReferenceID == referenceID {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// guardFixture: student s1 (user u-student) is advised by lecturer l1 (user u-advisor), who
+// delegated to l2 (user u-delegate); l3 (user u-other) has no relation; u-admin is an admin.
+func guardFixture() (*AchievementService, *fakeMemberRepo) {
+	roles, _ := testRoles()
+	advisor := "l1"
+	students := &fakeStudentRepo{students: map[string]*pgModel.Student{
+		"s1": {ID: "s1", UserID: "u-student", AdvisorID: &advisor},
+		"s2": {ID: "s2", UserID: "u-member"},
+	}}
+	members := &fakeMemberRepo{}
+	return &AchievementService{
+		studentRepo: students,
+		lecturerRepo: &fakeLecturerRepo{lecturers: []*pgModel.Lecturer{
+			{ID: "l1", UserID: "u-advisor"}, {ID: "l2", UserID: "u-delegate"}, {ID: "l3", UserID: "u-other"},
+		}},
+		delegationRepo: &fakeDelegationRepo{delegations: []*pgModel.VerificationDelegation{
+			{ID: "d1", AdvisorID: "l1", DelegateID: "l2"},
+		}},
+		memberRepo: members,
+		policy:     NewAccessPolicy(roles, students, nil, nil, members),
+		workflow:   DefaultWorkflow(),
+	}, members
+}
+
+func TestCheckGuard(t *testing.T) {
+	svc, members := guardFixture()
+	members.members = []*pgModel.AchievementMember{
+		{ReferenceID: "team", StudentID: "s2", Status: pgModel.MemberInvited},
+		{ReferenceID: "done", StudentID: "s2", Status: pgModel.MemberAccepted},
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		guard     string
+		actor     Actor
+		refID     string
+		note      string
+		want      error
+		authority string // expected grant of the reviewer guard
+	}{
+		{"owner", GuardOwner, Actor{UserID: "u-student"}, "ref", "", nil, ""},
+		{"not the owner", GuardOwner, Actor{UserID: "u-member"}, "ref", "", ErrNotOwner, ""},
+		{"not a student", GuardOwner, Actor{UserID: "u-advisor"}, "ref", "", ErrNotOwner, ""},
+		{"advisor reviews", GuardReviewer, Actor{UserID: "u-advisor"}, "ref", "", nil, AuthorityAdvisor},
+		{"delegate reviews", GuardReviewer, Actor{UserID: "u-delegate"}, "ref", "", nil, AuthorityDelegate},
+		{"admin overrides", GuardReviewer, Actor{UserID: "u-admin", RoleID: "admin"}, "ref", "", nil, AuthorityAdminOverride},
+		{"admin in a second role", GuardReviewer, Actor{UserID: "u-admin", RoleID: "staff", RoleIDs: []string{"staff", "admin"}}, "ref", "", nil, AuthorityAdminOverride},
+		{"other lecturer", GuardReviewer, Actor{UserID: "u-other", RoleID: "lecturer"}, "ref", "", ErrNotAdvisor, ""},
+		{"own achievement", GuardReviewer, Actor{UserID: "u-student", RoleID: "admin"}, "ref", "", ErrSelfVerification, ""},
+		{"admin guard", GuardAdmin, Actor{UserID: "u-admin", RoleID: "admin"}, "ref", "", nil, ""},
+		{"admin guard denied", GuardAdmin, Actor{UserID: "u-other", RoleID: "lecturer"}, "ref", "", ErrForbidden, ""},
+		{"note given", GuardNoteRequired, Actor{}, "ref", "missing page 2", nil, ""},
+		{"note missing", GuardNoteRequired, Actor{}, "ref", "", ErrNoteRequired, ""},
+		{"invitation still open", GuardMembersAccepted, Actor{}, "team", "", ErrPendingInvitations, ""},
+		{"invitations answered", GuardMembersAccepted, Actor{}, "done", "", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := &transitionRun{
+				ref:   &pgModel.AchievementReference{ID: tt.refID, StudentID: "s1"},
+				actor: tt.actor,
+				input: TransitionInput{Note: tt.note},
+			}
+			err := svc.checkGuard(ctx, run, tt.guard)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("checkGuard(%s) = %v, want %v", tt.guard, err, tt.want)
+			}
+			if tt.authority != "" && (run.grant == nil || run.grant.Authority != tt.authority) {
+				t.Fatalf("grant = %+v, want authority %s", run.grant, tt.authority)
+			}
+		})
+	}
+}
+
+func TestCheckPermissionUsesEveryRole(t *testing.T) {
+	svc, _ := guardFixture()
+	roles, perms := testRoles()
+	svc.permissions = NewRBACService(perms, nil, roles).HasPermissionByRoleID
+	verify := svc.workflow.Transition("verify", "submitted")
+	ctx := context.Background()
+
+	tests := []struct {
+		actor Actor
+		want  error
+	}{
+		{Actor{RoleID: "lecturer"}, nil},
+		{Actor{RoleID: "base"}, ErrForbidden},
+		{Actor{RoleID: "base", RoleIDs: []string{"base", "lecturer"}}, nil},
+		{Actor{RoleID: "admin"}, nil}, // *:*
+		{Actor{}, ErrForbidden},
+	}
+	for _, tt := range tests {
+		err := svc.checkPermission(ctx, &transitionRun{t: verify, actor: tt.actor})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("checkPermission(%+v) = %v, want %v", tt.actor, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned by Open when the object does not exist.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage keeps uploaded files by key (e.g. "<reference id>/<attachment id>.pdf").
+// LocalStorage is the filesystem implementation; an S3-compatible one only needs these methods.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object; deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"os"
+	"strconv"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	fiberlogger "github.com/gofiber/fiber/v2/middleware/logger"
@@ -11,6 +14,10 @@ import (
 // Optionally pass a custom logger writer (from InitLogger) to wire into fiber logger.
 func NewFiberApp(logWriter ...interface{}) *fiber.App {
 	app := fiber.New(fiber.Config{
+		// multipart attachment uploads must fit (see ATTACHMENT_MAX_SIZE). Every request is read
+		// into memory up to this size; middleware.BodyLimit in route.RegisterRoutes then rejects
+		// bodies over fiber's default on the other routes
+		BodyLimit: bodyLimit(),
 		// you can set ReadTimeout/WriteTimeout here if you want
 		// ReadTimeout:  5 * time.Second,
 		// WriteTimeout: 10 * time.Second,
@@ -58,3 +65,16 @@ func NewFiberApp(logWriter ...interface{}) *fiber.App {
 
 	return app
 }
+
+// bodyLimit leaves 1 MB of multipart overhead above ATTACHMENT_MAX_SIZE (default 5 MB),
+// never going below fiber's default of 4 MB.
+func bodyLimit() int {
+	max := 5 << 20
+	if n, err := strconv.Atoi(os.Getenv("ATTACHMENT_MAX_SIZE")); err == nil && n > 0 {
+		max = n
+	}
+	if limit := max + 1<<20; limit > fiber.DefaultBodyLimit {
+		return limit
+	}
+	return fiber.DefaultBodyLimit
+}
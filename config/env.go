@@ -16,6 +16,15 @@ type Config struct {
 	JWTSecret   string
 	LogPath     string
 	LogLevel    string
+	// WorkflowPath points to the achievement workflow definition (JSON)
+	WorkflowPath string
+	// Attachment storage: "local" (files under StorageLocalRoot); S3-compatible drivers can be added
+	StorageDriver    string
+	StorageLocalRoot string
+	// AttachmentURLSecret signs short-lived attachment download links; keep it apart from JWTSecret
+	AttachmentURLSecret string
+	// PermissionSyncOnStart creates missing route/workflow permissions at startup
+	PermissionSyncOnStart bool
 }
 
 // singleton config
@@ -33,13 +42,20 @@ func LoadEnv() error {
 		_ = godotenv.Load()
 
 		c := &Config{
-			AppPort:     getEnv("APP_PORT", "3000"),
-			DBDriver:    getEnv("DB_DRIVER", "mongo"), // mongo or postgres
-			PostgresDsn: getEnv("POSTGRES_DSN", ""),
-			MongoURI:    getEnv("MONGO_URI", ""),
-			JWTSecret:   getEnv("JWT_SECRET", "dev-secret"),
-			LogPath:     getEnv("LOG_PATH", "logs/app.log"),
-			LogLevel:    getEnv("LOG_LEVEL", "info"),
+			AppPort:          getEnv("APP_PORT", "3000"),
+			DBDriver:         getEnv("DB_DRIVER", "mongo"), // mongo or postgres
+			PostgresDsn:      getEnv("POSTGRES_DSN", ""),
+			MongoURI:         getEnv("MONGO_URI", ""),
+			JWTSecret:        getEnv("JWT_SECRET", "dev-secret"),
+			LogPath:          getEnv("LOG_PATH", "logs/app.log"),
+			LogLevel:         getEnv("LOG_LEVEL", "info"),
+			WorkflowPath:     getEnv("WORKFLOW_CONFIG", "config/workflow.json"),
+			StorageDriver:    getEnv("STORAGE_DRIVER", "local"),
+			StorageLocalRoot: getEnv("STORAGE_LOCAL_ROOT", "uploads/attachments"),
+
+			AttachmentURLSecret: getEnv("ATTACHMENT_URL_SECRET", "dev-attachment-secret"),
+
+			PermissionSyncOnStart: getEnv("PERMISSION_SYNC_ON_START", "true") != "false",
 		}
 		cfg = c
 	})
@@ -0,0 +1,335 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Store names a migration target.
+const (
+	StorePostgres = "postgres"
+	StoreMongo    = "mongo"
+)
+
+// migrationsTable is used both as the Postgres table and the Mongo collection name.
+const migrationsTable = "schema_migrations"
+
+//go:embed migrations/*.sql
+var postgresMigrationFS embed.FS
+
+// Migration is a single versioned schema change. Versions are unique across
+// both stores so that "up" and "down" follow one global order.
+type Migration struct {
+	Version int
+	Name    string
+	Store   string
+
+	// Postgres migrations
+	UpSQL   string
+	DownSQL string
+
+	// Mongo migrations
+	UpMongo   func(ctx context.Context, db *mongo.Database) error
+	DownMongo func(ctx context.Context, db *mongo.Database) error
+}
+
+// MigrationStatus describes whether a migration has been applied.
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Store     string     `json:"store"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Skipped   bool       `json:"skipped"` // store not connected
+}
+
+// Migrator applies embedded Postgres migrations and registered Mongo migrations.
+// Either connection may be nil; migrations for a missing store are skipped.
+type Migrator struct {
+	pg         *sql.DB
+	mongo      *mongo.Database
+	migrations []Migration
+}
+
+// NewMigrator loads all known migrations and validates their ordering.
+func NewMigrator(pg *sql.DB, mongoDB *mongo.Database) (*Migrator, error) {
+	pgMigrations, err := loadPostgresMigrations(postgresMigrationFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	all := append(pgMigrations, mongoMigrations()...)
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+
+	seen := make(map[int]string)
+	for _, m := range all {
+		if prev, ok := seen[m.Version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d (%s and %s)", m.Version, prev, m.Name)
+		}
+		seen[m.Version] = m.Name
+	}
+	return &Migrator{pg: pg, mongo: mongoDB, migrations: all}, nil
+}
+
+// loadPostgresMigrations parses files named NNNN_name.up.sql / NNNN_name.down.sql.
+func loadPostgresMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+		base := strings.TrimSuffix(e.Name(), ".sql")
+		direction := path.Ext(base) // ".up" or ".down"
+		base = strings.TrimSuffix(base, direction)
+		parts := strings.SplitN(base, "_", 2)
+		if len(parts) != 2 || (direction != ".up" && direction != ".down") {
+			return nil, fmt.Errorf("invalid migration file name: %s", e.Name())
+		}
+		version, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", e.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1], Store: StorePostgres}
+			byVersion[version] = m
+		}
+		if direction == ".up" {
+			m.UpSQL = string(body)
+		} else {
+			m.DownSQL = string(body)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpSQL == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", m.Version, m.Name)
+		}
+		out = append(out, *m)
+	}
+	return out, nil
+}
+
+func (m *Migrator) connected(store string) bool {
+	switch store {
+	case StorePostgres:
+		return m.pg != nil
+	case StoreMongo:
+		return m.mongo != nil
+	}
+	return false
+}
+
+func (m *Migrator) ensureTables(ctx context.Context) error {
+	if m.pg != nil {
+		q := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		          version    BIGINT PRIMARY KEY,
+		          name       TEXT NOT NULL,
+		          applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		      )`
+		if _, err := m.pg.ExecContext(ctx, q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applied returns applied versions (with timestamps) for every connected store.
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	out := make(map[int]time.Time)
+	if m.pg != nil {
+		rows, err := m.pg.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var v int
+			var at time.Time
+			if err := rows.Scan(&v, &at); err != nil {
+				return nil, err
+			}
+			out[v] = at
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if m.mongo != nil {
+		cur, err := m.mongo.Collection(migrationsTable).Find(ctx, bson.M{})
+		if err != nil {
+			return nil, err
+		}
+		defer cur.Close(ctx)
+		for cur.Next(ctx) {
+			var doc struct {
+				Version   int       `bson:"_id"`
+				AppliedAt time.Time `bson:"appliedAt"`
+			}
+			if err := cur.Decode(&doc); err != nil {
+				return nil, err
+			}
+			out[doc.Version] = doc.AppliedAt
+		}
+		if err := cur.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		st := MigrationStatus{Version: mig.Version, Name: mig.Name, Store: mig.Store}
+		if !m.connected(mig.Store) {
+			st.Skipped = true
+		} else if at, ok := applied[mig.Version]; ok {
+			at := at
+			st.Applied = true
+			st.AppliedAt = &at
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// Up applies all pending migrations in version order and returns the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; ok || !m.connected(mig.Store) {
+			continue
+		}
+		if err := m.apply(ctx, mig, true); err != nil {
+			return done, fmt.Errorf("migration %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down rolls back the most recently applied `steps` migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	if err := m.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok || !m.connected(mig.Store) {
+			continue
+		}
+		if err := m.apply(ctx, mig, false); err != nil {
+			return done, fmt.Errorf("rollback %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, mig Migration, up bool) error {
+	switch mig.Store {
+	case StorePostgres:
+		return m.applyPostgres(ctx, mig, up)
+	case StoreMongo:
+		return m.applyMongo(ctx, mig, up)
+	}
+	return fmt.Errorf("unknown migration store %q", mig.Store)
+}
+
+// applyPostgres runs the script and the bookkeeping row in one transaction.
+func (m *Migrator) applyPostgres(ctx context.Context, mig Migration, up bool) error {
+	script := mig.UpSQL
+	if !up {
+		script = mig.DownSQL
+		if script == "" {
+			return errors.New("no down script")
+		}
+	}
+	tx, err := m.pg.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1,$2,$3)`,
+			mig.Version, mig.Name, time.Now())
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version=$1`, mig.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) applyMongo(ctx context.Context, mig Migration, up bool) error {
+	col := m.mongo.Collection(migrationsTable)
+	if up {
+		if err := mig.UpMongo(ctx, m.mongo); err != nil {
+			return err
+		}
+		_, err := col.UpdateOne(ctx,
+			bson.M{"_id": mig.Version},
+			bson.M{"$set": bson.M{"name": mig.Name, "appliedAt": time.Now()}},
+			options.Update().SetUpsert(true),
+		)
+		return err
+	}
+	if mig.DownMongo == nil {
+		return errors.New("no down function")
+	}
+	if err := mig.DownMongo(ctx, m.mongo); err != nil {
+		return err
+	}
+	_, err := col.DeleteOne(ctx, bson.M{"_id": mig.Version})
+	return err
+}
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS token_blacklist;
+DROP TABLE IF EXISTS activity_logs;
+DROP TABLE IF EXISTS achievement_references;
+DROP TABLE IF EXISTS students;
+DROP TABLE IF EXISTS lecturers;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
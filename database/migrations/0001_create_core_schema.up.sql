@@ -0,0 +1,90 @@
+-- Core schema used by app/repository/postgre
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+CREATE TABLE IF NOT EXISTS roles (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(50) UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(100) UNIQUE NOT NULL,
+    resource    VARCHAR(50) NOT NULL,
+    action      VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id       UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id UUID NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS users (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    username      VARCHAR(50) UNIQUE NOT NULL,
+    email         VARCHAR(100) UNIQUE NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    full_name     VARCHAR(100) NOT NULL DEFAULT '',
+    role_id       UUID REFERENCES roles(id),
+    is_active     BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS lecturers (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID UNIQUE NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    lecturer_id VARCHAR(20) UNIQUE NOT NULL,
+    department  VARCHAR(100) NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS students (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       UUID UNIQUE NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    student_id    VARCHAR(20) UNIQUE NOT NULL,
+    program_study VARCHAR(100) NOT NULL DEFAULT '',
+    academic_year VARCHAR(10) NOT NULL DEFAULT '',
+    advisor_id    UUID REFERENCES lecturers(id) ON DELETE SET NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_students_advisor_id ON students(advisor_id);
+
+CREATE TABLE IF NOT EXISTS achievement_references (
+    id                   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    student_id           UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    mongo_achievement_id VARCHAR(24) NOT NULL,
+    status               VARCHAR(30) NOT NULL DEFAULT 'draft',
+    submitted_at         TIMESTAMPTZ,
+    verified_at          TIMESTAMPTZ,
+    verified_by          UUID REFERENCES users(id) ON DELETE SET NULL,
+    rejection_note       TEXT,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_achievement_references_student_id ON achievement_references(student_id);
+CREATE INDEX IF NOT EXISTS idx_achievement_references_status ON achievement_references(status);
+CREATE INDEX IF NOT EXISTS idx_achievement_references_created_at ON achievement_references(created_at DESC);
+
+CREATE TABLE IF NOT EXISTS activity_logs (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id   VARCHAR(64) NOT NULL,
+    event_type  VARCHAR(50) NOT NULL,
+    actor_id    UUID,
+    actor_role  VARCHAR(50),
+    previous    JSONB,
+    current     JSONB,
+    metadata    JSONB,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_activity_logs_entity ON activity_logs(entity_type, entity_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS token_blacklist (
+    token      TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_token_blacklist_expires_at ON token_blacklist(expires_at);
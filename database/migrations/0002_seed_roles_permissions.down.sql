@@ -0,0 +1,12 @@
+DELETE FROM role_permissions
+WHERE role_id IN (SELECT id FROM roles WHERE name IN ('Admin', 'Mahasiswa', 'Dosen Wali'));
+
+DELETE FROM permissions WHERE name IN (
+    'user:read', 'user:create', 'user:update', 'user:delete', 'user:assign_role',
+    'student:manage',
+    'achievement:create', 'achievement:update', 'achievement:delete', 'achievement:submit', 'achievement:verify',
+    'report:view'
+);
+
+DELETE FROM roles WHERE name IN ('Admin', 'Mahasiswa', 'Dosen Wali')
+  AND NOT EXISTS (SELECT 1 FROM users u WHERE u.role_id = roles.id);
@@ -0,0 +1,41 @@
+-- Default roles and the permissions referenced by route/route.go
+INSERT INTO roles (name, description) VALUES
+    ('Admin', 'Pengelola sistem'),
+    ('Mahasiswa', 'Pelapor prestasi'),
+    ('Dosen Wali', 'Verifikator prestasi mahasiswa bimbingan')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (name, resource, action, description) VALUES
+    ('user:read', 'user', 'read', 'Melihat daftar user'),
+    ('user:create', 'user', 'create', 'Membuat user'),
+    ('user:update', 'user', 'update', 'Mengubah user'),
+    ('user:delete', 'user', 'delete', 'Menghapus user'),
+    ('user:assign_role', 'user', 'assign_role', 'Mengubah role user'),
+    ('student:manage', 'student', 'manage', 'Mengelola data mahasiswa'),
+    ('achievement:create', 'achievement', 'create', 'Membuat draft prestasi'),
+    ('achievement:update', 'achievement', 'update', 'Mengubah draft prestasi'),
+    ('achievement:delete', 'achievement', 'delete', 'Menghapus draft prestasi'),
+    ('achievement:submit', 'achievement', 'submit', 'Mengajukan prestasi untuk verifikasi'),
+    ('achievement:verify', 'achievement', 'verify', 'Memverifikasi atau menolak prestasi'),
+    ('report:view', 'report', 'view', 'Melihat laporan dan statistik')
+ON CONFLICT (name) DO NOTHING;
+
+-- Admin: semua permission
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'Admin'
+ON CONFLICT DO NOTHING;
+
+-- Mahasiswa: kelola prestasi sendiri
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p
+  ON p.name IN ('achievement:create', 'achievement:update', 'achievement:delete', 'achievement:submit')
+WHERE r.name = 'Mahasiswa'
+ON CONFLICT DO NOTHING;
+
+-- Dosen Wali: verifikasi dan laporan
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p
+  ON p.name IN ('achievement:verify', 'report:view')
+WHERE r.name = 'Dosen Wali'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS tokens_valid_after;
+
+TRUNCATE token_blacklist;
+ALTER TABLE token_blacklist ALTER COLUMN jti TYPE TEXT;
+ALTER TABLE token_blacklist RENAME COLUMN jti TO token;
@@ -0,0 +1,8 @@
+-- Blacklist now stores the JWT ID (jti) instead of the full token string.
+-- Old rows cannot be mapped to a jti and expire within a day anyway.
+TRUNCATE token_blacklist;
+ALTER TABLE token_blacklist RENAME COLUMN token TO jti;
+ALTER TABLE token_blacklist ALTER COLUMN jti TYPE VARCHAR(64);
+
+-- Force-logout cutoff: access tokens issued before this instant are rejected
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMPTZ;
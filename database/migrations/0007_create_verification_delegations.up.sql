@@ -0,0 +1,16 @@
+-- Advisor hands verification rights for their advisees to another lecturer for a period
+CREATE TABLE IF NOT EXISTS verification_delegations (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    advisor_id  UUID NOT NULL REFERENCES lecturers(id) ON DELETE CASCADE,
+    delegate_id UUID NOT NULL REFERENCES lecturers(id) ON DELETE CASCADE,
+    starts_at   TIMESTAMPTZ NOT NULL,
+    ends_at     TIMESTAMPTZ NOT NULL,
+    reason      TEXT NOT NULL DEFAULT '',
+    created_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at  TIMESTAMPTZ,
+    CHECK (ends_at > starts_at),
+    CHECK (advisor_id <> delegate_id)
+);
+CREATE INDEX IF NOT EXISTS idx_verification_delegations_advisor ON verification_delegations(advisor_id);
+CREATE INDEX IF NOT EXISTS idx_verification_delegations_delegate ON verification_delegations(delegate_id);
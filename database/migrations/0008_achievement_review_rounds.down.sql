@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS achievement_review_rounds;
+ALTER TABLE achievement_references DROP COLUMN IF EXISTS revision;
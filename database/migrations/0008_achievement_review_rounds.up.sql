@@ -0,0 +1,17 @@
+-- Rejected achievements can be revised and resubmitted; every submission is a numbered round
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS revision INT NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS achievement_review_rounds (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reference_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    round        INT NOT NULL,
+    submitted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    submitted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    snapshot     JSONB NOT NULL,
+    changes      JSONB,
+    decision     VARCHAR(20),
+    decided_by   UUID REFERENCES users(id) ON DELETE SET NULL,
+    decided_at   TIMESTAMPTZ,
+    note         TEXT,
+    UNIQUE (reference_id, round)
+);
@@ -0,0 +1,17 @@
+-- Transactional outbox: Mongo writes are recorded in the same Postgres transaction
+-- as the achievement_references change and applied (and retried) afterwards
+CREATE TABLE IF NOT EXISTS achievement_outbox (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reference_id    UUID NOT NULL,
+    operation       VARCHAR(50) NOT NULL,
+    payload         JSONB,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts        INT NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at    TIMESTAMPTZ,
+    CHECK (status IN ('pending', 'done', 'failed'))
+);
+CREATE INDEX IF NOT EXISTS idx_achievement_outbox_pending ON achievement_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_achievement_outbox_reference ON achievement_outbox(reference_id, created_at);
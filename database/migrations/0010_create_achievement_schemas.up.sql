@@ -0,0 +1,46 @@
+-- Per-category JSON Schemas for achievement details (managed via /api/v1/achievement-schemas)
+CREATE TABLE IF NOT EXISTS achievement_schemas (
+    category    VARCHAR(50) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    schema      JSONB NOT NULL,
+    updated_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO achievement_schemas (category, description, schema) VALUES
+    ('lomba', 'Kompetisi: peringkat, penyelenggara, tanggal', '{
+        "type": "object",
+        "required": ["rank", "organizer", "date"],
+        "additionalProperties": false,
+        "properties": {
+            "rank":      {"type": "integer", "minimum": 1},
+            "organizer": {"type": "string", "minLength": 1, "maxLength": 200},
+            "date":      {"type": "string", "format": "date"},
+            "eventName": {"type": "string", "maxLength": 200},
+            "location":  {"type": "string", "maxLength": 200}
+        }
+    }'),
+    ('sertifikasi', 'Sertifikasi: penerbit, nomor, masa berlaku', '{
+        "type": "object",
+        "required": ["issuer", "number"],
+        "additionalProperties": false,
+        "properties": {
+            "issuer":   {"type": "string", "minLength": 1, "maxLength": 200},
+            "number":   {"type": "string", "minLength": 1, "maxLength": 100},
+            "issuedAt": {"type": "string", "format": "date"},
+            "expiry":   {"type": "string", "format": "date"}
+        }
+    }'),
+    ('publikasi', 'Publikasi: DOI, jurnal, penulis', '{
+        "type": "object",
+        "required": ["journal", "authors"],
+        "additionalProperties": false,
+        "properties": {
+            "doi":     {"type": "string", "pattern": "^10\\.\\d{4,9}/\\S+$"},
+            "journal": {"type": "string", "minLength": 1, "maxLength": 300},
+            "authors": {"type": "array", "minItems": 1, "items": {"type": "string", "minLength": 1}},
+            "year":    {"type": "integer", "minimum": 1900, "maximum": 2100}
+        }
+    }')
+ON CONFLICT (category) DO NOTHING;
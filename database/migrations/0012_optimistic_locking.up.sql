@@ -0,0 +1,3 @@
+-- Row versions for optimistic concurrency (ETag / If-Match); bumped on every update
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
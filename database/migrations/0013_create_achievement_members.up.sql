@@ -0,0 +1,18 @@
+-- Team achievements: every student credited for an achievement. The creator is the leader
+-- (achievement_references.student_id); co-members are invited and must accept.
+CREATE TABLE IF NOT EXISTS achievement_members (
+    reference_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    student_id   UUID NOT NULL REFERENCES students(id) ON DELETE CASCADE,
+    role         VARCHAR(20) NOT NULL DEFAULT 'member', -- leader, member
+    status       VARCHAR(20) NOT NULL DEFAULT 'invited', -- invited, accepted, declined
+    invited_by   UUID REFERENCES users(id) ON DELETE SET NULL,
+    invited_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    responded_at TIMESTAMPTZ,
+    PRIMARY KEY (reference_id, student_id)
+);
+CREATE INDEX IF NOT EXISTS idx_achievement_members_student ON achievement_members(student_id, status);
+
+-- existing achievements are single-member teams led by their owner
+INSERT INTO achievement_members (reference_id, student_id, role, status, invited_at, responded_at)
+SELECT id, student_id, 'leader', 'accepted', created_at, created_at FROM achievement_references
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+ALTER TABLE achievement_references DROP COLUMN IF EXISTS points_semester;
+ALTER TABLE achievement_references DROP COLUMN IF EXISTS points_rule_id;
+ALTER TABLE achievement_references DROP COLUMN IF EXISTS points;
+DROP TABLE IF EXISTS achievement_point_caps;
+DROP TABLE IF EXISTS achievement_scoring_rules;
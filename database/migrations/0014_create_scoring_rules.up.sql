@@ -0,0 +1,50 @@
+-- SKPI credit points: rules by category x level x rank (rank '' = any rank)
+CREATE TABLE IF NOT EXISTS achievement_scoring_rules (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    category    VARCHAR(50) NOT NULL,
+    level       VARCHAR(50) NOT NULL,
+    rank        VARCHAR(20) NOT NULL DEFAULT '',
+    points      NUMERIC(8,2) NOT NULL CHECK (points >= 0),
+    description TEXT NOT NULL DEFAULT '',
+    updated_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (category, level, rank)
+);
+
+-- Maximum points a student can collect per category in one semester
+CREATE TABLE IF NOT EXISTS achievement_point_caps (
+    category   VARCHAR(50) PRIMARY KEY,
+    max_points NUMERIC(8,2) NOT NULL CHECK (max_points >= 0),
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Points awarded when the achievement was verified (recalculated when rules change)
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS points NUMERIC(8,2);
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS points_rule_id UUID REFERENCES achievement_scoring_rules(id) ON DELETE SET NULL;
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS points_semester VARCHAR(20);
+
+INSERT INTO achievement_scoring_rules (category, level, rank, points, description) VALUES
+    ('lomba', 'lokal', '1', 20, 'Juara 1 tingkat lokal'),
+    ('lomba', 'lokal', '2', 15, 'Juara 2 tingkat lokal'),
+    ('lomba', 'lokal', '3', 10, 'Juara 3 tingkat lokal'),
+    ('lomba', 'lokal', '', 5, 'Peserta tingkat lokal'),
+    ('lomba', 'nasional', '1', 50, 'Juara 1 tingkat nasional'),
+    ('lomba', 'nasional', '2', 40, 'Juara 2 tingkat nasional'),
+    ('lomba', 'nasional', '3', 30, 'Juara 3 tingkat nasional'),
+    ('lomba', 'nasional', '', 15, 'Peserta tingkat nasional'),
+    ('lomba', 'internasional', '1', 100, 'Juara 1 tingkat internasional'),
+    ('lomba', 'internasional', '2', 80, 'Juara 2 tingkat internasional'),
+    ('lomba', 'internasional', '3', 60, 'Juara 3 tingkat internasional'),
+    ('lomba', 'internasional', '', 30, 'Peserta tingkat internasional'),
+    ('sertifikasi', 'nasional', '', 25, 'Sertifikasi nasional'),
+    ('sertifikasi', 'internasional', '', 50, 'Sertifikasi internasional'),
+    ('publikasi', 'nasional', '', 40, 'Publikasi nasional'),
+    ('publikasi', 'internasional', '', 80, 'Publikasi internasional')
+ON CONFLICT (category, level, rank) DO NOTHING;
+
+INSERT INTO achievement_point_caps (category, max_points) VALUES
+    ('lomba', 150),
+    ('sertifikasi', 100)
+ON CONFLICT (category) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS achievement_comment_reads;
+DROP TABLE IF EXISTS achievement_comments;
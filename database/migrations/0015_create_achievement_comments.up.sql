@@ -0,0 +1,21 @@
+-- Review discussion between the student and reviewers; comments stay across review rounds
+CREATE TABLE IF NOT EXISTS achievement_comments (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reference_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    parent_id    UUID REFERENCES achievement_comments(id) ON DELETE CASCADE, -- reply to a thread
+    round        INT NOT NULL,                 -- achievement_references.revision when posted
+    author_id    UUID REFERENCES users(id) ON DELETE SET NULL,
+    author_role  VARCHAR(20) NOT NULL,         -- owner, advisor, delegate, admin
+    field_path   VARCHAR(200),                 -- optional anchor, e.g. details.rank
+    body         TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_achievement_comments_reference ON achievement_comments(reference_id, created_at);
+
+-- How far each user has read the comments of an achievement (unread counters)
+CREATE TABLE IF NOT EXISTS achievement_comment_reads (
+    reference_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    user_id      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_read_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (reference_id, user_id)
+);
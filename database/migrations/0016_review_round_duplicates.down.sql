@@ -0,0 +1,2 @@
+ALTER TABLE achievement_review_rounds DROP COLUMN IF EXISTS duplicate_override;
+ALTER TABLE achievement_review_rounds DROP COLUMN IF EXISTS duplicates;
@@ -0,0 +1,3 @@
+-- Likely duplicates found when the round was submitted, and the student's reason to go ahead
+ALTER TABLE achievement_review_rounds ADD COLUMN IF NOT EXISTS duplicates JSONB;
+ALTER TABLE achievement_review_rounds ADD COLUMN IF NOT EXISTS duplicate_override TEXT;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_achievement_references_renewal_of;
+DROP INDEX IF EXISTS idx_achievement_references_expires_at;
+ALTER TABLE achievement_references DROP COLUMN IF EXISTS renewal_of;
+ALTER TABLE achievement_references DROP COLUMN IF EXISTS expiry_flagged_at;
+ALTER TABLE achievement_references DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,11 @@
+-- Validity of certification achievements, mirrored from the Mongo document (expiresAt)
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+-- Set by the daily expiry check once the owner was warned; cleared when the expiry date changes
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS expiry_flagged_at TIMESTAMPTZ;
+-- A renewed certificate points at the certificate it replaces
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS renewal_of UUID REFERENCES achievement_references(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_achievement_references_expires_at
+    ON achievement_references (expires_at) WHERE expires_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_achievement_references_renewal_of
+    ON achievement_references (renewal_of) WHERE renewal_of IS NOT NULL;
@@ -0,0 +1,16 @@
+DELETE FROM permissions WHERE name = 'lecturer:manage';
+
+DROP INDEX IF EXISTS idx_achievement_references_deleted_at;
+DROP INDEX IF EXISTS idx_lecturers_deleted_at;
+DROP INDEX IF EXISTS idx_students_deleted_at;
+DROP INDEX IF EXISTS idx_users_deleted_at;
+
+ALTER TABLE achievement_references DROP COLUMN IF EXISTS deleted_from;
+ALTER TABLE achievement_references DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE achievement_references DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE lecturers DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE lecturers DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE students DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE students DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,31 @@
+-- Trash: soft-deleted rows keep deleted_at / deleted_by until restored or purged
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE students ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE students ADD COLUMN IF NOT EXISTS deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE lecturers ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE lecturers ADD COLUMN IF NOT EXISTS deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Achievements are trashed by the workflow (status deleted); deleted_from is the status to restore
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS deleted_from VARCHAR(20);
+
+UPDATE achievement_references
+SET deleted_at = updated_at, deleted_from = 'draft'
+WHERE status = 'deleted' AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_students_deleted_at ON students (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_lecturers_deleted_at ON lecturers (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_achievement_references_deleted_at
+    ON achievement_references (deleted_at) WHERE deleted_at IS NOT NULL;
+
+INSERT INTO permissions (name, resource, action, description) VALUES
+    ('lecturer:manage', 'lecturer', 'manage', 'Mengelola data dosen')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p ON p.name = 'lecturer:manage'
+WHERE r.name = 'Admin'
+ON CONFLICT DO NOTHING;
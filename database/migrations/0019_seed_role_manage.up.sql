@@ -0,0 +1,9 @@
+-- Role & permission administration API (/api/v1/roles, /api/v1/permissions)
+INSERT INTO permissions (name, resource, action, description) VALUES
+    ('role:manage', 'role', 'manage', 'Mengelola role dan permission')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p ON p.name = 'role:manage'
+WHERE r.name = 'Admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_permissions_notify ON permissions;
+DROP TRIGGER IF EXISTS trg_role_permissions_notify ON role_permissions;
+DROP FUNCTION IF EXISTS notify_permissions_changed();
+DROP FUNCTION IF EXISTS notify_role_permissions_changed();
@@ -0,0 +1,32 @@
+-- Announce permission changes on channel rbac_changed so every instance drops its cached
+-- permission sets (payload: role id; empty = all roles). Covers raw SQL changes too.
+CREATE OR REPLACE FUNCTION notify_role_permissions_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM pg_notify('rbac_changed', OLD.role_id::text);
+    ELSE
+        PERFORM pg_notify('rbac_changed', NEW.role_id::text);
+        IF TG_OP = 'UPDATE' AND OLD.role_id <> NEW.role_id THEN
+            PERFORM pg_notify('rbac_changed', OLD.role_id::text);
+        END IF;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION notify_permissions_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('rbac_changed', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_role_permissions_notify ON role_permissions;
+CREATE TRIGGER trg_role_permissions_notify
+    AFTER INSERT OR UPDATE OR DELETE ON role_permissions
+    FOR EACH ROW EXECUTE FUNCTION notify_role_permissions_changed();
+
+DROP TRIGGER IF EXISTS trg_permissions_notify ON permissions;
+CREATE TRIGGER trg_permissions_notify
+    AFTER UPDATE OR DELETE ON permissions
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_permissions_changed();
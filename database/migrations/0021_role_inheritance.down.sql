@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS trg_roles_notify ON roles;
+DROP FUNCTION IF EXISTS notify_roles_changed();
+
+DELETE FROM permissions WHERE name = '*:*';
+
+ALTER TABLE roles DROP COLUMN IF EXISTS parent_id;
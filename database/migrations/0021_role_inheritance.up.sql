@@ -0,0 +1,30 @@
+-- Role inheritance: a role holds its own permissions plus those of its parent chain
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES roles(id) ON DELETE SET NULL;
+
+UPDATE roles SET parent_id = (SELECT id FROM roles WHERE name = 'Mahasiswa')
+WHERE name = 'Dosen Wali' AND parent_id IS NULL;
+UPDATE roles SET parent_id = (SELECT id FROM roles WHERE name = 'Dosen Wali')
+WHERE name = 'Admin' AND parent_id IS NULL;
+
+-- Wildcard permission: *:* matches every resource and action
+INSERT INTO permissions (name, resource, action, description) VALUES
+    ('*:*', '*', '*', 'Semua permission')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p ON p.name = '*:*'
+WHERE r.name = 'Admin'
+ON CONFLICT DO NOTHING;
+
+-- A changed parent changes the effective permissions of the role and its descendants
+CREATE OR REPLACE FUNCTION notify_roles_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('rbac_changed', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_roles_notify ON roles;
+CREATE TRIGGER trg_roles_notify
+    AFTER UPDATE OF parent_id OR DELETE ON roles
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_roles_changed();
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_users_primary_role ON users;
+DROP FUNCTION IF EXISTS sync_user_primary_role();
+
+DROP TABLE IF EXISTS user_roles;
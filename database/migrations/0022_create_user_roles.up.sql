@@ -0,0 +1,33 @@
+-- A user may hold several roles; users.role_id stays as the primary role for older clients
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id    UUID NOT NULL REFERENCES roles(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_role_id ON user_roles (role_id);
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT id, role_id FROM users WHERE role_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+-- Writes to users.role_id replace the primary role in user_roles, so code that only knows
+-- role_id keeps both in step
+CREATE OR REPLACE FUNCTION sync_user_primary_role() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.role_id IS NOT NULL AND OLD.role_id IS DISTINCT FROM NEW.role_id THEN
+        DELETE FROM user_roles WHERE user_id = OLD.id AND role_id = OLD.role_id;
+    END IF;
+    IF NEW.role_id IS NOT NULL THEN
+        INSERT INTO user_roles (user_id, role_id) VALUES (NEW.id, NEW.role_id)
+        ON CONFLICT DO NOTHING;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_users_primary_role ON users;
+CREATE TRIGGER trg_users_primary_role
+    AFTER INSERT OR UPDATE OF role_id ON users
+    FOR EACH ROW EXECUTE FUNCTION sync_user_primary_role();
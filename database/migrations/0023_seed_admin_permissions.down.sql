@@ -0,0 +1,2 @@
+DELETE FROM permissions
+WHERE name IN ('trash:manage', 'schema:manage', 'scoring:manage', 'report:reconcile');
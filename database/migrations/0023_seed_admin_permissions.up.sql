@@ -0,0 +1,13 @@
+-- Permissions of admin routes that used to check the Admin role by name in the services
+INSERT INTO permissions (name, resource, action, description) VALUES
+    ('trash:manage', 'trash', 'manage', 'Melihat, memulihkan dan menghapus permanen isi trash'),
+    ('schema:manage', 'schema', 'manage', 'Mengelola schema details prestasi per kategori'),
+    ('scoring:manage', 'scoring', 'manage', 'Mengelola aturan poin dan batas poin per semester'),
+    ('report:reconcile', 'report', 'reconcile', 'Melihat laporan drift Postgres dan MongoDB')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p
+  ON p.name IN ('trash:manage', 'schema:manage', 'scoring:manage', 'report:reconcile')
+WHERE r.name = 'Admin'
+ON CONFLICT DO NOTHING;
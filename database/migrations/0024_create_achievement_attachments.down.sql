@@ -0,0 +1,3 @@
+ALTER TABLE achievement_references DROP COLUMN IF EXISTS attachments_indexed;
+
+DROP TABLE IF EXISTS achievement_attachments;
@@ -0,0 +1,14 @@
+-- Postgres index of the files attached to each achievement (the files themselves are listed in
+-- the Mongo document); the attachment limit and duplicate check are enforced here
+CREATE TABLE IF NOT EXISTS achievement_attachments (
+    id           TEXT PRIMARY KEY,
+    reference_id UUID NOT NULL REFERENCES achievement_references(id) ON DELETE CASCADE,
+    sha256       TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (reference_id, sha256)
+);
+
+-- Achievements written before the index are seeded from their document on the first
+-- attachment change; new achievements start indexed
+ALTER TABLE achievement_references ADD COLUMN IF NOT EXISTS attachments_indexed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE achievement_references ALTER COLUMN attachments_indexed SET DEFAULT TRUE;
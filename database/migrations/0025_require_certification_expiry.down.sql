@@ -0,0 +1,3 @@
+UPDATE achievement_schemas
+SET schema = jsonb_set(schema, '{required}', '["issuer", "number"]'::jsonb), updated_at = NOW()
+WHERE category = 'sertifikasi' AND schema->'required' = '["issuer", "number", "expiry"]'::jsonb;
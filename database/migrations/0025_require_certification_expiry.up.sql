@@ -0,0 +1,4 @@
+-- Certifications need their expiry date (see 0010); schemas an admin already changed are left alone
+UPDATE achievement_schemas
+SET schema = jsonb_set(schema, '{required}', '["issuer", "number", "expiry"]'::jsonb), updated_at = NOW()
+WHERE category = 'sertifikasi' AND schema->'required' = '["issuer", "number"]'::jsonb;
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoMigrations returns migrations applied to the Mongo database.
+// Versions share the numbering of the SQL files in database/migrations.
+func mongoMigrations() []Migration {
+	return []Migration{
+		{
+			Version: 3,
+			Name:    "achievements_indexes",
+			Store:   StoreMongo,
+			UpMongo: func(ctx context.Context, db *mongo.Database) error {
+				indexes := []mongo.IndexModel{
+					{Keys: bson.D{{Key: "studentId", Value: 1}}, Options: options.Index().SetName("studentId_1")},
+					{Keys: bson.D{{Key: "createdAt", Value: -1}}, Options: options.Index().SetName("createdAt_-1")},
+					{Keys: bson.D{{Key: "tags", Value: 1}}, Options: options.Index().SetName("tags_1")},
+				}
+				_, err := db.Collection("achievements").Indexes().CreateMany(ctx, indexes)
+				return err
+			},
+			DownMongo: func(ctx context.Context, db *mongo.Database) error {
+				return dropIndexes(ctx, db.Collection("achievements"), "studentId_1", "createdAt_-1", "tags_1")
+			},
+		},
+		{
+			Version: 6,
+			Name:    "achievements_filter_indexes",
+			Store:   StoreMongo,
+			UpMongo: func(ctx context.Context, db *mongo.Database) error {
+				indexes := []mongo.IndexModel{
+					{Keys: bson.D{{Key: "category", Value: 1}, {Key: "level", Value: 1}}, Options: options.Index().SetName("category_1_level_1")},
+					{Keys: bson.D{{Key: "type", Value: 1}}, Options: options.Index().SetName("type_1")},
+				}
+				_, err := db.Collection("achievements").Indexes().CreateMany(ctx, indexes)
+				return err
+			},
+			DownMongo: func(ctx context.Context, db *mongo.Database) error {
+				return dropIndexes(ctx, db.Collection("achievements"), "category_1_level_1", "type_1")
+			},
+		},
+		{
+			Version: 11,
+			Name:    "achievement_versions_indexes",
+			Store:   StoreMongo,
+			UpMongo: func(ctx context.Context, db *mongo.Database) error {
+				idx := mongo.IndexModel{
+					Keys:    bson.D{{Key: "achievementId", Value: 1}, {Key: "version", Value: 1}},
+					Options: options.Index().SetName("achievementId_1_version_1").SetUnique(true),
+				}
+				_, err := db.Collection("achievement_versions").Indexes().CreateOne(ctx, idx)
+				return err
+			},
+			DownMongo: func(ctx context.Context, db *mongo.Database) error {
+				return dropIndexes(ctx, db.Collection("achievement_versions"), "achievementId_1_version_1")
+			},
+		},
+	}
+}
+
+// dropIndexes drops the named indexes, ignoring ones that no longer exist.
+func dropIndexes(ctx context.Context, col *mongo.Collection, names ...string) error {
+	for _, name := range names {
+		if _, err := col.Indexes().DropOne(ctx, name); err != nil {
+			var cmdErr mongo.CommandError
+			if errors.As(err, &cmdErr) && cmdErr.Name == "IndexNotFound" {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
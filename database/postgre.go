@@ -1,10 +1,12 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"log"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // ConnectPostgres opens and returns a *sql.DB using the provided DSN.
@@ -26,3 +28,37 @@ func ConnectPostgres(dsn string) (*sql.DB, error) {
 	}
 	return db, nil
 }
+
+// ListenPostgres LISTENs on channel over a dedicated connection and calls fn with the payload
+// of every notification until ctx is cancelled. After a reconnect fn gets an empty payload,
+// since notifications sent while disconnected are lost.
+func ListenPostgres(ctx context.Context, dsn string, channel string, fn func(payload string)) error {
+	l := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("postgres listener %s: %v", channel, err)
+		}
+	})
+	if err := l.Listen(channel); err != nil {
+		_ = l.Close()
+		return err
+	}
+	go func() {
+		defer l.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n := <-l.Notify:
+				if n == nil { // reconnected
+					fn("")
+					continue
+				}
+				fn(n.Extra)
+			case <-time.After(90 * time.Second):
+				// keep the connection checked while idle
+				_ = l.Ping()
+			}
+		}
+	}()
+	return nil
+}
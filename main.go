@@ -14,6 +14,7 @@ import (
 	mongorepo "clean-arch/app/repository/mongo"
 	pgrepo "clean-arch/app/repository/postgre"
 	service "clean-arch/app/service"
+	"clean-arch/app/storage"
 	config "clean-arch/config"
 	db "clean-arch/database"
 	route "clean-arch/route"
@@ -55,13 +56,14 @@ func main() {
 
 	// Holders
 	var pgDB *sql.DB
+	var psqlDsn string
 	var mongoClient *mongo.Client
 	var mongoDB *mongo.Database
 
 	// Connect to Postgres if required or available
 	if driver == "postgres" || driver == "both" {
 		// Use provided DSN or build one from environment variables
-		psqlDsn := conf.PostgresDsn
+		psqlDsn = conf.PostgresDsn
 		if psqlDsn == "" {
 			// try to build from env vars (fallback)
 			host := os.Getenv("PG_HOST")
@@ -90,30 +92,6 @@ func main() {
 			}
 		}
 
-		// ... di dalam fungsi main()
-
-	// Build repositories
-	var userRepo pgrepo.UserRepository
-	// ... repo lainnya ...
-	var tokenRepo pgrepo.TokenRepository // <-- Tambahkan variabel ini
-
-	if pgDB != nil {
-		userRepo = pgrepo.NewUserRepository(pgDB)
-		// ... repo lainnya ...
-		tokenRepo = pgrepo.NewTokenRepository(pgDB) // <-- Inisialisasi di sini
-	}
-
-	// Build service repos struct
-	repos := &service.Repos{
-		UserRepo:           userRepo,
-		// ... repo lainnya ...
-		TokenRepo:          tokenRepo, // <-- Masukkan ke struct Repos
-	}
-
-	// Create services
-	service.NewServices(pgDB, mongoDB, repos)
-    
-// ...
 		var err error
 		pgDB, err = db.ConnectPostgres(psqlDsn)
 		if err != nil {
@@ -148,6 +126,18 @@ func main() {
 		log.Printf("connected to mongo (db=%s)", mongoDBName)
 	}
 
+	// CLI subcommand: migrate up|down [n]|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		code := runMigrate(pgDB, mongoDB, os.Args[2:])
+		if mongoClient != nil {
+			_ = mongoClient.Disconnect(context.Background())
+		}
+		if pgDB != nil {
+			_ = pgDB.Close()
+		}
+		os.Exit(code)
+	}
+
 	// Build repositories (only if DB connections exist)
 	var userRepo pgrepo.UserRepository
 	var studentRepo pgrepo.StudentRepository
@@ -156,6 +146,19 @@ func main() {
 	var permissionRepo pgrepo.PermissionRepository
 	var rolePermRepo pgrepo.RolePermissionRepository
 	var achRefRepo pgrepo.AchievementRefRepository
+	var activityLogRepo pgrepo.ActivityLogRepository
+	var tokenRepo pgrepo.TokenRepository
+	var sessionRepo pgrepo.SessionRepository
+	var delegationRepo pgrepo.DelegationRepository
+	var reviewRoundRepo pgrepo.ReviewRoundRepository
+	var memberRepo pgrepo.AchievementMemberRepository
+	var commentRepo pgrepo.AchievementCommentRepository
+	var attachmentRepo pgrepo.AchievementAttachmentRepository
+	var outboxRepo pgrepo.OutboxRepository
+	var schemaRepo pgrepo.AchievementSchemaRepository
+	var scoringRepo pgrepo.ScoringRuleRepository
+	var trashRepo pgrepo.TrashRepository
+	var transactor pgrepo.Transactor
 	var achRepo mongorepo.AchievementRepository
 
 	if pgDB != nil {
@@ -166,6 +169,19 @@ func main() {
 		permissionRepo = pgrepo.NewPermissionRepository(pgDB)
 		rolePermRepo = pgrepo.NewRolePermissionRepository(pgDB)
 		achRefRepo = pgrepo.NewAchievementRefRepository(pgDB)
+		activityLogRepo = pgrepo.NewActivityLogRepository(pgDB)
+		tokenRepo = pgrepo.NewTokenRepository(pgDB)
+		sessionRepo = pgrepo.NewSessionRepository(pgDB)
+		delegationRepo = pgrepo.NewDelegationRepository(pgDB)
+		reviewRoundRepo = pgrepo.NewReviewRoundRepository(pgDB)
+		memberRepo = pgrepo.NewAchievementMemberRepository(pgDB)
+		commentRepo = pgrepo.NewAchievementCommentRepository(pgDB)
+		attachmentRepo = pgrepo.NewAchievementAttachmentRepository(pgDB)
+		outboxRepo = pgrepo.NewOutboxRepository(pgDB)
+		schemaRepo = pgrepo.NewAchievementSchemaRepository(pgDB)
+		scoringRepo = pgrepo.NewScoringRuleRepository(pgDB)
+		trashRepo = pgrepo.NewTrashRepository(pgDB)
+		transactor = pgrepo.NewTransactor(pgDB)
 	}
 
 	if mongoDB != nil {
@@ -182,14 +198,89 @@ func main() {
 		LecturerRepo:       lecturerRepo,
 		AchievementRefRepo: achRefRepo,
 		AchievementRepo:    achRepo,
+		ActivityLogRepo:    activityLogRepo,
+		TokenRepo:          tokenRepo,
+		SessionRepo:        sessionRepo,
+		DelegationRepo:     delegationRepo,
+		ReviewRoundRepo:    reviewRoundRepo,
+		MemberRepo:         memberRepo,
+		CommentRepo:        commentRepo,
+		AttachmentRepo:     attachmentRepo,
+		OutboxRepo:         outboxRepo,
+		SchemaRepo:         schemaRepo,
+		ScoringRepo:        scoringRepo,
+		TrashRepo:          trashRepo,
+		Transactor:         transactor,
 	}
 
+	// Achievement workflow (state machine) from config; missing file = built-in default
+	workflow, err := service.LoadWorkflow(conf.WorkflowPath)
+	if err != nil {
+		log.Fatalf("failed load workflow: %v", err)
+	}
+
+	// Attachment file storage
+	var files storage.Storage
+	switch conf.StorageDriver {
+	case "local":
+		local, err := storage.NewLocalStorage(conf.StorageLocalRoot)
+		if err != nil {
+			log.Fatalf("failed init attachment storage: %v", err)
+		}
+		files = local
+	default:
+		log.Fatalf("unknown STORAGE_DRIVER %q", conf.StorageDriver)
+	}
+	repos.Storage = files
+
 	// Create services
-	services := service.NewServices(pgDB, mongoDB, repos)
+	services := service.NewServices(pgDB, mongoDB, repos, workflow, conf.AttachmentURLSecret)
 
 	// Register routes (assumes route.RegisterRoutes accepts app and services)
 	// You may need to adapt if your route.RegisterRoutes signature is different.
-	route.RegisterRoutes(app, services)
+	perms := route.RegisterRoutes(app, services)
+
+	// CLI subcommand: sync-permissions (create permissions required by routes/workflow, report orphans)
+	if len(os.Args) > 1 && os.Args[1] == "sync-permissions" {
+		code := 1
+		if pgDB != nil {
+			code = runSyncPermissions(app, services, perms)
+		} else {
+			log.Println("sync-permissions: postgres is not connected")
+		}
+		if mongoClient != nil {
+			_ = mongoClient.Disconnect(context.Background())
+		}
+		if pgDB != nil {
+			_ = pgDB.Close()
+		}
+		os.Exit(code)
+	}
+	if pgDB != nil && conf.PermissionSyncOnStart {
+		syncPermissionsOnStart(app, services, perms)
+	}
+
+	// Background jobs (stopped on shutdown)
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if pgDB != nil {
+		services.Auth.StartBlacklistSweeper(jobsCtx, 10*time.Minute)
+		// drop cached role permissions when role_permissions changes on any instance
+		if err := db.ListenPostgres(jobsCtx, psqlDsn, service.RBACNotifyChannel, services.RBAC.HandleChange); err != nil {
+			log.Printf("rbac change listener not started, cached permissions expire after RBAC_CACHE_TTL: %v", err)
+		}
+	}
+	if pgDB != nil && mongoDB != nil {
+		// retry Mongo writes left pending by a crash, then keep draining; report drift periodically
+		services.Achievement.StartOutboxRelay(jobsCtx, 30*time.Second)
+		services.Achievement.StartReconciler(jobsCtx, 6*time.Hour)
+		// flag certifications expiring within CERT_EXPIRY_NOTICE_DAYS (default 30)
+		services.Achievement.StartExpiryCheck(jobsCtx, 24*time.Hour)
+		// purge what has been in the trash longer than TRASH_RETENTION_DAYS (default 30)
+		services.Trash.StartTrashPurger(jobsCtx, 24*time.Hour)
+		// points recalculation queued by scoring rule changes
+		services.Scoring.StartRecalculator(jobsCtx)
+	}
 
 	// Swagger route
 	app.Get("/swagger/*", fiberSwagger.WrapHandler)
@@ -215,6 +306,7 @@ func main() {
 	case sig := <-quit:
 		log.Printf("signal %v received, shutting down...", sig)
 		// Graceful shutdown sequence
+		stopJobs()
 		ctxShutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
@@ -1,27 +1,26 @@
 package middleware
 
 import (
+	"context"
 	"strings"
-	"time"
-
-	"clean-arch/config"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 // Key names for locals
 const (
-	LocalsUserID = "user_id"
-	LocalsRoleID = "role_id"
+	LocalsUserID    = "user_id"
+	LocalsRoleID    = "role_id"  // primary role
+	LocalsRoleIDs   = "role_ids" // every role of the user, primary first
+	LocalsSessionID = "session_id"
 )
 
+// TokenVerifier memvalidasi token dan mengembalikan klaimnya.
+// Implementasinya adalah AuthService.VerifyToken (signature, expiry, blacklist, status akun).
+type TokenVerifier func(ctx context.Context, token string) (map[string]interface{}, error)
+
 // NewJWTMiddleware returns a Fiber middleware that validates JWT and sets c.Locals("user_id", id)
-func NewJWTMiddleware() fiber.Handler {
-	secret := config.Get().JWTSecret
-	if secret == "" {
-		secret = "dev-secret"
-	}
+func NewJWTMiddleware(verify TokenVerifier) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		auth := c.Get("Authorization")
 		if auth == "" {
@@ -31,28 +30,42 @@ func NewJWTMiddleware() fiber.Handler {
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid authorization header"})
 		}
-		tokenStr := parts[1]
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-			// ensure signing method
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fiber.ErrUnauthorized
-			}
-			return []byte(secret), nil
-		}, jwt.WithLeeway(5*time.Second))
-		if err != nil || !token.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid token"})
-		}
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid token claims"})
+		claims, err := verify(c.UserContext(), parts[1])
+		if err != nil {
+			// the cause (expired, revoked, lookup failure) stays on the server
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid or expired token"})
 		}
-		// expected claims: sub (user id), role
+		// expected claims: sub (user id), role (primary role), roles (all role ids), sid (session id)
 		if sub, ok := claims["sub"].(string); ok && sub != "" {
 			c.Locals(LocalsUserID, sub)
 		}
 		if role, ok := claims["role"].(string); ok && role != "" {
 			c.Locals(LocalsRoleID, role)
 		}
+		if roles, ok := claims["roles"].([]interface{}); ok {
+			ids := make([]string, 0, len(roles))
+			for _, r := range roles {
+				if id, ok := r.(string); ok && id != "" {
+					ids = append(ids, id)
+				}
+			}
+			c.Locals(LocalsRoleIDs, ids)
+		}
+		if sid, ok := claims["sid"].(string); ok && sid != "" {
+			c.Locals(LocalsSessionID, sid)
+		}
 		return c.Next()
 	}
 }
+
+// RoleIDs returns the roles of the caller. Tokens issued before multiple roles carry only
+// the role claim, which then is the only role.
+func RoleIDs(c *fiber.Ctx) []string {
+	if ids, ok := c.Locals(LocalsRoleIDs).([]string); ok && len(ids) > 0 {
+		return ids
+	}
+	if role, ok := c.Locals(LocalsRoleID).(string); ok && role != "" {
+		return []string{role}
+	}
+	return nil
+}
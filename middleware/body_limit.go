@@ -0,0 +1,21 @@
+package middleware
+
+import "github.com/gofiber/fiber/v2"
+
+// BodyLimit answers 413 for request bodies larger than limit, so handlers outside the upload
+// route never see a body sized for attachments. Requests for which skip returns true keep the
+// server-wide BodyLimit (config.NewFiberApp).
+//
+// fasthttp has already read the whole body, up to the server-wide limit, before any handler
+// runs: this limits what handlers accept, not how much memory a request may take.
+func BodyLimit(limit int, skip func(c *fiber.Ctx) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if skip != nil && skip(c) {
+			return c.Next()
+		}
+		if c.Request().Header.ContentLength() > limit || len(c.Request().Body()) > limit {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "request body too large"})
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RoutePermission is a registered route and the permission it requires (empty: none).
+type RoutePermission struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Permission string `json:"permission,omitempty"`
+}
+
+// PermissionRegistry registers routes together with the permission they require, so the
+// permission catalogue is derived from the routes instead of being maintained by hand.
+type PermissionRegistry struct {
+	check  PermissionChecker
+	mu     sync.RWMutex
+	routes map[string]string // "METHOD /full/path" -> permission
+	groups map[string]string // group prefix -> permission of every route under it
+}
+
+func NewPermissionRegistry(check PermissionChecker) *PermissionRegistry {
+	return &PermissionRegistry{
+		check:  check,
+		routes: make(map[string]string),
+		groups: make(map[string]string),
+	}
+}
+
+// Handle registers handlers for method and path on router behind RequirePermission(permission).
+// GET routes also answer HEAD, as with router.Get.
+func (r *PermissionRegistry) Handle(router fiber.Router, method, path, permission string, handlers ...fiber.Handler) fiber.Router {
+	r.mu.Lock()
+	r.routes[method+" "+joinPath(routerPrefix(router), path)] = permission
+	r.mu.Unlock()
+
+	guarded := append([]fiber.Handler{RequirePermission(r.check, permission)}, handlers...)
+	if method == fiber.MethodGet {
+		return router.Get(path, guarded...)
+	}
+	return router.Add(method, path, guarded...)
+}
+
+// Group creates a group whose routes all require permission; handlers (e.g. JWT auth) run
+// before the permission check.
+func (r *PermissionRegistry) Group(router fiber.Router, prefix, permission string, handlers ...fiber.Handler) fiber.Router {
+	r.mu.Lock()
+	r.groups[joinPath(routerPrefix(router), prefix)] = permission
+	r.mu.Unlock()
+
+	chain := append(append([]fiber.Handler{}, handlers...), RequirePermission(r.check, permission))
+	return router.Group(prefix, chain...)
+}
+
+// Routes lists every route of app (HEAD and middleware excluded) with the permission it
+// requires, sorted by path and method.
+func (r *PermissionRegistry) Routes(app *fiber.App) []RoutePermission {
+	out := []RoutePermission{}
+	seen := map[string]bool{}
+	for _, rt := range app.GetRoutes(true) {
+		key := rt.Method + " " + rt.Path
+		if rt.Method == fiber.MethodHead || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, RoutePermission{Method: rt.Method, Path: rt.Path, Permission: r.permissionFor(rt.Method, rt.Path)})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Method < out[j].Method
+	})
+	return out
+}
+
+// Permissions maps each permission required by a route of app to those routes ("METHOD path").
+func (r *PermissionRegistry) Permissions(app *fiber.App) map[string][]string {
+	out := map[string][]string{}
+	for _, rt := range r.Routes(app) {
+		if rt.Permission != "" {
+			out[rt.Permission] = append(out[rt.Permission], rt.Method+" "+rt.Path)
+		}
+	}
+	return out
+}
+
+// permissionFor returns the permission of a route, or that of the innermost group holding it
+func (r *PermissionRegistry) permissionFor(method, path string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if p, ok := r.routes[method+" "+path]; ok {
+		return p
+	}
+	best, perm := "", ""
+	for prefix, p := range r.groups {
+		if (path == prefix || strings.HasPrefix(path, strings.TrimRight(prefix, "/")+"/")) && len(prefix) > len(best) {
+			best, perm = prefix, p
+		}
+	}
+	return perm
+}
+
+func routerPrefix(router fiber.Router) string {
+	if g, ok := router.(*fiber.Group); ok {
+		return g.Prefix
+	}
+	return ""
+}
+
+// joinPath joins a group prefix and a route path the way fiber does
+func joinPath(prefix, path string) string {
+	if path == "" {
+		return prefix
+	}
+	if path[0] != '/' {
+		path = "/" + path
+	}
+	return strings.TrimRight(prefix, "/") + path
+}
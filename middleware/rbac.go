@@ -8,21 +8,24 @@ import (
 // Implementasikan wrapper yang memanggil RBACService.HasPermissionByRoleID di tempat wiring.
 type PermissionChecker func(roleID string, permission string) (bool, error)
 
-// RequirePermission returns a middleware that checks permission string (e.g. "achievement:verify")
+// RequirePermission returns a middleware that checks permission string (e.g. "achievement:verify").
+// A user with several roles passes if any of them holds the permission.
 func RequirePermission(check PermissionChecker, permission string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		role, ok := c.Locals(LocalsRoleID).(string)
-		if !ok || role == "" {
+		roles := RoleIDs(c)
+		if len(roles) == 0 {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "role not found in token"})
 		}
-		okPerm, err := check(role, permission)
-		if err != nil {
-			// optionally log error
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "permission check failed"})
+		for _, role := range roles {
+			okPerm, err := check(role, permission)
+			if err != nil {
+				// optionally log error
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "permission check failed"})
+			}
+			if okPerm {
+				return c.Next()
+			}
 		}
-		if !okPerm {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "permission denied"})
-		}
-		return c.Next()
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "permission denied"})
 	}
 }
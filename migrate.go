@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	db "clean-arch/database"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// runMigrate handles `migrate up`, `migrate down [n]` and `migrate status`.
+// Returns the process exit code.
+func runMigrate(pgDB *sql.DB, mongoDB *mongo.Database, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: migrate up|down [n]|status")
+		return 2
+	}
+
+	migrator, err := db.NewMigrator(pgDB, mongoDB)
+	if err != nil {
+		log.Printf("migrate: %v", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx)
+		for _, m := range done {
+			log.Printf("applied %04d_%s (%s)", m.Version, m.Name, m.Store)
+		}
+		if err != nil {
+			log.Printf("migrate up: %v", err)
+			return 1
+		}
+		if len(done) == 0 {
+			log.Println("no pending migrations")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				fmt.Fprintln(os.Stderr, "usage: migrate down [n]")
+				return 2
+			}
+			steps = n
+		}
+		done, err := migrator.Down(ctx, steps)
+		for _, m := range done {
+			log.Printf("rolled back %04d_%s (%s)", m.Version, m.Name, m.Store)
+		}
+		if err != nil {
+			log.Printf("migrate down: %v", err)
+			return 1
+		}
+	case "status":
+		list, err := migrator.Status(ctx)
+		if err != nil {
+			log.Printf("migrate status: %v", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTORE\tSTATUS")
+		for _, st := range list {
+			state := "pending"
+			switch {
+			case st.Skipped:
+				state = "skipped (not connected)"
+			case st.Applied:
+				state = "applied " + st.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", st.Version, st.Name, st.Store, state)
+		}
+		_ = w.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, "usage: migrate up|down [n]|status")
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,20 @@
+package route
+
+import (
+	"context"
+
+	"clean-arch/app/service"
+	"clean-arch/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SyncPermissions menyamakan tabel permissions dengan permission yang dibutuhkan route
+// (dari registry) dan transisi workflow: yang belum ada dibuat, yang yatim dilaporkan.
+func SyncPermissions(ctx context.Context, app *fiber.App, s *service.Services, perms *middleware.PermissionRegistry) (*service.PermissionSyncResult, error) {
+	declared := perms.Permissions(app)
+	for name, where := range s.Achievement.WorkflowPermissions() {
+		declared[name] = append(declared[name], where...)
+	}
+	return s.Role.SyncPermissions(ctx, declared)
+}
@@ -2,6 +2,10 @@ package route
 
 import (
 	"context"
+	"errors"
+	"io"
+	"log"
+	"regexp"
 	"time"
 
 	mongoModel "clean-arch/app/model/mongo"
@@ -13,13 +17,23 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// attachmentUploadPath cocok dengan POST /api/v1/achievements/:id/attachments, satu-satunya
+// route yang boleh memakai batas body besar (ATTACHMENT_MAX_SIZE)
+var attachmentUploadPath = regexp.MustCompile(`^/api/v1/achievements/[^/]+/attachments/?$`)
+
 // RegisterRoutes mendaftarkan semua endpoint API ke dalam Fiber App
-func RegisterRoutes(app *fiber.App, s *service.Services) {
+// RegisterRoutes mendaftarkan semua route dan mengembalikan registry permission-nya
+// (dipakai untuk sinkronisasi katalog permission, lihat SyncPermissions)
+func RegisterRoutes(app *fiber.App, s *service.Services) *middleware.PermissionRegistry {
 
 	// 1. Global Middleware
 	app.Use(middleware.RequestID())
 	app.Use(middleware.Helmet())
 	app.Use(middleware.RateLimiter())
+	// Batas body server dinaikkan untuk upload lampiran; route lain tetap dibatasi default fiber
+	app.Use(middleware.BodyLimit(fiber.DefaultBodyLimit, func(c *fiber.Ctx) bool {
+		return c.Method() == fiber.MethodPost && attachmentUploadPath.MatchString(c.Path())
+	}))
 	// app.Use(middleware.Logger()) // Opsional, sudah ada di config/app.go
 
 	// Helper untuk context dengan timeout standar
@@ -33,6 +47,80 @@ func RegisterRoutes(app *fiber.App, s *service.Services) {
 		return s.RBAC.HasPermissionByRoleID(context.Background(), roleID, permission)
 	}
 
+	// Registry route -> permission; katalog permission diturunkan dari sini
+	perms := middleware.NewPermissionRegistry(rbacCheck)
+
+	// Identitas pemanggil (dari klaim JWT) untuk policy di service layer
+	currentActor := func(c *fiber.Ctx) service.Actor {
+		userID, _ := c.Locals(middleware.LocalsUserID).(string)
+		roleID, _ := c.Locals(middleware.LocalsRoleID).(string)
+		roleIDs, _ := c.Locals(middleware.LocalsRoleIDs).([]string)
+		return service.Actor{UserID: userID, RoleID: roleID, RoleIDs: roleIDs}
+	}
+
+	// Map error service ke status HTTP (404/403 dari CustomError, 422 untuk details yang tidak sesuai schema,
+	// 409 + kandidat untuk kemungkinan duplikat)
+	serviceError := func(c *fiber.Ctx, err error, fallback int) error {
+		var ce *service.CustomError
+		if errors.As(err, &ce) {
+			return utils.JSONError(c, ce.Status, ce.Message)
+		}
+		var ve *service.DetailsValidationError
+		if errors.As(err, &ve) {
+			return utils.JSONErrorDetails(c, fiber.StatusUnprocessableEntity, ve.Error(), ve.Violations)
+		}
+		var de *service.DuplicateError
+		if errors.As(err, &de) {
+			return utils.JSONErrorDetails(c, fiber.StatusConflict, de.Error(), de.Matches)
+		}
+		return utils.JSONError(c, fallback, err.Error())
+	}
+
+	// Trash per entitas (trash:manage): GET /trash, PATCH /:id/restore, DELETE /:id/hard.
+	// Didaftarkan sebelum route /:id lain agar "trash" tidak terbaca sebagai id.
+	trashRoutes := func(group fiber.Router, entity string) {
+		// GET <entity>/trash (isi trash + purge_at sesuai TRASH_RETENTION_DAYS)
+		perms.Handle(group, fiber.MethodGet, "/trash", "trash:manage", func(c *fiber.Ctx) error {
+			ctx, cancel := timeoutContext(c)
+			defer cancel()
+
+			list, err := s.Trash.List(ctx, entity)
+			if err != nil {
+				return serviceError(c, err, fiber.StatusInternalServerError)
+			}
+			return utils.JSONSuccess(c, fiber.StatusOK, list)
+		})
+
+		// PATCH <entity>/:id/restore (kembalikan dari trash)
+		perms.Handle(group, fiber.MethodPatch, "/:id/restore", "trash:manage", func(c *fiber.Ctx) error {
+			id := c.Params("id")
+			ctx, cancel := timeoutContext(c)
+			defer cancel()
+
+			if err := s.Trash.Restore(ctx, currentActor(c), entity, id); err != nil {
+				return serviceError(c, err, fiber.StatusInternalServerError)
+			}
+			if entity == pgModel.TrashUsers {
+				s.Auth.InvalidateUser(id)
+			}
+			return utils.JSONSuccess(c, fiber.StatusOK, "Restored")
+		})
+
+		// DELETE <entity>/:id/hard (hapus permanen; harus sudah di trash)
+		perms.Handle(group, fiber.MethodDelete, "/:id/hard", "trash:manage", func(c *fiber.Ctx) error {
+			ctx, cancel := timeoutContext(c)
+			defer cancel()
+
+			if err := s.Trash.Purge(ctx, currentActor(c), entity, c.Params("id")); err != nil {
+				return serviceError(c, err, fiber.StatusInternalServerError)
+			}
+			return utils.JSONSuccess(c, fiber.StatusOK, "Permanently deleted")
+		})
+	}
+
+	// JWT middleware: signature, expiry, blacklist (jti) & status akun via AuthService
+	jwtAuth := middleware.NewJWTMiddleware(s.Auth.VerifyToken)
+
 	// API Group Base
 	api := app.Group("/api/v1")
 
@@ -51,32 +139,85 @@ func RegisterRoutes(app *fiber.App, s *service.Services) {
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
 
-		token, user, err := s.Auth.Login(ctx, req.Username, req.Password)
+		client := service.ClientInfo{DeviceName: req.DeviceName, UserAgent: c.Get("User-Agent"), IP: c.IP()}
+		pair, user, err := s.Auth.Login(ctx, req.Username, req.Password, client)
 		if err != nil {
 			return utils.JSONError(c, fiber.StatusUnauthorized, err.Error())
 		}
 
 		return utils.JSONSuccess(c, fiber.StatusOK, fiber.Map{
-			"token": token,
-			"user":  user,
+			"token":              pair.AccessToken,
+			"refresh_token":      pair.RefreshToken,
+			"token_type":         pair.TokenType,
+			"expires_in":         pair.ExpiresIn,
+			"refresh_expires_at": pair.RefreshExpiresAt,
+			"session_id":         pair.SessionID,
+			"user":               user,
 		})
 	})
 
-	// POST /auth/refresh
-	authGroup.Post("/refresh", middleware.NewJWTMiddleware(), func(c *fiber.Ctx) error {
-		userID := c.Locals(middleware.LocalsUserID).(string)
+	// POST /auth/refresh (body: refresh_token; access token boleh sudah expired)
+	authGroup.Post("/refresh", func(c *fiber.Ctx) error {
+		var req pgModel.RefreshRequest
+		if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+			return utils.JSONError(c, fiber.StatusBadRequest, "refresh_token is required")
+		}
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
 
-		newToken, err := s.Auth.Refresh(ctx, userID)
+		client := service.ClientInfo{UserAgent: c.Get("User-Agent"), IP: c.IP()}
+		pair, err := s.Auth.Refresh(ctx, req.RefreshToken, client)
 		if err != nil {
 			return utils.JSONError(c, fiber.StatusUnauthorized, err.Error())
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, fiber.Map{"token": newToken})
+		return utils.JSONSuccess(c, fiber.StatusOK, pair)
+	})
+
+	// GET /auth/sessions (perangkat yang sedang login)
+	authGroup.Get("/sessions", jwtAuth, func(c *fiber.Ctx) error {
+		userID := c.Locals(middleware.LocalsUserID).(string)
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		list, err := s.Auth.ListSessions(ctx, userID)
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, fiber.Map{
+			"current_session_id": c.Locals(middleware.LocalsSessionID),
+			"sessions":           list,
+		})
+	})
+
+	// DELETE /auth/sessions (logout dari semua perangkat)
+	authGroup.Delete("/sessions", jwtAuth, func(c *fiber.Ctx) error {
+		userID := c.Locals(middleware.LocalsUserID).(string)
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Auth.RevokeAllSessions(ctx, userID); err != nil {
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "All sessions revoked")
+	})
+
+	// DELETE /auth/sessions/:id
+	authGroup.Delete("/sessions/:id", jwtAuth, func(c *fiber.Ctx) error {
+		userID := c.Locals(middleware.LocalsUserID).(string)
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Auth.RevokeSession(ctx, userID, c.Params("id")); err != nil {
+			if errors.Is(err, service.ErrSessionNotFound) {
+				return utils.JSONError(c, fiber.StatusNotFound, err.Error())
+			}
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Session revoked")
 	})
 
 	// POST /auth/logout
-	authGroup.Post("/logout", middleware.NewJWTMiddleware(), func(c *fiber.Ctx) error {
+	authGroup.Post("/logout", jwtAuth, func(c *fiber.Ctx) error {
 		// Ambil token mentah dari header untuk diblacklist
 		authHeader := c.Get("Authorization")
 		if len(authHeader) < 7 {
@@ -94,7 +235,7 @@ func RegisterRoutes(app *fiber.App, s *service.Services) {
 	})
 
 	// GET /auth/profile
-	authGroup.Get("/profile", middleware.NewJWTMiddleware(), func(c *fiber.Ctx) error {
+	authGroup.Get("/profile", jwtAuth, func(c *fiber.Ctx) error {
 		userID := c.Locals(middleware.LocalsUserID).(string)
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
@@ -110,10 +251,11 @@ func RegisterRoutes(app *fiber.App, s *service.Services) {
 	// 5.2 USERS (ADMIN)
 	// =========================================================================
 	// Group ini dilindungi Auth & RBAC (misal permission: 'user:manage')
-	userGroup := api.Group("/users", middleware.NewJWTMiddleware())
+	userGroup := api.Group("/users", jwtAuth)
+	trashRoutes(userGroup, pgModel.TrashUsers)
 	
 	// GET /users
-	userGroup.Get("/", middleware.RequirePermission(rbacCheck, "user:read"), func(c *fiber.Ctx) error {
+	perms.Handle(userGroup, fiber.MethodGet, "/", "user:read", func(c *fiber.Ctx) error {
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
 		users, err := s.User.ListAll(ctx)
@@ -124,7 +266,7 @@ func RegisterRoutes(app *fiber.App, s *service.Services) {
 	})
 
 	// POST /users
-	userGroup.Post("/", middleware.RequirePermission(rbacCheck, "user:create"), func(c *fiber.Ctx) error {
+	perms.Handle(userGroup, fiber.MethodPost, "/", "user:create", func(c *fiber.Ctx) error {
 		var u pgModel.User
 		if err := c.BodyParser(&u); err != nil {
 			return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
@@ -144,28 +286,85 @@ func RegisterRoutes(app *fiber.App, s *service.Services) {
 		return utils.JSONSuccess(c, fiber.StatusCreated, "User created")
 	})
 
-	// PUT /users/:id
-	userGroup.Put("/:id", middleware.RequirePermission(rbacCheck, "user:update"), func(c *fiber.Ctx) error {
+	// GET /users/:id (ETag = versi user; If-None-Match -> 304)
+	perms.Handle(userGroup, fiber.MethodGet, "/:id", "user:read", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		u, err := s.User.GetByID(ctx, c.Params("id"))
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusNotFound, "User not found")
+		}
+		if utils.NotModified(c, utils.ETag(u.Version)) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, u)
+	})
+
+	// GET /users/:id/effective-permissions (permission efektif role user beserta sumbernya)
+	perms.Handle(userGroup, fiber.MethodGet, "/:id/effective-permissions", "role:manage", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		eff, err := s.Role.EffectiveForUser(ctx, c.Params("id"))
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, eff)
+	})
+
+	// PUT /users/:id (If-Match: versi dari ETag; 412 jika user sudah diubah orang lain)
+	perms.Handle(userGroup, fiber.MethodPut, "/:id", "user:update", func(c *fiber.Ctx) error {
 		id := c.Params("id")
 		var u pgModel.User
 		if err := c.BodyParser(&u); err != nil {
 			return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
 		}
 		u.ID = id
+		ifMatch, err := utils.IfMatchVersion(c)
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
+		}
 		
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
 		
-		if err := s.User.Update(ctx, &u); err != nil {
-			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		if err := s.User.Update(ctx, &u, ifMatch); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
+		s.Auth.InvalidateUser(id) // is_active mungkin berubah
+		c.Set(fiber.HeaderETag, utils.ETag(u.Version))
 		return utils.JSONSuccess(c, fiber.StatusOK, "User updated")
 	})
 
-	// PUT /users/:id/role (Assign Role)
-	userGroup.Put("/:id/role", middleware.RequirePermission(rbacCheck, "user:assign_role"), func(c *fiber.Ctx) error {
+	// PUT /users/:id/roles (set semua role user; role_ids[0] jadi role utama / role_id,
+	// berlaku di token berikutnya setelah refresh)
+	perms.Handle(userGroup, fiber.MethodPut, "/:id/roles", "user:assign_role", func(c *fiber.Ctx) error {
+		var req struct {
+			RoleIDs []string `json:"role_ids"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+		}
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+		roles, err := s.Role.SetUserRoles(ctx, currentActor(c), c.Params("id"), req.RoleIDs)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		// token lama masih membawa role lama; refresh berikutnya memakai role baru
+		if err := s.Auth.RevokeAccessTokens(ctx, c.Params("id")); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, roles)
+	})
+
+	// PUT /users/:id/role (Assign Role: ganti role utama, role lain tetap)
+	perms.Handle(userGroup, fiber.MethodPut, "/:id/role", "user:assign_role", func(c *fiber.Ctx) error {
 		id := c.Params("id")
-		var req struct { RoleID string `json:"role_id"` }
+		var req struct {
+			RoleID string `json:"role_id"`
+		}
 		if err := c.BodyParser(&req); err != nil {
 			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
 		}
@@ -173,266 +372,1143 @@ func RegisterRoutes(app *fiber.App, s *service.Services) {
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
 
-		if err := s.User.UpdateRole(ctx, id, req.RoleID); err != nil {
-			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		if _, err := s.Role.SetPrimaryRole(ctx, currentActor(c), id, req.RoleID); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		if err := s.Auth.RevokeAccessTokens(ctx, id); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
 		return utils.JSONSuccess(c, fiber.StatusOK, "Role updated")
 	})
 
-	// DELETE /users/:id
-	userGroup.Delete("/:id", middleware.RequirePermission(rbacCheck, "user:delete"), func(c *fiber.Ctx) error {
+	// DELETE /users/:id (pindah ke trash beserta profil mahasiswa/dosen; If-Match opsional)
+	perms.Handle(userGroup, fiber.MethodDelete, "/:id", "user:delete", func(c *fiber.Ctx) error {
 		id := c.Params("id")
+		ifMatch, err := utils.IfMatchVersion(c)
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
+		}
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
 
-		if err := s.User.Delete(ctx, id); err != nil {
-			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		if err := s.User.Delete(ctx, currentActor(c), id, ifMatch); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
+		s.Auth.InvalidateUser(id)
 		return utils.JSONSuccess(c, fiber.StatusOK, "User deleted")
 	})
 
-	// =========================================================================
-	// 5.5 STUDENTS & LECTURERS
-	// =========================================================================
-	studentGroup := api.Group("/students", middleware.NewJWTMiddleware())
-	lecturerGroup := api.Group("/lecturers", middleware.NewJWTMiddleware())
-
-	// GET /students (List)
-	studentGroup.Get("/", func(c *fiber.Ctx) error {
+	// POST /users/:id/force-logout (cabut semua token & session user)
+	perms.Handle(userGroup, fiber.MethodPost, "/:id/force-logout", "user:update", func(c *fiber.Ctx) error {
+		id := c.Params("id")
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
-		list, err := s.Student.ListAll(ctx)
-		if err != nil {
-			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+
+		if err := s.Auth.ForceLogout(ctx, id); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, list)
+		return utils.JSONSuccess(c, fiber.StatusOK, "User logged out from all devices")
 	})
 
-	// GET /students/:id
-	studentGroup.Get("/:id", func(c *fiber.Ctx) error {
-		// Note: ID disini bisa StudentID (UUID) atau NIM, sesuaikan dengan logic service
-		// Service GetByID expect UUID table ID
-		id := c.Params("id")
+	// =========================================================================
+	// ROLES & PERMISSIONS (ADMIN; setiap perubahan dicatat di activity_logs)
+	// =========================================================================
+	roleGroup := perms.Group(api, "/roles", "role:manage", jwtAuth)
+	permissionGroup := perms.Group(api, "/permissions", "role:manage", jwtAuth)
+
+	// GET /roles (beserta permission & jumlah user)
+	roleGroup.Get("/", func(c *fiber.Ctx) error {
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
-		st, err := s.Student.GetByID(ctx, id) 
+
+		list, err := s.Role.ListRoles(ctx)
 		if err != nil {
-			return utils.JSONError(c, fiber.StatusNotFound, err.Error())
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, st)
+		return utils.JSONSuccess(c, fiber.StatusOK, list)
 	})
 
-	// PUT /students/:id/advisor (Set Advisor) - Admin Only
-	studentGroup.Put("/:id/advisor", middleware.RequirePermission(rbacCheck, "student:manage"), func(c *fiber.Ctx) error {
-		id := c.Params("id")
-		var req struct { AdvisorID string `json:"advisor_id"` }
+	// POST /roles (permissions: daftar nama permission awal, opsional)
+	roleGroup.Post("/", func(c *fiber.Ctx) error {
+		var req service.RoleRequest
 		if err := c.BodyParser(&req); err != nil {
 			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
 		}
-		
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
-		
-		if err := s.Student.UpdateAdvisor(ctx, id, &req.AdvisorID); err != nil {
-			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
-		}
-		return utils.JSONSuccess(c, fiber.StatusOK, "Advisor updated")
-	})
 
-	// GET /lecturers
-	lecturerGroup.Get("/", func(c *fiber.Ctx) error {
-		ctx, cancel := timeoutContext(c)
-		defer cancel()
-		list, err := s.Lecturer.ListAll(ctx)
+		role, err := s.Role.CreateRole(ctx, currentActor(c), req)
 		if err != nil {
-			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, list)
+		return utils.JSONSuccess(c, fiber.StatusCreated, role)
 	})
 
-	// GET /lecturers/:id/advisees (Mahasiswa Bimbingan)
-	lecturerGroup.Get("/:id/advisees", func(c *fiber.Ctx) error {
-		id := c.Params("id") // Lecturer ID
+	// GET /roles/:id
+	roleGroup.Get("/:id", func(c *fiber.Ctx) error {
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
-		list, err := s.Lecturer.GetAdvisees(ctx, id)
+
+		role, err := s.Role.GetRole(ctx, c.Params("id"))
 		if err != nil {
-			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, list)
+		return utils.JSONSuccess(c, fiber.StatusOK, role)
 	})
 
-	// =========================================================================
-	// 5.4 ACHIEVEMENTS (CORE)
-	// =========================================================================
-	achGroup := api.Group("/achievements", middleware.NewJWTMiddleware())
-
-	// GET /achievements (List All - Filtered by Service logic)
-	// Permission: Admin atau Lecturer (lihat semua/bimbingan), Student (lihat punya sendiri biasanya via endpoint profile)
-	achGroup.Get("/", func(c *fiber.Ctx) error {
-		// TODO: Parse query params for filtering
+	// GET /roles/:id/effective-permissions (permission efektif: warisan parent + wildcard, beserta sumbernya)
+	roleGroup.Get("/:id/effective-permissions", func(c *fiber.Ctx) error {
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
-		list, err := s.Achievement.GetAllAchievements(ctx, nil)
+
+		eff, err := s.Role.EffectiveForRole(ctx, c.Params("id"))
 		if err != nil {
-			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, list)
+		return utils.JSONSuccess(c, fiber.StatusOK, eff)
 	})
 
-	// POST /achievements (Create Draft - Mahasiswa)
-	achGroup.Post("/", middleware.RequirePermission(rbacCheck, "achievement:create"), func(c *fiber.Ctx) error {
-		userID := c.Locals(middleware.LocalsUserID).(string)
-		var doc mongoModel.Achievement
-		if err := c.BodyParser(&doc); err != nil {
+	// PUT /roles/:id (nama, deskripsi & parent_id; "" = tanpa parent; role bawaan tidak bisa diganti nama)
+	roleGroup.Put("/:id", func(c *fiber.Ctx) error {
+		var req service.RoleRequest
+		if err := c.BodyParser(&req); err != nil {
 			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
 		}
-
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
 
-		result, err := s.Achievement.CreateDraft(ctx, userID, &doc)
+		role, err := s.Role.UpdateRole(ctx, currentActor(c), c.Params("id"), req)
 		if err != nil {
-			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
-		return utils.JSONSuccess(c, fiber.StatusCreated, result)
+		return utils.JSONSuccess(c, fiber.StatusOK, role)
 	})
 
-	// GET /achievements/:id (Detail)
-	achGroup.Get("/:id", func(c *fiber.Ctx) error {
-		id := c.Params("id")
+	// DELETE /roles/:id (409 jika masih dipakai user)
+	roleGroup.Delete("/:id", func(c *fiber.Ctx) error {
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
 
-		mongoData, pgRef, err := s.Achievement.GetDetail(ctx, id)
-		if err != nil {
-			return utils.JSONError(c, fiber.StatusNotFound, err.Error())
+		if err := s.Role.DeleteRole(ctx, currentActor(c), c.Params("id")); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, fiber.Map{
-			"reference": pgRef,
-			"detail":    mongoData,
-		})
+		return utils.JSONSuccess(c, fiber.StatusOK, "Role deleted")
 	})
 
-	// PUT /achievements/:id (Update Draft - Mahasiswa)
-	achGroup.Put("/:id", middleware.RequirePermission(rbacCheck, "achievement:update"), func(c *fiber.Ctx) error {
-		id := c.Params("id")
-		userID := c.Locals(middleware.LocalsUserID).(string)
-		var updates map[string]interface{}
-		if err := c.BodyParser(&updates); err != nil {
+	// POST /roles/:id/permissions (body: permission = id atau nama, mis. achievement:verify)
+	roleGroup.Post("/:id/permissions", func(c *fiber.Ctx) error {
+		var req struct {
+			Permission string `json:"permission"`
+		}
+		if err := c.BodyParser(&req); err != nil {
 			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
 		}
-
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
 
-		if err := s.Achievement.UpdateDraft(ctx, id, userID, updates); err != nil {
-			return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
+		role, err := s.Role.AssignPermission(ctx, currentActor(c), c.Params("id"), req.Permission)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, "Draft updated")
+		return utils.JSONSuccess(c, fiber.StatusOK, role)
 	})
 
-	// DELETE /achievements/:id (Delete Draft - Mahasiswa)
-	achGroup.Delete("/:id", middleware.RequirePermission(rbacCheck, "achievement:delete"), func(c *fiber.Ctx) error {
-		id := c.Params("id")
-		userID := c.Locals(middleware.LocalsUserID).(string)
-		
+	// DELETE /roles/:id/permissions/:permission (id atau nama permission)
+	roleGroup.Delete("/:id/permissions/:permission", func(c *fiber.Ctx) error {
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
 
-		if err := s.Achievement.DeleteDraft(ctx, id, userID); err != nil {
-			return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
+		role, err := s.Role.RemovePermission(ctx, currentActor(c), c.Params("id"), c.Params("permission"))
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, "Draft deleted")
+		return utils.JSONSuccess(c, fiber.StatusOK, role)
 	})
 
-	// POST /achievements/:id/submit (Submit for Verification - Mahasiswa)
-	achGroup.Post("/:id/submit", middleware.RequirePermission(rbacCheck, "achievement:submit"), func(c *fiber.Ctx) error {
-		id := c.Params("id")
-		userID := c.Locals(middleware.LocalsUserID).(string)
-
+	// GET /permissions
+	permissionGroup.Get("/", func(c *fiber.Ctx) error {
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
 
-		if err := s.Achievement.Submit(ctx, id, userID); err != nil {
-			return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
+		list, err := s.Role.ListPermissions(ctx)
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, "Achievement submitted")
+		return utils.JSONSuccess(c, fiber.StatusOK, list)
 	})
 
-	// POST /achievements/:id/verify (Verify - Dosen Wali)
-	achGroup.Post("/:id/verify", middleware.RequirePermission(rbacCheck, "achievement:verify"), func(c *fiber.Ctx) error {
-		id := c.Params("id")
-		verifierID := c.Locals(middleware.LocalsUserID).(string)
+	// GET /permissions/cache-stats (hit/miss cache permission per role di instance ini)
+	permissionGroup.Get("/cache-stats", func(c *fiber.Ctx) error {
+		return utils.JSONSuccess(c, fiber.StatusOK, s.RBAC.CacheStats())
+	})
 
+	// GET /permissions/routes (semua route: method, path, permission yang dibutuhkan)
+	permissionGroup.Get("/routes", func(c *fiber.Ctx) error {
+		return utils.JSONSuccess(c, fiber.StatusOK, perms.Routes(app))
+	})
+
+	// POST /permissions/sync (buat permission yang dibutuhkan route/workflow tapi belum ada; laporkan yang yatim)
+	permissionGroup.Post("/sync", func(c *fiber.Ctx) error {
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
-
-		if err := s.Achievement.Verify(ctx, id, verifierID); err != nil {
-			return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
+		res, err := SyncPermissions(ctx, app, s, perms)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, "Achievement verified")
+		return utils.JSONSuccess(c, fiber.StatusOK, res)
 	})
 
-	// POST /achievements/:id/reject (Reject - Dosen Wali)
-	achGroup.Post("/:id/reject", middleware.RequirePermission(rbacCheck, "achievement:verify"), func(c *fiber.Ctx) error {
-		id := c.Params("id")
-		verifierID := c.Locals(middleware.LocalsUserID).(string)
-		
-		var req struct { Note string `json:"note"` }
+	// POST /permissions (name: resource:action; resource & action diambil dari name)
+	permissionGroup.Post("/", func(c *fiber.Ctx) error {
+		var req service.PermissionRequest
 		if err := c.BodyParser(&req); err != nil {
-			return utils.JSONError(c, fiber.StatusBadRequest, "Note is required")
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
 		}
-
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
 
-		if err := s.Achievement.Reject(ctx, id, verifierID, req.Note); err != nil {
-			return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
+		p, err := s.Role.CreatePermission(ctx, currentActor(c), req)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, "Achievement rejected")
+		return utils.JSONSuccess(c, fiber.StatusCreated, p)
 	})
 
-	// GET /achievements/:id/history (History Log)
-	achGroup.Get("/:id/history", func(c *fiber.Ctx) error {
-		id := c.Params("id")
+	// PUT /permissions/:id
+	permissionGroup.Put("/:id", func(c *fiber.Ctx) error {
+		var req service.PermissionRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+		}
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
 
-		hist, err := s.Report.GetAchievementHistory(ctx, id)
+		p, err := s.Role.UpdatePermission(ctx, currentActor(c), c.Params("id"), req)
 		if err != nil {
-			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+			return serviceError(c, err, fiber.StatusInternalServerError)
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, hist)
+		return utils.JSONSuccess(c, fiber.StatusOK, p)
+	})
+
+	// DELETE /permissions/:id (dicabut juga dari semua role)
+	permissionGroup.Delete("/:id", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Role.DeletePermission(ctx, currentActor(c), c.Params("id")); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Permission deleted")
 	})
 
 	// =========================================================================
-	// 5.8 REPORTS & ANALYTICS
+	// 5.5 STUDENTS & LECTURERS
 	// =========================================================================
-	reportGroup := api.Group("/reports", middleware.NewJWTMiddleware())
+	studentGroup := api.Group("/students", jwtAuth)
+	lecturerGroup := api.Group("/lecturers", jwtAuth)
+	trashRoutes(studentGroup, pgModel.TrashStudents)
+	trashRoutes(lecturerGroup, pgModel.TrashLecturers)
 
-	// GET /reports/statistics (Global Stats - Admin/Dosen)
-	reportGroup.Get("/statistics", middleware.RequirePermission(rbacCheck, "report:view"), func(c *fiber.Ctx) error {
+	// GET /students (List)
+	studentGroup.Get("/", func(c *fiber.Ctx) error {
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
-
-		stats, err := s.Report.GetAllAchievementsStatistics(ctx)
+		list, err := s.Student.ListAll(ctx)
 		if err != nil {
 			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, stats)
+		return utils.JSONSuccess(c, fiber.StatusOK, list)
 	})
 
-	// GET /reports/student/:id (Individual Stats)
-	reportGroup.Get("/student/:id", func(c *fiber.Ctx) error {
-		studentID := c.Params("id") // User ID or Student ID logic depends on implementation
+	// GET /students/:id
+	studentGroup.Get("/:id", func(c *fiber.Ctx) error {
+		// Note: ID disini bisa StudentID (UUID) atau NIM, sesuaikan dengan logic service
+		// Service GetByID expect UUID table ID
+		id := c.Params("id")
 		ctx, cancel := timeoutContext(c)
 		defer cancel()
-
-		stats, err := s.Report.GetStudentStatistics(ctx, studentID)
+		st, err := s.Student.GetByID(ctx, id) 
 		if err != nil {
 			return utils.JSONError(c, fiber.StatusNotFound, err.Error())
 		}
-		return utils.JSONSuccess(c, fiber.StatusOK, stats)
+		return utils.JSONSuccess(c, fiber.StatusOK, st)
+	})
+
+	// PUT /students/:id/advisor (Set Advisor) - Admin Only
+	perms.Handle(studentGroup, fiber.MethodPut, "/:id/advisor", "student:manage", func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		var req struct { AdvisorID string `json:"advisor_id"` }
+		if err := c.BodyParser(&req); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+		}
+		
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+		
+		if err := s.Student.UpdateAdvisor(ctx, id, &req.AdvisorID); err != nil {
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Advisor updated")
 	})
-}
\ No newline at end of file
+
+	// DELETE /students/:id (pindah ke trash)
+	perms.Handle(studentGroup, fiber.MethodDelete, "/:id", "student:manage", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Trash.MoveToTrash(ctx, currentActor(c), pgModel.TrashStudents, c.Params("id"), nil); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Student moved to trash")
+	})
+
+	// GET /lecturers
+	lecturerGroup.Get("/", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+		list, err := s.Lecturer.ListAll(ctx)
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, list)
+	})
+
+	// GET /lecturers/:id/advisees (Mahasiswa Bimbingan)
+	lecturerGroup.Get("/:id/advisees", func(c *fiber.Ctx) error {
+		id := c.Params("id") // Lecturer ID
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+		list, err := s.Lecturer.GetAdvisees(ctx, id)
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, list)
+	})
+
+	// DELETE /lecturers/:id (pindah ke trash)
+	perms.Handle(lecturerGroup, fiber.MethodDelete, "/:id", "lecturer:manage", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Trash.MoveToTrash(ctx, currentActor(c), pgModel.TrashLecturers, c.Params("id"), nil); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Lecturer moved to trash")
+	})
+
+	// POST /lecturers/delegations (Dosen Wali mendelegasikan hak verifikasi untuk periode tertentu)
+	perms.Handle(lecturerGroup, fiber.MethodPost, "/delegations", "achievement:verify", func(c *fiber.Ctx) error {
+		var req service.DelegationRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+		}
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		d, err := s.Delegation.Create(ctx, currentActor(c), req)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusCreated, d)
+	})
+
+	// GET /lecturers/delegations (delegasi yang diberikan / diterima)
+	lecturerGroup.Get("/delegations", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		list, err := s.Delegation.ListMine(ctx, currentActor(c))
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, list)
+	})
+
+	// DELETE /lecturers/delegations/:id (cabut delegasi)
+	perms.Handle(lecturerGroup, fiber.MethodDelete, "/delegations/:id", "achievement:verify", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Delegation.Revoke(ctx, currentActor(c), c.Params("id")); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Delegation revoked")
+	})
+
+	// =========================================================================
+	// 5.4 ACHIEVEMENTS (CORE)
+	// =========================================================================
+	achGroup := api.Group("/achievements", jwtAuth)
+	trashRoutes(achGroup, pgModel.TrashAchievements)
+
+	// GET /achievements (List - filter, sort & cursor pagination)
+	// Query: status (comma separated; expired = verified yang lewat masa berlaku), student_id, advisor_id, program_study, academic_year,
+	// created_from/to, submitted_from/to, verified_from/to, category, level, type, q (judul),
+	// sort_by (created_at|updated_at|submitted_at|verified_at), order (asc|desc), cursor, limit
+	// Visibility: Admin semua, Dosen Wali hanya mahasiswa bimbingan, Mahasiswa hanya miliknya (AccessPolicy)
+	achGroup.Get("/", func(c *fiber.Ctx) error {
+		q := service.AchievementListQuery{
+			Statuses:     utils.GetQueryList(c, "status"),
+			StudentID:    c.Query("student_id"),
+			AdvisorID:    c.Query("advisor_id"),
+			ProgramStudy: c.Query("program_study"),
+			AcademicYear: c.Query("academic_year"),
+			Category:     c.Query("category"),
+			Level:        c.Query("level"),
+			Type:         c.Query("type"),
+			Title:        c.Query("q"),
+			SortBy:       c.Query("sort_by"),
+			SortOrder:    c.Query("order"),
+			Cursor:       c.Query("cursor"),
+			Limit:        utils.GetQueryInt(c, "limit", 20),
+		}
+		var err error
+		dates := []struct {
+			key      string
+			endOfDay bool
+			dst      **time.Time
+		}{
+			{"created_from", false, &q.CreatedFrom}, {"created_to", true, &q.CreatedTo},
+			{"submitted_from", false, &q.SubmittedFrom}, {"submitted_to", true, &q.SubmittedTo},
+			{"verified_from", false, &q.VerifiedFrom}, {"verified_to", true, &q.VerifiedTo},
+		}
+		for _, d := range dates {
+			if *d.dst, err = utils.GetQueryTime(c, d.key, d.endOfDay); err != nil {
+				return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
+			}
+		}
+
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+		page, err := s.Achievement.GetAllAchievements(ctx, currentActor(c), q)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidSortField) {
+				return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
+			}
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, page)
+	})
+
+	// POST /achievements (Create Draft - Mahasiswa)
+	perms.Handle(achGroup, fiber.MethodPost, "/", "achievement:create", func(c *fiber.Ctx) error {
+		userID := c.Locals(middleware.LocalsUserID).(string)
+		var doc mongoModel.Achievement
+		if err := c.BodyParser(&doc); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+		}
+
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		result, err := s.Achievement.CreateDraft(ctx, userID, &doc)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusCreated, result)
+	})
+
+	// GET /achievements/invitations (Undangan tim yang belum dijawab - Mahasiswa)
+	achGroup.Get("/invitations", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		list, err := s.Achievement.ListInvitations(ctx, currentActor(c))
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, list)
+	})
+
+	// GET /achievements/comments/unread (Jumlah komentar review yang belum dibaca, per prestasi)
+	achGroup.Get("/comments/unread", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		counts, err := s.Achievement.UnreadComments(ctx, currentActor(c))
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, counts)
+	})
+
+	// setAchievementETag: versi reference + versi dokumen saat ini, sama dengan ETag dari GET /achievements/:id.
+	// Dokumen Mongo diperbarui lewat outbox; jika versinya tidak bisa dibaca, header ETag tidak dikirim.
+	setAchievementETag := func(c *fiber.Ctx, ctx context.Context, ref *pgModel.AchievementReference) {
+		docVersion, err := s.Achievement.DocumentVersion(ctx, ref)
+		if err != nil {
+			log.Printf("achievement %s: read document version: %v", ref.ID, err)
+			return
+		}
+		c.Set(fiber.HeaderETag, utils.ETag(ref.Version, docVersion))
+	}
+
+	// GET /achievements/:id (Detail; ETag = versi reference + versi dokumen, If-None-Match -> 304)
+	achGroup.Get("/:id", func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		mongoData, pgRef, err := s.Achievement.GetDetail(ctx, currentActor(c), id)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusNotFound)
+		}
+		// dokumen Mongo diperbarui lewat outbox, jadi versinya ikut menentukan ETag
+		docVersion := 0
+		if mongoData != nil {
+			docVersion = mongoData.Version
+		}
+		if utils.NotModified(c, utils.ETag(pgRef.Version, docVersion)) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, fiber.Map{
+			"reference": pgRef,
+			"detail":    mongoData,
+			"status":    service.EffectiveStatus(pgRef, time.Now()), // verified, or expired past the expiry date
+		})
+	})
+
+	// PUT /achievements/:id (Update Draft / revisi prestasi yang ditolak - Mahasiswa)
+	perms.Handle(achGroup, fiber.MethodPut, "/:id", "achievement:update", func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		userID := c.Locals(middleware.LocalsUserID).(string)
+		var updates map[string]interface{}
+		if err := c.BodyParser(&updates); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+		}
+		ifMatch, err := utils.IfMatchVersion(c)
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
+		}
+
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		ref, err := s.Achievement.UpdateDraft(ctx, id, userID, updates, ifMatch)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		setAchievementETag(c, ctx, ref)
+		return utils.JSONSuccess(c, fiber.StatusOK, "Draft updated")
+	})
+
+	// Perpindahan status mengikuti workflow (config/workflow.json); permission & guard dicek di service
+	// DELETE /achievements/:id (Delete Draft - Mahasiswa; If-Match opsional)
+	achGroup.Delete("/:id", func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ifMatch, err := utils.IfMatchVersion(c)
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
+		}
+
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Achievement.DeleteDraft(ctx, id, currentActor(c), ifMatch); err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Draft deleted")
+	})
+
+	// POST /achievements/:id/submit (Submit for Verification - Mahasiswa; rejected -> round baru)
+	// Jika mirip prestasi lain (duplikat), 409 + daftar kandidat; kirim ulang dengan {"override_reason":"..."}
+	achGroup.Post("/:id/submit", func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		var req struct {
+			OverrideReason string `json:"override_reason"`
+		}
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+			}
+		}
+
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Achievement.Submit(ctx, id, currentActor(c), req.OverrideReason); err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Achievement submitted")
+	})
+
+	// POST /achievements/:id/verify (Verify - Dosen Wali mahasiswa ybs / delegasi / override Admin)
+	achGroup.Post("/:id/verify", func(c *fiber.Ctx) error {
+		id := c.Params("id")
+
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Achievement.Verify(ctx, id, currentActor(c)); err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Achievement verified")
+	})
+
+	// POST /achievements/:id/reject (Reject - Dosen Wali mahasiswa ybs / delegasi / override Admin)
+	achGroup.Post("/:id/reject", func(c *fiber.Ctx) error {
+		id := c.Params("id")
+
+		var req struct { Note string `json:"note"` }
+		if err := c.BodyParser(&req); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Note is required")
+		}
+
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Achievement.Reject(ctx, id, currentActor(c), req.Note); err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Achievement rejected")
+	})
+
+	// POST /achievements/bulk (Verifikasi / tolak banyak prestasi sekaligus - Dosen Wali)
+	// body: {"action":"verify|reject","ids":["..."],"note":"wajib untuk reject"}; hasil per item
+	perms.Handle(achGroup, fiber.MethodPost, "/bulk", "achievement:verify", func(c *fiber.Ctx) error {
+		var req service.BulkRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+		}
+		// Tanpa timeout global: tiap item dibatasi timeout-nya sendiri di service
+		res, err := s.Achievement.Bulk(c.Context(), currentActor(c), req)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, res)
+	})
+
+	// GET /achievements/:id/actions (aksi workflow yang boleh dilakukan pemanggil saat ini)
+	achGroup.Get("/:id/actions", func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		actions, err := s.Achievement.AllowedActions(ctx, currentActor(c), id)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, actions)
+	})
+
+	// POST /achievements/:id/transitions/:action (aksi workflow apa pun, mis. under_review dari config)
+	achGroup.Post("/:id/transitions/:action", func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		var input service.TransitionInput
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&input); err != nil {
+				return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+			}
+		}
+		ifMatch, err := utils.IfMatchVersion(c)
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
+		}
+		input.IfMatch = ifMatch
+
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		ref, err := s.Achievement.Transition(ctx, id, currentActor(c), c.Params("action"), input)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		setAchievementETag(c, ctx, ref)
+		return utils.JSONSuccess(c, fiber.StatusOK, ref)
+	})
+
+	// sendAttachment streams a stored file; fasthttp closes the reader when done
+	sendAttachment := func(c *fiber.Ctx, att *mongoModel.Attachment, rc io.ReadCloser) error {
+		c.Attachment(att.FileName)
+		c.Set(fiber.HeaderContentType, att.MimeType)
+		c.Set("X-Content-SHA256", att.SHA256)
+		return c.SendStream(rc, int(att.Size))
+	}
+
+	// POST /achievements/:id/attachments (Upload lampiran, multipart field "file" - Mahasiswa pemilik)
+	perms.Handle(achGroup, fiber.MethodPost, "/:id/attachments", "achievement:update", func(c *fiber.Ctx) error {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "File is required (multipart field \"file\")")
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, err.Error())
+		}
+		defer f.Close()
+
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		att, err := s.Achievement.AddAttachment(ctx, currentActor(c), c.Params("id"), service.AttachmentUpload{
+			FileName: fh.Filename, Size: fh.Size, Content: f,
+		})
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusCreated, att)
+	})
+
+	// GET /achievements/:id/attachments/:attId (Download - siapa pun yang boleh melihat prestasi)
+	achGroup.Get("/:id/attachments/:attId", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		att, rc, err := s.Achievement.OpenAttachment(ctx, currentActor(c), c.Params("id"), c.Params("attId"))
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return sendAttachment(c, att, rc)
+	})
+
+	// GET /achievements/:id/attachments/:attId/url (Signed URL berumur pendek, tanpa bearer token)
+	achGroup.Get("/:id/attachments/:attId/url", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		link, err := s.Achievement.AttachmentURL(ctx, currentActor(c), c.Params("id"), c.Params("attId"))
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, link)
+	})
+
+	// DELETE /achievements/:id/attachments/:attId (Hapus lampiran - Mahasiswa pemilik)
+	perms.Handle(achGroup, fiber.MethodDelete, "/:id/attachments/:attId", "achievement:update", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Achievement.RemoveAttachment(ctx, currentActor(c), c.Params("id"), c.Params("attId")); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Attachment deleted")
+	})
+
+	// GET /attachments/download?ref=&att=&exp=&sig= (Signed URL; tanpa JWT)
+	api.Get("/attachments/download", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		att, rc, err := s.Achievement.OpenSignedAttachment(ctx, c.Query("ref"), c.Query("att"), c.Query("exp"), c.Query("sig"))
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return sendAttachment(c, att, rc)
+	})
+
+	// GET /achievements/:id/versions (Riwayat versi dokumen, terlama dulu)
+	achGroup.Get("/:id/versions", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		list, err := s.Achievement.ListVersions(ctx, currentActor(c), c.Params("id"))
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, list)
+	})
+
+	// GET /achievements/:id/versions/diff?from=1&to=3 (to kosong = versi sekarang)
+	achGroup.Get("/:id/versions/diff", func(c *fiber.Ctx) error {
+		from := utils.GetQueryInt(c, "from", -1)
+		if from < 0 {
+			return utils.JSONError(c, fiber.StatusBadRequest, "from is required")
+		}
+		to := utils.GetQueryInt(c, "to", -1)
+
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		diff, err := s.Achievement.DiffVersions(ctx, currentActor(c), c.Params("id"), from, to)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, diff)
+	})
+
+	// GET /achievements/:id/versions/:version (Isi dokumen pada versi tertentu)
+	achGroup.Get("/:id/versions/:version", func(c *fiber.Ctx) error {
+		version, err := c.ParamsInt("version")
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid version")
+		}
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		doc, err := s.Achievement.GetVersion(ctx, currentActor(c), c.Params("id"), version)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, doc)
+	})
+
+	// POST /achievements/:id/versions/:version/restore (Kembalikan draft ke versi lama - Mahasiswa pemilik)
+	perms.Handle(achGroup, fiber.MethodPost, "/:id/versions/:version/restore", "achievement:update", func(c *fiber.Ctx) error {
+		version, err := c.ParamsInt("version")
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid version")
+		}
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Achievement.RestoreVersion(ctx, currentActor(c), c.Params("id"), version); err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Draft restored")
+	})
+
+	// GET /achievements/:id/members (Anggota tim, ketua dulu)
+	achGroup.Get("/:id/members", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		list, err := s.Achievement.ListMembers(ctx, currentActor(c), c.Params("id"))
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, list)
+	})
+
+	// POST /achievements/:id/members (Undang anggota tim - ketua, selama masih bisa diedit)
+	perms.Handle(achGroup, fiber.MethodPost, "/:id/members", "achievement:update", func(c *fiber.Ctx) error {
+		var req struct {
+			StudentID string `json:"student_id"`
+		}
+		if err := c.BodyParser(&req); err != nil || req.StudentID == "" {
+			return utils.JSONError(c, fiber.StatusBadRequest, "student_id is required")
+		}
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		m, err := s.Achievement.InviteMember(ctx, currentActor(c), c.Params("id"), req.StudentID)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusCreated, m)
+	})
+
+	// POST /achievements/:id/members/accept & /decline (Jawab undangan - Mahasiswa yang diundang)
+	for _, answer := range []struct {
+		path   string
+		accept bool
+	}{{"accept", true}, {"decline", false}} {
+		answer := answer
+		achGroup.Post("/:id/members/"+answer.path, func(c *fiber.Ctx) error {
+			ctx, cancel := timeoutContext(c)
+			defer cancel()
+
+			m, err := s.Achievement.RespondInvitation(ctx, currentActor(c), c.Params("id"), answer.accept)
+			if err != nil {
+				return serviceError(c, err, fiber.StatusBadRequest)
+			}
+			return utils.JSONSuccess(c, fiber.StatusOK, m)
+		})
+	}
+
+	// DELETE /achievements/:id/members/:studentId (Ketua mengeluarkan anggota, atau anggota keluar)
+	achGroup.Delete("/:id/members/:studentId", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Achievement.RemoveMember(ctx, currentActor(c), c.Params("id"), c.Params("studentId")); err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Member removed")
+	})
+
+	// GET /achievements/:id/comments (Diskusi review per thread, semua round - pemilik, dosen wali, admin)
+	achGroup.Get("/:id/comments", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		threads, err := s.Achievement.ListComments(ctx, currentActor(c), c.Params("id"))
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, threads)
+	})
+
+	// POST /achievements/:id/comments body: {"body":"...","field_path":"details.rank","parent_id":"..."}
+	achGroup.Post("/:id/comments", func(c *fiber.Ctx) error {
+		var req service.CommentRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+		}
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		comment, err := s.Achievement.AddComment(ctx, currentActor(c), c.Params("id"), req)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusCreated, comment)
+	})
+
+	// POST /achievements/:id/comments/read (Tandai diskusi sudah dibaca)
+	achGroup.Post("/:id/comments/read", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Achievement.MarkCommentsRead(ctx, currentActor(c), c.Params("id")); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Comments marked as read")
+	})
+
+	// GET /achievements/:id/duplicates (Prestasi lain milik mahasiswa / tim yang mirip - bahan verifikator)
+	achGroup.Get("/:id/duplicates", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		matches, err := s.Achievement.FindDuplicates(ctx, currentActor(c), c.Params("id"))
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, matches)
+	})
+
+	// POST /achievements/:id/renew (Perpanjang sertifikat: draft baru yang terhubung ke sertifikat lama - Mahasiswa)
+	perms.Handle(achGroup, fiber.MethodPost, "/:id/renew", "achievement:create", func(c *fiber.Ctx) error {
+		var doc mongoModel.Achievement
+		if err := c.BodyParser(&doc); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+		}
+
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		ref, err := s.Achievement.RenewCertificate(ctx, currentActor(c), c.Params("id"), &doc)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusCreated, ref)
+	})
+
+	// GET /achievements/:id/history (History Log + review rounds)
+	achGroup.Get("/:id/history", func(c *fiber.Ctx) error {
+		id := c.Params("id")
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		hist, err := s.Report.GetAchievementHistory(ctx, currentActor(c), id)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, hist)
+	})
+
+	// =========================================================================
+	// ACHIEVEMENT SCHEMAS (JSON Schema details per kategori)
+	// =========================================================================
+	schemaGroup := api.Group("/achievement-schemas", jwtAuth)
+
+	// GET /achievement-schemas (semua user login; dipakai form prestasi)
+	schemaGroup.Get("/", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		list, err := s.Schema.List(ctx)
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, list)
+	})
+
+	// GET /achievement-schemas/:category
+	schemaGroup.Get("/:category", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		sc, err := s.Schema.Get(ctx, c.Params("category"))
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, sc)
+	})
+
+	// PUT /achievement-schemas/:category (buat / ganti schema - Admin)
+	perms.Handle(schemaGroup, fiber.MethodPut, "/:category", "schema:manage", func(c *fiber.Ctx) error {
+		var req service.AchievementSchemaRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+		}
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		sc, err := s.Schema.Put(ctx, currentActor(c), c.Params("category"), req)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, sc)
+	})
+
+	// DELETE /achievement-schemas/:category (details kategori ini kembali bebas - Admin)
+	perms.Handle(schemaGroup, fiber.MethodDelete, "/:category", "schema:manage", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Schema.Delete(ctx, currentActor(c), c.Params("category")); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Schema deleted")
+	})
+
+	// =========================================================================
+	// SCORING (aturan poin SKPI dan batas poin per semester)
+	// =========================================================================
+	scoringGroup := api.Group("/scoring", jwtAuth)
+
+	// GET /scoring/rules (semua user login)
+	scoringGroup.Get("/rules", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		list, err := s.Scoring.ListRules(ctx)
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, list)
+	})
+
+	// POST /scoring/rules (Admin; poin prestasi terverifikasi dihitung ulang di background)
+	perms.Handle(scoringGroup, fiber.MethodPost, "/rules", "scoring:manage", func(c *fiber.Ctx) error {
+		var req service.ScoringRuleRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+		}
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		rule, res, err := s.Scoring.CreateRule(ctx, currentActor(c), req)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusCreated, fiber.Map{"rule": rule, "recalculation": res})
+	})
+
+	// PUT /scoring/rules/:id (Admin; dihitung ulang di background)
+	perms.Handle(scoringGroup, fiber.MethodPut, "/rules/:id", "scoring:manage", func(c *fiber.Ctx) error {
+		var req service.ScoringRuleRequest
+		if err := c.BodyParser(&req); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+		}
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		rule, res, err := s.Scoring.UpdateRule(ctx, currentActor(c), c.Params("id"), req)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, fiber.Map{"rule": rule, "recalculation": res})
+	})
+
+	// DELETE /scoring/rules/:id (Admin; dihitung ulang di background)
+	perms.Handle(scoringGroup, fiber.MethodDelete, "/rules/:id", "scoring:manage", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		res, err := s.Scoring.DeleteRule(ctx, currentActor(c), c.Params("id"))
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, fiber.Map{"recalculation": res})
+	})
+
+	// POST /scoring/recalculate (Admin; antrekan hitung ulang poin semua prestasi terverifikasi)
+	perms.Handle(scoringGroup, fiber.MethodPost, "/recalculate", "scoring:manage", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		return utils.JSONSuccess(c, fiber.StatusAccepted, s.Scoring.Recalculate(ctx, currentActor(c)))
+	})
+
+	// GET /scoring/recalculate (Admin; status hitung ulang: queued, running, hasil terakhir)
+	perms.Handle(scoringGroup, fiber.MethodGet, "/recalculate", "scoring:manage", func(c *fiber.Ctx) error {
+		return utils.JSONSuccess(c, fiber.StatusOK, s.Scoring.RecalculationStatus())
+	})
+
+	// GET /scoring/caps (semua user login)
+	scoringGroup.Get("/caps", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		list, err := s.Scoring.ListCaps(ctx)
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, list)
+	})
+
+	// PUT /scoring/caps/:category (Admin) body: {"max_points": 150}
+	perms.Handle(scoringGroup, fiber.MethodPut, "/caps/:category", "scoring:manage", func(c *fiber.Ctx) error {
+		var req struct {
+			MaxPoints float64 `json:"max_points"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return utils.JSONError(c, fiber.StatusBadRequest, "Invalid body")
+		}
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		cp, err := s.Scoring.PutCap(ctx, currentActor(c), c.Params("category"), req.MaxPoints)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusBadRequest)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, cp)
+	})
+
+	// DELETE /scoring/caps/:category (Admin)
+	perms.Handle(scoringGroup, fiber.MethodDelete, "/caps/:category", "scoring:manage", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		if err := s.Scoring.DeleteCap(ctx, currentActor(c), c.Params("category")); err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, "Cap deleted")
+	})
+
+	// =========================================================================
+	// 5.8 REPORTS & ANALYTICS
+	// =========================================================================
+	reportGroup := api.Group("/reports", jwtAuth)
+
+	// GET /reports/statistics (Global Stats - Admin/Dosen)
+	perms.Handle(reportGroup, fiber.MethodGet, "/statistics", "report:view", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		stats, err := s.Report.GetAllAchievementsStatistics(ctx)
+		if err != nil {
+			return utils.JSONError(c, fiber.StatusInternalServerError, err.Error())
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, stats)
+	})
+
+	// GET /reports/reconciliation (Drift Mongo <-> Postgres - Admin)
+	perms.Handle(reportGroup, fiber.MethodGet, "/reconciliation", "report:reconcile", func(c *fiber.Ctx) error {
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		report, err := s.Achievement.Reconcile(ctx)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusInternalServerError)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, report)
+	})
+
+	// GET /reports/student/:id (Individual Stats)
+	reportGroup.Get("/student/:id", func(c *fiber.Ctx) error {
+		studentID := c.Params("id") // students.id; mahasiswa hanya dirinya, dosen wali hanya bimbingannya
+		ctx, cancel := timeoutContext(c)
+		defer cancel()
+
+		stats, err := s.Report.GetStudentStatistics(ctx, currentActor(c), studentID)
+		if err != nil {
+			return serviceError(c, err, fiber.StatusNotFound)
+		}
+		return utils.JSONSuccess(c, fiber.StatusOK, stats)
+	})
+
+	return perms
+}
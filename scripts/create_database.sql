@@ -1,3 +1,6 @@
+-- LEGACY: skema alumni/pekerjaan lama. Skema aplikasi saat ini ada di database/migrations
+-- dan dijalankan dengan: go run . migrate up
+
 -- Create database and tables for alumni management system
 CREATE DATABASE alumni_db;
 
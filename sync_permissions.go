@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"clean-arch/app/service"
+	"clean-arch/middleware"
+	"clean-arch/route"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// runSyncPermissions handles `sync-permissions`: permissions required by a route or a
+// workflow transition are created when missing, stored ones nothing requires are listed.
+// Returns the process exit code.
+func runSyncPermissions(app *fiber.App, services *service.Services, perms *middleware.PermissionRegistry) int {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	res, err := route.SyncPermissions(ctx, app, services, perms)
+	if err != nil {
+		log.Printf("sync-permissions: %v", err)
+		return 1
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PERMISSION\tSTATUS")
+	for _, name := range res.Created {
+		fmt.Fprintf(w, "%s\tcreated\n", name)
+	}
+	for _, name := range res.Orphaned {
+		fmt.Fprintf(w, "%s\torphaned (not required by any route or transition)\n", name)
+	}
+	_ = w.Flush()
+	log.Printf("%d permissions required, %d created, %d orphaned", res.Declared, len(res.Created), len(res.Orphaned))
+	return 0
+}
+
+// syncPermissionsOnStart runs the same sync at startup (PERMISSION_SYNC_ON_START); failures
+// are logged, not fatal.
+func syncPermissionsOnStart(app *fiber.App, services *service.Services, perms *middleware.PermissionRegistry) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	res, err := route.SyncPermissions(ctx, app, services, perms)
+	if err != nil {
+		log.Printf("permission sync failed: %v", err)
+		return
+	}
+	for _, name := range res.Created {
+		log.Printf("permission sync: created %s", name)
+	}
+	if len(res.Orphaned) > 0 {
+		log.Printf("permission sync: not required by any route or transition: %v", res.Orphaned)
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ETag formats a row version as a strong entity tag ("3"). Resources assembled from more
+// than one versioned store pass every version ("3.5"); the first one is the row version
+// that If-Match is checked against.
+func ETag(versions ...int) string {
+	parts := make([]string, len(versions))
+	for i, v := range versions {
+		parts[i] = strconv.Itoa(v)
+	}
+	return `"` + strings.Join(parts, ".") + `"`
+}
+
+// IfMatchVersion parses the If-Match header into the expected row version, the first
+// version of the tag. It returns nil when the header is absent or "*" (no precondition).
+func IfMatchVersion(c *fiber.Ctx) (*int, error) {
+	v := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if v == "" || v == "*" {
+		return nil, nil
+	}
+	if strings.Contains(v, ",") {
+		return nil, errors.New("If-Match must contain a single entity tag")
+	}
+	v = strings.Trim(strings.TrimPrefix(v, "W/"), `"`)
+	if i := strings.IndexByte(v, '.'); i >= 0 {
+		v = v[:i]
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, errors.New("invalid If-Match entity tag")
+	}
+	return &n, nil
+}
+
+// NotModified sets the ETag header and reports whether If-None-Match already names it,
+// in which case the caller should answer 304 without a body.
+func NotModified(c *fiber.Ctx, etag string) bool {
+	c.Set(fiber.HeaderETag, etag)
+	for _, t := range strings.Split(c.Get(fiber.HeaderIfNoneMatch), ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == etag {
+			return true
+		}
+	}
+	return false
+}
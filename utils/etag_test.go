@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// withRequest runs fn inside a fiber handler for a GET with the given headers
+func withRequest(t *testing.T, headers map[string]string, fn func(c *fiber.Ctx) error) {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/", fn)
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestETag(t *testing.T) {
+	tests := []struct {
+		versions []int
+		want     string
+	}{
+		{[]int{3}, `"3"`},
+		{[]int{3, 5}, `"3.5"`},
+		{[]int{0}, `"0"`},
+	}
+	for _, tt := range tests {
+		if got := ETag(tt.versions...); got != tt.want {
+			t.Errorf("ETag(%v) = %s, want %s", tt.versions, got, tt.want)
+		}
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    int // -1: no precondition
+		wantErr bool
+	}{
+		{"", -1, false},
+		{"*", -1, false},
+		{`"7"`, 7, false},
+		{` "7" `, 7, false},
+		{`W/"7"`, 7, false},
+		{`"7.2"`, 7, false}, // tag of a resource with a document version: the row version counts
+		{"7", 7, false},
+		{`"7", "8"`, 0, true},
+		{`"seven"`, 0, true},
+		{`".2"`, 0, true},
+	}
+	for _, tt := range tests {
+		withRequest(t, map[string]string{fiber.HeaderIfMatch: tt.header}, func(c *fiber.Ctx) error {
+			got, err := IfMatchVersion(c)
+			switch {
+			case tt.wantErr:
+				if err == nil {
+					t.Errorf("If-Match %q: got %v, want an error", tt.header, *got)
+				}
+			case err != nil:
+				t.Errorf("If-Match %q: unexpected error %v", tt.header, err)
+			case tt.want == -1 && got != nil:
+				t.Errorf("If-Match %q: got %d, want no precondition", tt.header, *got)
+			case tt.want != -1 && (got == nil || *got != tt.want):
+				t.Errorf("If-Match %q: got %v, want %d", tt.header, got, tt.want)
+			}
+			return nil
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		header string
+		etag   string
+		want   bool
+	}{
+		{"", `"3"`, false},
+		{`"3"`, `"3"`, true},
+		{`"2"`, `"3"`, false},
+		{`W/"3"`, `"3"`, true},
+		{`"1", "3"`, `"3"`, true},
+		{"*", `"3"`, true},
+		{`"3"`, `"3.5"`, false}, // the document changed since the client's copy
+		{`"3.5"`, `"3.5"`, true},
+	}
+	for _, tt := range tests {
+		withRequest(t, map[string]string{fiber.HeaderIfNoneMatch: tt.header}, func(c *fiber.Ctx) error {
+			if got := NotModified(c, tt.etag); got != tt.want {
+				t.Errorf("If-None-Match %q vs %s: got %v, want %v", tt.header, tt.etag, got, tt.want)
+			}
+			if got := string(c.Response().Header.Peek(fiber.HeaderETag)); got != tt.etag {
+				t.Errorf("ETag header = %s, want %s", got, tt.etag)
+			}
+			return nil
+		})
+	}
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -22,6 +24,15 @@ func JSONError(c *fiber.Ctx, code int, message string) error {
 	})
 }
 
+// JSONErrorDetails error response with a list of details (e.g. validation errors per field)
+func JSONErrorDetails(c *fiber.Ctx, code int, message string, details interface{}) error {
+	return c.Status(code).JSON(fiber.Map{
+		"status":  "error",
+		"message": message,
+		"errors":  details,
+	})
+}
+
 // GetQueryInt helper: get int query with fallback
 func GetQueryInt(c *fiber.Ctx, key string, fallback int) int {
 	if v := c.Query(key); v != "" {
@@ -34,3 +45,39 @@ func GetQueryInt(c *fiber.Ctx, key string, fallback int) int {
 	}
 	return fallback
 }
+
+// GetQueryTime parses a date (YYYY-MM-DD) or RFC3339 query param.
+// When endOfDay is true a plain date is moved to the start of the next day,
+// so it can be used as an exclusive upper bound.
+func GetQueryTime(c *fiber.Ctx, key string, endOfDay bool) (*time.Time, error) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: use YYYY-MM-DD or RFC3339", key)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// GetQueryList splits a comma separated query param ("a,b,c"), dropping empty parts
+func GetQueryList(c *fiber.Ctx, key string) []string {
+	v := c.Query(key)
+	if v == "" {
+		return nil
+	}
+	var out []string
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}